@@ -13,6 +13,9 @@ import (
 	"order-matching-engine/internal/api"
 	"order-matching-engine/internal/config"
 	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/margin"
 )
 
 func main() {
@@ -22,7 +25,36 @@ func main() {
 	cfg := config.Load()
 
 	eng := engine.NewMatchingEngine()
+	eng.Risk.SetDefaultLimits(cfg.RiskLimits)
+	if err := eng.Fees.SetSchedule(cfg.FeeSchedule); err != nil {
+		log.Fatalf("Invalid fee schedule: %v", err)
+	}
+	if cfg.InstrumentsFile != "" {
+		reg, err := instruments.Load(cfg.InstrumentsFile)
+		if err != nil {
+			log.Fatalf("Failed to load instruments: %v", err)
+		}
+		eng.Instruments = reg
+		eng.ScheduleExpiries()
+	}
+	if cfg.LedgerEnabled {
+		eng.Ledger = ledger.New()
+	}
+	if cfg.MarginEnabled {
+		eng.Margin = margin.NewManager()
+		stopLiquidations := eng.StartLiquidationMonitor(cfg.LiquidationInterval)
+		defer stopLiquidations()
+	}
 	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionHeartbeat = cfg.SessionHeartbeat
+	apiLayer.SessionGrace = cfg.SessionGrace
+	apiLayer.SessionTokens = cfg.SessionTokens
+	apiLayer.SessionOpen = cfg.SessionOpen
+	if _, err := apiLayer.Prices.SetDefaultConfig(cfg.MarkPrice); err != nil {
+		log.Fatalf("Invalid mark price config: %v", err)
+	}
+	stopPrices := apiLayer.Prices.Start(cfg.MarkPriceInterval, cfg.IndexFile)
+	defer stopPrices()
 
 	router := apiLayer.Router()
 
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/ledger"
+)
+
+type transferRequest struct {
+	Asset  string `json:"asset"`
+	Amount int64  `json:"amount"`
+}
+
+// GET /api/v1/accounts/{account}/balances
+func (a *API) getBalances(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Ledger == nil {
+		http.Error(w, "Ledger not enabled", http.StatusNotFound)
+		return
+	}
+	account := chi.URLParam(r, "account")
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"account":  account,
+		"balances": a.Engine.Ledger.Balances(account),
+	})
+}
+
+// POST /api/v1/accounts/{account}/deposit
+func (a *API) deposit(w http.ResponseWriter, r *http.Request) {
+	a.transfer(w, r, a.Engine.Ledger.Deposit)
+}
+
+// POST /api/v1/accounts/{account}/withdraw
+func (a *API) withdraw(w http.ResponseWriter, r *http.Request) {
+	a.transfer(w, r, a.Engine.Ledger.Withdraw)
+}
+
+func (a *API) transfer(w http.ResponseWriter, r *http.Request, apply func(account, asset string, amount int64) error) {
+	if a.Engine.Ledger == nil {
+		http.Error(w, "Ledger not enabled", http.StatusNotFound)
+		return
+	}
+	account := chi.URLParam(r, "account")
+
+	var req transferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := apply(account, req.Asset, req.Amount); err != nil {
+		switch err {
+		case ledger.ErrInvalidAmount:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case ledger.ErrInsufficientFunds:
+			writeReject(w, "INSUFFICIENT_FUNDS", err.Error())
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"account": account,
+		"asset":   req.Asset,
+		"balance": a.Engine.Ledger.Balance(account, req.Asset),
+	})
+}
+
+// GET /api/v1/admin/settlement-failures
+func (a *API) listSettlementFailures(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"failures": a.Engine.SettlementFailures()})
+}
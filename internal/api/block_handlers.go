@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"order-matching-engine/internal/engine"
+)
+
+// POST /api/v1/block-trades
+func (a *API) submitBlockTrade(w http.ResponseWriter, r *http.Request) {
+	var req engine.BlockTrade
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	trade, err := a.Engine.SubmitBlockTrade(req)
+	if err != nil {
+		switch err {
+		case engine.ErrInvalidOrderData, engine.ErrAccountRequired:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeReject(w, orderRejectCode(err), err.Error())
+		}
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(trade)
+}
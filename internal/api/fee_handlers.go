@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/fees"
+)
+
+// GET /api/v1/fees/{account}?from=MS&to=MS
+func (a *API) getFeeReport(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	var from, to int64
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	maker, taker := a.Engine.Fees.Rates(account)
+	json.NewEncoder(w).Encode(map[string]any{
+		"report":     a.Engine.Fees.Report(account, from, to),
+		"volume_30d": a.Engine.Fees.Volume30d(account),
+		"maker_bps":  maker,
+		"taker_bps":  taker,
+	})
+}
+
+// GET /api/v1/admin/fees/schedule
+func (a *API) getFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(a.Engine.Fees.Schedule())
+}
+
+// PUT /api/v1/admin/fees/schedule
+func (a *API) setFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	var s fees.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	if err := a.Engine.Fees.SetSchedule(s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(a.Engine.Fees.Schedule())
+}
+
+// PUT /api/v1/admin/fees/accounts/{account}
+func (a *API) setFeeOverride(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	var o fees.Override
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	if err := a.Engine.Fees.SetOverride(account, o); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"account":  account,
+		"override": o,
+	})
+}
+
+// DELETE /api/v1/admin/fees/accounts/{account}
+func (a *API) clearFeeOverride(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+	a.Engine.Fees.ClearOverride(account)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+// orderGroupRequest is an OCO (legs) or a bracket (entry and exit prices).
+type orderGroupRequest struct {
+	Type engine.GroupType `json:"type"`
+
+	Legs []*common.Order `json:"legs"`
+
+	Entry         *common.Order `json:"entry"`
+	TakeProfit    int64         `json:"take_profit"`
+	StopLoss      int64         `json:"stop_loss"`
+	StopLossLimit int64         `json:"stop_loss_limit"`
+}
+
+// POST /api/v1/order-groups
+func (a *API) placeOrderGroup(w http.ResponseWriter, r *http.Request) {
+	var req orderGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		group  *engine.OrderGroup
+		orders []*common.Order
+		trades []*common.Trade
+		err    error
+	)
+	switch req.Type {
+	case engine.GroupOCO:
+		for _, leg := range req.Legs {
+			if leg != nil {
+				leg.SessionID, leg.QuoteID = "", ""
+			}
+		}
+		group, orders, trades, err = a.Engine.PlaceOCO(req.Legs)
+	case engine.GroupBracket:
+		if req.Entry == nil {
+			http.Error(w, "entry is required", http.StatusBadRequest)
+			return
+		}
+		req.Entry.SessionID, req.Entry.QuoteID = "", ""
+		var entry *common.Order
+		group, entry, trades, err = a.Engine.PlaceBracket(engine.BracketRequest{
+			Entry:         req.Entry,
+			TakeProfit:    req.TakeProfit,
+			StopLoss:      req.StopLoss,
+			StopLossLimit: req.StopLossLimit,
+		})
+		orders = []*common.Order{entry}
+	default:
+		http.Error(w, "type must be OCO or BRACKET", http.StatusBadRequest)
+		return
+	}
+	if err != nil && len(trades) > 0 {
+		// Earlier OCO legs traded before a later leg was rejected.
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":       err.Error(),
+			"reject_code": orderRejectCode(err),
+			"group":       group,
+			"orders":      orders,
+			"trades":      trades,
+		})
+		return
+	}
+	if err != nil {
+		switch err {
+		case engine.ErrInvalidOrderData, engine.ErrInsufficientLiquidity, engine.ErrAccountRequired:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeReject(w, orderRejectCode(err), err.Error())
+		}
+		return
+	}
+
+	if trades == nil {
+		trades = []*common.Trade{}
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"group":  group,
+		"orders": orders,
+		"trades": trades,
+	})
+}
+
+// GET /api/v1/order-groups/{id}
+func (a *API) getOrderGroup(w http.ResponseWriter, r *http.Request) {
+	g, ok := a.Engine.GetGroup(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "Order group not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(g)
+}
+
+// DELETE /api/v1/order-groups/{id}
+func (a *API) cancelOrderGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	switch err := a.Engine.CancelGroup(id); err {
+	case nil:
+	case engine.ErrGroupNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case engine.ErrGroupFinalized:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		writeReject(w, orderRejectCode(err), err.Error())
+		return
+	}
+
+	g, _ := a.Engine.GetGroup(id)
+	json.NewEncoder(w).Encode(g)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+)
+
+// GET /api/v1/instruments
+func (a *API) listInstruments(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Instruments == nil {
+		http.Error(w, "Instrument registry not enabled", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"instruments": a.Engine.Instruments.List(),
+	})
+}
+
+// GET /api/v1/instruments/{symbol}
+func (a *API) getInstrument(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Instruments == nil {
+		http.Error(w, "Instrument registry not enabled", http.StatusNotFound)
+		return
+	}
+	inst, ok := a.Engine.Instruments.Get(chi.URLParam(r, "symbol"))
+	if !ok {
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(inst)
+}
+
+// PUT /api/v1/admin/instruments/{symbol}
+func (a *API) upsertInstrument(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Instruments == nil {
+		http.Error(w, "Instrument registry not enabled", http.StatusNotFound)
+		return
+	}
+
+	var inst instruments.Instrument
+	if err := json.NewDecoder(r.Body).Decode(&inst); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	inst.Symbol = chi.URLParam(r, "symbol")
+
+	stored, err := a.Engine.Instruments.Upsert(inst)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.Engine.ScheduleExpiries()
+	json.NewEncoder(w).Encode(stored)
+}
+
+// DELETE /api/v1/admin/instruments/{symbol}
+func (a *API) deleteInstrument(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Instruments == nil {
+		http.Error(w, "Instrument registry not enabled", http.StatusNotFound)
+		return
+	}
+	switch err := a.Engine.Instruments.Delete(chi.URLParam(r, "symbol")); err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case instruments.ErrInstrumentInUse:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+	}
+}
+
+// GET /api/v1/settlements
+func (a *API) listSettlements(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"settlements": a.Engine.Settlements(),
+	})
+}
+
+// GET /api/v1/instruments/{symbol}/settlement
+func (a *API) getSettlement(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.Engine.Settlement(chi.URLParam(r, "symbol"))
+	if !ok {
+		http.Error(w, engine.ErrNotExpired.Error(), http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(s)
+}
+
+// PUT /api/v1/admin/instruments/{symbol}/settlement
+func (a *API) setSettlement(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Price int64 `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	s, err := a.Engine.SetSettlementPrice(chi.URLParam(r, "symbol"), req.Price)
+	switch err {
+	case nil:
+		json.NewEncoder(w).Encode(s)
+	case engine.ErrNotExpired:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
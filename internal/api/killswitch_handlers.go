@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type deadManRequest struct {
+	TimeoutMs int64 `json:"timeout_ms"`
+}
+
+type killSwitchRequest struct {
+	Active bool `json:"active"`
+}
+
+// POST /api/v1/accounts/{account}/dead-man
+func (a *API) armDeadMan(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	var req deadManRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	expires, err := a.Engine.ArmDeadManSwitch(account, time.Duration(req.TimeoutMs)*time.Millisecond)
+	if err != nil {
+		http.Error(w, "timeout_ms must be positive", http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"account_id": account,
+		"expires_at": expires.UnixMilli(),
+	})
+}
+
+// GET /api/v1/accounts/{account}/dead-man
+func (a *API) getDeadMan(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	resp := map[string]any{"account_id": account, "armed": false}
+	if expires, ok := a.Engine.DeadManSwitch(account); ok {
+		resp["armed"] = true
+		resp["expires_at"] = expires.UnixMilli()
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DELETE /api/v1/accounts/{account}/dead-man
+func (a *API) disarmDeadMan(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	if !a.Engine.DisarmDeadManSwitch(account) {
+		http.Error(w, "dead-man's switch not armed", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/v1/admin/kill-switch
+func (a *API) getKillSwitches(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(a.Engine.KillSwitches())
+}
+
+// PUT /api/v1/admin/kill-switch
+func (a *API) setGlobalKillSwitch(w http.ResponseWriter, r *http.Request) {
+	var req killSwitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	ids := a.Engine.SetGlobalKillSwitch(req.Active)
+	json.NewEncoder(w).Encode(map[string]any{
+		"active":    req.Active,
+		"cancelled": nonNil(ids),
+	})
+}
+
+// PUT /api/v1/admin/kill-switch/accounts/{account}
+func (a *API) setAccountKillSwitch(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	var req killSwitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := a.Engine.SetAccountKillSwitch(account, req.Active)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"account_id": account,
+		"active":     req.Active,
+		"cancelled":  nonNil(ids),
+	})
+}
+
+// nonNil makes an empty ID list encode as [] rather than null.
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
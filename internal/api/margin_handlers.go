@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/margin"
+)
+
+type marginTransferRequest struct {
+	Amount int64 `json:"amount"`
+}
+
+// GET /api/v1/accounts/{account}/margin
+func (a *API) getMargin(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Margin == nil {
+		http.Error(w, "Margin not enabled", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(a.Engine.MarginStatus(chi.URLParam(r, "account")))
+}
+
+// POST /api/v1/accounts/{account}/margin/deposit
+func (a *API) depositMargin(w http.ResponseWriter, r *http.Request) {
+	a.transferMargin(w, r, a.Engine.Margin.Deposit)
+}
+
+// POST /api/v1/accounts/{account}/margin/withdraw
+func (a *API) withdrawMargin(w http.ResponseWriter, r *http.Request) {
+	a.transferMargin(w, r, a.Engine.WithdrawMargin)
+}
+
+func (a *API) transferMargin(w http.ResponseWriter, r *http.Request, apply func(account string, amount int64) error) {
+	if a.Engine.Margin == nil {
+		http.Error(w, "Margin not enabled", http.StatusNotFound)
+		return
+	}
+	account := chi.URLParam(r, "account")
+
+	var req marginTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := apply(account, req.Amount); err != nil {
+		switch err {
+		case margin.ErrInvalidAmount:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case engine.ErrInsufficientMargin, margin.ErrInsufficientCollateral:
+			writeReject(w, "INSUFFICIENT_MARGIN", err.Error())
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(a.Engine.MarginStatus(account))
+}
+
+// GET /api/v1/admin/liquidations
+func (a *API) listLiquidations(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"liquidations": a.Engine.Liquidations()})
+}
+
+// GET /api/v1/admin/insurance-fund
+func (a *API) getInsuranceFund(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Margin == nil {
+		http.Error(w, "Margin not enabled", http.StatusNotFound)
+		return
+	}
+	balance, entries := a.Engine.Margin.Insurance()
+	json.NewEncoder(w).Encode(map[string]any{"balance": balance, "entries": entries})
+}
+
+// POST /api/v1/admin/insurance-fund/deposit
+func (a *API) fundInsurance(w http.ResponseWriter, r *http.Request) {
+	if a.Engine.Margin == nil {
+		http.Error(w, "Margin not enabled", http.StatusNotFound)
+		return
+	}
+	var req marginTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	entry, err := a.Engine.Margin.FundInsurance(req.Amount)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(entry)
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/common"
 )
 
 // GET /api/v1/market/ohlcv/{symbol}
@@ -28,7 +30,7 @@ func (a *API) getOHLCV(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(ohlcv)
 }
 
-// GET /api/v1/market/trades/{symbol}?limit=100
+// GET /api/v1/market/trades/{symbol}?limit=100&type=BLOCK
 func (a *API) getTrades(w http.ResponseWriter, r *http.Request) {
 	symbol := chi.URLParam(r, "symbol")
 	if symbol == "" {
@@ -44,10 +46,14 @@ func (a *API) getTrades(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	trades := a.MarketData.GetRecentTrades(symbol, limit)
+	trades := a.MarketData.GetRecentTrades(symbol, limit, common.TradeType(r.URL.Query().Get("type")))
+	public := make([]common.PublicTrade, len(trades))
+	for i, t := range trades {
+		public[i] = t.Public()
+	}
 	json.NewEncoder(w).Encode(map[string]any{
 		"symbol": symbol,
-		"trades": trades,
+		"trades": public,
 	})
 }
 
@@ -69,43 +75,10 @@ func (a *API) getDepth(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Build depth response
-	bids := make([]map[string]any, 0, levels)
-	asks := make([]map[string]any, 0, levels)
-
-	for i, price := range book.Bids.Prices {
-		if i >= levels {
-			break
-		}
-		level := book.Bids.Levels[price]
-		qty := int64(0)
-		for _, o := range level.Orders {
-			qty += o.Quantity - o.FilledQty
-		}
-		bids = append(bids, map[string]any{
-			"price":    price,
-			"quantity": qty,
-		})
-	}
-
-	for i, price := range book.Asks.Prices {
-		if i >= levels {
-			break
-		}
-		level := book.Asks.Levels[price]
-		qty := int64(0)
-		for _, o := range level.Orders {
-			qty += o.Quantity - o.FilledQty
-		}
-		asks = append(asks, map[string]any{
-			"price":    price,
-			"quantity": qty,
-		})
-	}
-
+	// Displayed quantity per price level; hidden orders are left out.
 	json.NewEncoder(w).Encode(map[string]any{
 		"symbol": symbol,
-		"bids":   bids,
-		"asks":   asks,
+		"bids":   book.Bids.Depth(levels),
+		"asks":   book.Asks.Depth(levels),
 	})
 }
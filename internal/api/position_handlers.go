@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GET /api/v1/accounts/{account}/positions
+func (a *API) getPositions(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(a.Engine.Positions.Account(chi.URLParam(r, "account")))
+}
+
+// GET /api/v1/accounts/{account}/positions/{symbol}
+func (a *API) getPosition(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.Engine.Positions.Get(chi.URLParam(r, "account"), chi.URLParam(r, "symbol"))
+	if !ok {
+		http.Error(w, "position not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+// GET /ws/account
+//
+// A private, read-only channel for one account. After a logon (checked like
+// an order-entry session) the server sends a "positions" snapshot, then a
+// "position" message whenever a trade changes one of the account's
+// positions. Clients keep the channel open with heartbeats.
+func (a *API) handleAccountWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(a.SessionHeartbeat))
+	logon, err := readSessionMessage(conn)
+	if err != nil || logon.Type != "logon" {
+		writeSessionReject(conn, "LOGON_REQUIRED", "first message must be a logon")
+		return
+	}
+	if !a.authorizeSession(logon.Account, logon.Token) {
+		writeSessionReject(conn, "UNAUTHORIZED", "invalid account or token")
+		return
+	}
+	account := logon.Account
+
+	// From here on only the client's writer touches the connection.
+	client := newWSClient(conn)
+	defer client.close()
+	a.WSHub.subscribeAccount(account, client, func() WSMessage {
+		return WSMessage{Type: "positions", Payload: a.Engine.Positions.Account(account)}
+	})
+	defer a.WSHub.unsubscribeAccount(account, client)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(a.SessionHeartbeat))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
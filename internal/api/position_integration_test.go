@@ -0,0 +1,249 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"order-matching-engine/internal/api"
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestAccountChannelStreamsOwnPositions(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionOpen = true
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/account", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.WriteJSON(map[string]any{"type": "logon", "account_id": "A"})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var snapshot sessionReply
+	if err := conn.ReadJSON(&snapshot); err != nil || snapshot.Type != "positions" {
+		t.Fatalf("expected a positions snapshot, got %+v (%v)", snapshot, err)
+	}
+
+	// B trades with C first; A must not see it.
+	for _, o := range []*common.Order{
+		{Account: "C", Symbol: "AAPL", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 10000, Quantity: 5},
+		{Account: "B", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 10000, Quantity: 5},
+		{Account: "C", Symbol: "AAPL", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 10100, Quantity: 3},
+		{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 10100, Quantity: 3},
+	} {
+		if _, _, err := eng.PlaceOrder(o); err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+	}
+
+	// The full trade comes first, then the position it changed.
+	var trade sessionReply
+	if err := conn.ReadJSON(&trade); err != nil || trade.Type != "trade" {
+		t.Fatalf("expected the account's trade, got %+v (%v)", trade, err)
+	}
+	if trade.Payload["buy_account"] != "A" || trade.Payload["sell_account"] != "C" {
+		t.Fatalf("expected the private trade to name both accounts, got %+v", trade.Payload)
+	}
+	var update sessionReply
+	if err := conn.ReadJSON(&update); err != nil || update.Type != "position" {
+		t.Fatalf("expected a position update, got %+v (%v)", update, err)
+	}
+	if update.Payload["account_id"] != "A" || update.Payload["quantity"] != float64(3) || update.Payload["avg_price"] != float64(10100) {
+		t.Fatalf("unexpected position update %+v", update.Payload)
+	}
+}
+
+func TestAccountChannelKeepsUpdateOrder(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionOpen = true
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/account", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.WriteJSON(map[string]any{"type": "logon", "account_id": "A"})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var snapshot sessionReply
+	if err := conn.ReadJSON(&snapshot); err != nil || snapshot.Type != "positions" {
+		t.Fatalf("expected a positions snapshot first, got %+v (%v)", snapshot, err)
+	}
+
+	const fills = 50
+	for i := 0; i < fills; i++ {
+		eng.PlaceOrder(&common.Order{Account: "B", Symbol: "AAPL", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 10000, Quantity: 1})
+		eng.PlaceOrder(&common.Order{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 10000, Quantity: 1})
+	}
+
+	for want := 1; want <= fills; want++ {
+		var update sessionReply
+		if err := conn.ReadJSON(&update); err != nil || update.Type != "trade" {
+			t.Fatalf("expected a trade, got %+v (%v)", update, err)
+		}
+		if err := conn.ReadJSON(&update); err != nil || update.Type != "position" {
+			t.Fatalf("expected a position update, got %+v (%v)", update, err)
+		}
+		if update.Payload["quantity"] != float64(want) {
+			t.Fatalf("update %d out of order: %+v", want, update.Payload)
+		}
+	}
+}
+
+func TestSymbolChannelPublishesAnonymousTrades(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	srv := httptest.NewServer(api.NewAPI(eng).Router())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/AAPL", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	eng.PlaceOrder(&common.Order{Account: "B", Symbol: "AAPL", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 10000, Quantity: 2})
+	eng.PlaceOrder(&common.Order{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 10000, Quantity: 2})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg sessionReply
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "trade" {
+		t.Fatalf("expected a trade, got %+v (%v)", msg, err)
+	}
+	if msg.Payload["price"] != float64(10000) || msg.Payload["quantity"] != float64(2) {
+		t.Fatalf("unexpected trade %+v", msg.Payload)
+	}
+	for _, field := range []string{"buy_account", "sell_account", "buy_order", "sell_order", "buy_fee", "sell_fee"} {
+		if _, ok := msg.Payload[field]; ok {
+			t.Fatalf("public trade carries %s: %+v", field, msg.Payload)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/api/v1/market/trades/AAPL")
+	if err != nil {
+		t.Fatalf("get trades: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Trades []map[string]any `json:"trades"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Trades) != 1 {
+		t.Fatalf("expected one trade, got %+v", body.Trades)
+	}
+	if _, ok := body.Trades[0]["buy_account"]; ok {
+		t.Fatalf("trade history must not name accounts: %+v", body.Trades[0])
+	}
+}
+
+func TestCancelsNameAccountsOnlyPrivately(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionOpen = true
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	base := "ws" + strings.TrimPrefix(srv.URL, "http")
+	public, _, err := websocket.DefaultDialer.Dial(base+"/ws/AAPL", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer public.Close()
+	private, _, err := websocket.DefaultDialer.Dial(base+"/ws/account", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer private.Close()
+	private.WriteJSON(map[string]any{"type": "logon", "account_id": "A"})
+	private.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var snapshot sessionReply
+	if err := private.ReadJSON(&snapshot); err != nil || snapshot.Type != "positions" {
+		t.Fatalf("expected a positions snapshot, got %+v (%v)", snapshot, err)
+	}
+
+	o, _, _ := eng.PlaceOrder(&common.Order{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 9900, Quantity: 2})
+	if err := eng.CancelOrder(o.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	public.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg sessionReply
+	if err := public.ReadJSON(&msg); err != nil || msg.Type != "cancel" {
+		t.Fatalf("expected a public cancel, got %+v (%v)", msg, err)
+	}
+	if _, ok := msg.Payload["account_id"]; ok || msg.Payload["order_id"] != o.ID {
+		t.Fatalf("public cancel must not name the account: %+v", msg.Payload)
+	}
+	if err := private.ReadJSON(&msg); err != nil || msg.Type != "cancel" || msg.Payload["account_id"] != "A" {
+		t.Fatalf("expected the owner's cancel, got %+v (%v)", msg, err)
+	}
+}
+
+func TestHiddenAndDarkCancelsStayPrivate(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionOpen = true
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	base := "ws" + strings.TrimPrefix(srv.URL, "http")
+	public, _, err := websocket.DefaultDialer.Dial(base+"/ws/AAPL", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer public.Close()
+	private, _, err := websocket.DefaultDialer.Dial(base+"/ws/account", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer private.Close()
+	private.WriteJSON(map[string]any{"type": "logon", "account_id": "A"})
+	private.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var snapshot sessionReply
+	if err := private.ReadJSON(&snapshot); err != nil || snapshot.Type != "positions" {
+		t.Fatalf("expected a positions snapshot, got %+v (%v)", snapshot, err)
+	}
+
+	var ids []string
+	for _, o := range []*common.Order{
+		{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 9900, Quantity: 2, Hidden: true},
+		{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 9900, Quantity: 2, Dark: true},
+		{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 9900, Quantity: 2},
+	} {
+		placed, _, err := eng.PlaceOrder(o)
+		if err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+		ids = append(ids, placed.ID)
+	}
+	for _, id := range ids {
+		if err := eng.CancelOrder(id); err != nil {
+			t.Fatalf("cancel: %v", err)
+		}
+	}
+
+	// Only the displayed order's cancel is public.
+	public.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg sessionReply
+	if err := public.ReadJSON(&msg); err != nil || msg.Type != "cancel" || msg.Payload["order_id"] != ids[2] {
+		t.Fatalf("expected only the lit cancel in public, got %+v (%v)", msg, err)
+	}
+	for _, id := range ids {
+		if err := private.ReadJSON(&msg); err != nil || msg.Type != "cancel" || msg.Payload["order_id"] != id {
+			t.Fatalf("expected the owner's cancel of %s, got %+v (%v)", id, msg, err)
+		}
+	}
+}
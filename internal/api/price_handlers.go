@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/prices"
+)
+
+// GET /api/v1/prices
+func (a *API) listPrices(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"prices": a.Prices.All()})
+}
+
+// GET /api/v1/prices/{symbol}
+func (a *API) getPrice(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.Prices.Get(chi.URLParam(r, "symbol"))
+	if !ok {
+		http.Error(w, "No mark price", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+// PUT /api/v1/admin/prices/{symbol}/index
+func (a *API) setIndexPrice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Price int64 `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.Prices.SetIndex(chi.URLParam(r, "symbol"), req.Price)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+// PUT /api/v1/admin/prices/config
+func (a *API) setDefaultPriceConfig(w http.ResponseWriter, r *http.Request) {
+	a.applyPriceConfig(w, r, a.Prices.SetDefaultConfig)
+}
+
+// PUT /api/v1/admin/prices/{symbol}/config
+func (a *API) setPriceConfig(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	a.applyPriceConfig(w, r, func(cfg prices.Config) (prices.Config, error) {
+		return a.Prices.SetConfig(symbol, cfg)
+	})
+}
+
+func (a *API) applyPriceConfig(w http.ResponseWriter, r *http.Request, apply func(prices.Config) (prices.Config, error)) {
+	var req prices.Config
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	cfg, err := apply(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(cfg)
+}
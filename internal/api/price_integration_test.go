@@ -0,0 +1,75 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"order-matching-engine/internal/api"
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestMarkPricePublishedOnRESTAndWebSocket(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/AAPL", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	for _, o := range []*common.Order{
+		{Account: "A", Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 9900, Quantity: 5},
+		{Account: "B", Symbol: "AAPL", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 10100, Quantity: 5},
+	} {
+		if _, _, err := eng.PlaceOrder(o); err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+	}
+
+	body, _ := json.Marshal(map[string]any{"price": 10300})
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/api/v1/admin/prices/AAPL/index", bytes.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("set index: %v %v", resp, err)
+	}
+	resp.Body.Close()
+
+	// Mid 10000 and index 10300: the mark is their mean.
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg sessionReply
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "mark_price" {
+		t.Fatalf("expected a mark_price message, got %+v (%v)", msg, err)
+	}
+	if msg.Payload["mark_price"] != float64(10150) || msg.Payload["mid_price"] != float64(10000) {
+		t.Fatalf("unexpected mark price %+v", msg.Payload)
+	}
+	if eng.MarkPrice("AAPL") != 10150 {
+		t.Fatalf("expected the engine to use the mark, got %d", eng.MarkPrice("AAPL"))
+	}
+
+	resp, err = http.Get(srv.URL + "/api/v1/prices/AAPL")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("get price: %v %v", resp, err)
+	}
+	var price map[string]any
+	json.NewDecoder(resp.Body).Decode(&price)
+	resp.Body.Close()
+	if price["mark_price"] != float64(10150) || price["index_price"] != float64(10300) {
+		t.Fatalf("unexpected price %+v", price)
+	}
+
+	if resp, _ := http.Get(srv.URL + "/api/v1/prices/MSFT"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 without a mark, got %d", resp.StatusCode)
+	}
+}
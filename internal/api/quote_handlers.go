@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/engine"
+)
+
+type massQuoteRequest struct {
+	Quotes []engine.Quote `json:"quotes"`
+}
+
+// quoteAck is the per-quote response entry.
+type quoteAck struct {
+	QuoteID    string `json:"quote_id"`
+	Status     string `json:"status"` // ACCEPTED | REJECTED
+	OrderID    string `json:"order_id,omitempty"`
+	OrderState string `json:"order_status,omitempty"`
+	FilledQty  int64  `json:"filled_quantity,omitempty"`
+	RejectCode string `json:"reject_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Trades     any    `json:"trades,omitempty"`
+}
+
+// POST /api/v1/accounts/{account}/quotes
+func (a *API) massQuote(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+
+	var req massQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	res, err := a.Engine.MassQuote(engine.MassQuoteRequest{Account: account, Quotes: req.Quotes})
+	if err != nil {
+		writeReject(w, orderRejectCode(err), err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(massQuoteResponse(res))
+}
+
+func massQuoteResponse(res *engine.MassQuoteResult) map[string]any {
+	acks := make([]quoteAck, 0, len(res.Acks))
+	for _, ack := range res.Acks {
+		out := quoteAck{QuoteID: ack.QuoteID, Status: "ACCEPTED"}
+		if ack.Err != nil {
+			out.Status = "REJECTED"
+			out.RejectCode = orderRejectCode(ack.Err)
+			out.Error = ack.Err.Error()
+		} else {
+			out.OrderID = ack.Order.ID
+			out.OrderState = string(ack.Order.Status)
+			out.FilledQty = ack.Order.FilledQty
+			if len(ack.Trades) > 0 {
+				out.Trades = ack.Trades
+			}
+		}
+		acks = append(acks, out)
+	}
+	return map[string]any{
+		"cancelled": res.Cancelled,
+		"acks":      acks,
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/engine"
+)
+
+type rfqRequest struct {
+	engine.RFQRequest
+	TTLMs int64 `json:"ttl_ms"`
+}
+
+type rfqQuoteRequest struct {
+	Dealer   string `json:"dealer_id"`
+	Price    int64  `json:"price"`
+	Quantity int64  `json:"quantity"`
+	TTLMs    int64  `json:"ttl_ms"`
+}
+
+type rfqAcceptRequest struct {
+	Account string `json:"account_id"`
+	QuoteID string `json:"quote_id"`
+}
+
+// POST /api/v1/rfqs
+func (a *API) requestQuote(w http.ResponseWriter, r *http.Request) {
+	var req rfqRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	req.TTL = time.Duration(req.TTLMs) * time.Millisecond
+	rfq, err := a.Engine.RequestQuote(req.RFQRequest)
+	if err != nil {
+		writeRFQError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rfq)
+}
+
+// GET /api/v1/rfqs/{id}?account_id=...
+func (a *API) getRFQ(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account_id")
+	if account == "" {
+		http.Error(w, engine.ErrAccountRequired.Error(), http.StatusBadRequest)
+		return
+	}
+	rfq, ok := a.Engine.GetRFQ(chi.URLParam(r, "id"), account)
+	if !ok {
+		http.Error(w, engine.ErrRFQNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(rfq)
+}
+
+// DELETE /api/v1/rfqs/{id}?account_id=...
+func (a *API) cancelRFQ(w http.ResponseWriter, r *http.Request) {
+	if err := a.Engine.CancelRFQ(chi.URLParam(r, "id"), r.URL.Query().Get("account_id")); err != nil {
+		writeRFQError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/v1/rfqs/{id}/quotes
+func (a *API) quoteRFQ(w http.ResponseWriter, r *http.Request) {
+	var req rfqQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	q, err := a.Engine.QuoteRFQ(engine.RFQQuoteRequest{
+		RFQID:    chi.URLParam(r, "id"),
+		Dealer:   req.Dealer,
+		Price:    req.Price,
+		Quantity: req.Quantity,
+		TTL:      time.Duration(req.TTLMs) * time.Millisecond,
+	})
+	if err != nil {
+		writeRFQError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(q)
+}
+
+// POST /api/v1/rfqs/{id}/accept
+func (a *API) acceptRFQ(w http.ResponseWriter, r *http.Request) {
+	var req rfqAcceptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	trade, err := a.Engine.AcceptRFQQuote(chi.URLParam(r, "id"), req.Account, req.QuoteID)
+	if err != nil {
+		writeRFQError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(trade)
+}
+
+// GET /api/v1/admin/rfq/dealers
+func (a *API) listDealers(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"dealers": a.Engine.Dealers()})
+}
+
+// PUT /api/v1/admin/rfq/dealers/{account}
+func (a *API) registerDealer(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+	if err := a.Engine.RegisterDealer(account); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"account_id": account, "dealer": true})
+}
+
+// DELETE /api/v1/admin/rfq/dealers/{account}
+func (a *API) unregisterDealer(w http.ResponseWriter, r *http.Request) {
+	if !a.Engine.UnregisterDealer(chi.URLParam(r, "account")) {
+		http.Error(w, "dealer not registered", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRFQError(w http.ResponseWriter, err error) {
+	switch err {
+	case engine.ErrRFQNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case engine.ErrInvalidOrderData, engine.ErrAccountRequired:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeReject(w, orderRejectCode(err), err.Error())
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/risk"
+)
+
+// GET /api/v1/admin/risk/limits?account=ACC&symbol=SYM
+func (a *API) getRiskLimits(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+	symbol := r.URL.Query().Get("symbol")
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"account": account,
+		"symbol":  symbol,
+		"limits":  a.Engine.Risk.Limits(account, symbol),
+	})
+}
+
+// PUT /api/v1/admin/risk/defaults
+func (a *API) setDefaultRiskLimits(w http.ResponseWriter, r *http.Request) {
+	limits, ok := decodeLimits(w, r)
+	if !ok {
+		return
+	}
+	a.Engine.Risk.SetDefaultLimits(limits)
+	json.NewEncoder(w).Encode(limits)
+}
+
+// PUT /api/v1/admin/risk/symbols/{symbol}
+func (a *API) setSymbolRiskLimits(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	limits, ok := decodeLimits(w, r)
+	if !ok {
+		return
+	}
+	a.Engine.Risk.SetSymbolLimits(symbol, limits)
+	json.NewEncoder(w).Encode(map[string]any{
+		"symbol": symbol,
+		"limits": limits,
+	})
+}
+
+// PUT /api/v1/admin/risk/accounts/{account}
+func (a *API) setAccountRiskLimits(w http.ResponseWriter, r *http.Request) {
+	account := chi.URLParam(r, "account")
+	limits, ok := decodeLimits(w, r)
+	if !ok {
+		return
+	}
+	a.Engine.Risk.SetAccountLimits(account, limits)
+	json.NewEncoder(w).Encode(map[string]any{
+		"account": account,
+		"limits":  limits,
+	})
+}
+
+func decodeLimits(w http.ResponseWriter, r *http.Request) (risk.Limits, bool) {
+	var limits risk.Limits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return limits, false
+	}
+	return limits, true
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"sync/atomic"
@@ -11,22 +12,89 @@ import (
 
 	"order-matching-engine/internal/common"
 	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
 	"order-matching-engine/internal/marketdata"
+	"order-matching-engine/internal/prices"
+	"order-matching-engine/internal/risk"
 )
 
+// rejectCodes maps order-entry errors to the reject codes returned to clients.
+var rejectCodes = map[error]string{
+	engine.ErrInsufficientFunds:     "INSUFFICIENT_FUNDS",
+	instruments.ErrUnknownSymbol:    "UNKNOWN_SYMBOL",
+	engine.ErrSymbolNotOpen:         "SYMBOL_NOT_OPEN",
+	engine.ErrPriceBandBreached:     "PRICE_BAND",
+	engine.ErrUnknownSession:        "UNKNOWN_SESSION",
+	engine.ErrKillSwitchActive:      "KILL_SWITCH",
+	engine.ErrDuplicateQuoteID:      "DUPLICATE_QUOTE_ID",
+	engine.ErrCrossedQuotes:         "CROSSED_QUOTES",
+	engine.ErrNoPegReference:        "NO_PEG_REFERENCE",
+	engine.ErrMinQtyNotMet:          "MIN_QTY_NOT_MET",
+	engine.ErrNotDealer:             "NOT_DEALER",
+	engine.ErrRFQQuoteNotFound:      "QUOTE_NOT_FOUND",
+	engine.ErrBlockTooSmall:         "BLOCK_TOO_SMALL",
+	engine.ErrBlockPriceBand:        "BLOCK_PRICE_BAND",
+	engine.ErrNoReferencePrice:      "NO_REFERENCE_PRICE",
+	engine.ErrInsufficientMargin:    "INSUFFICIENT_MARGIN",
+	instruments.ErrInvalidTick:      "INVALID_TICK",
+	instruments.ErrInvalidLot:       "INVALID_LOT",
+	instruments.ErrQuantityTooSmall: "QTY_BELOW_MIN",
+	instruments.ErrQuantityTooLarge: "QTY_ABOVE_MAX",
+	instruments.ErrExpired:          "INSTRUMENT_EXPIRED",
+}
+
 type API struct {
 	Engine     *engine.MatchingEngine
 	WSHub      *WSHub
 	MarketData *marketdata.MarketData
+	Prices     *prices.Service
 	startTime  time.Time
+
+	// Order-entry sessions on /ws/session.
+	SessionHeartbeat time.Duration     // longest silence before a session is dropped
+	SessionGrace     time.Duration     // delay before cancel-on-disconnect fires
+	SessionTokens    map[string]string // account -> logon token
+	SessionOpen      bool              // without SessionTokens, accept any account
 }
 
 func NewAPI(e *engine.MatchingEngine) *API {
-	return &API{
+	a := &API{
 		Engine:     e,
 		WSHub:      NewWSHub(),
 		MarketData: marketdata.NewMarketData(),
 		startTime:  time.Now(),
+
+		SessionHeartbeat: 30 * time.Second,
+	}
+	a.Prices = prices.NewService(e, a.MarketData)
+	a.Prices.OnUpdate = a.WSHub.BroadcastMarkPrice
+	e.Subscribe(a.onEngineEvent)
+	return a
+}
+
+// onEngineEvent records trades in market data and fans engine events out
+// to WebSocket subscribers. Symbol channels get the public view of trades
+// and cancels; full trades, cancels and position updates go to their
+// accounts only.
+func (a *API) onEngineEvent(ev engine.Event) {
+	switch ev.Type {
+	case engine.EventTrade:
+		a.WSHub.BroadcastTrade(ev.Symbol, ev.Trade)
+		a.WSHub.SendTrade(ev.Symbol, ev.Trade)
+		a.MarketData.RecordTrade(ev.Trade, ev.Symbol)
+	case engine.EventStatusChange:
+		a.WSHub.BroadcastStatus(ev.Symbol, ev.Status)
+	case engine.EventAuction:
+		a.WSHub.BroadcastAuction(ev.Symbol, ev.Auction)
+	case engine.EventCancel:
+		a.WSHub.BroadcastCancel(ev.Symbol, ev.Cancel)
+		a.WSHub.SendCancel(ev.Symbol, ev.Cancel)
+	case engine.EventRFQ:
+		a.WSHub.BroadcastRFQ(ev.Symbol, ev.RFQ)
+	case engine.EventPosition:
+		a.WSHub.SendPosition(ev.Position)
+	case engine.EventLiquidation:
+		a.WSHub.SendLiquidation(ev.Liquidation)
 	}
 }
 
@@ -42,16 +110,101 @@ func (a *API) Router() http.Handler {
 
 	// Core order endpoints
 	r.Post("/api/v1/orders", a.placeOrder)
+	r.Post("/api/v1/orders/mass-cancel", a.massCancel)
 	r.Delete("/api/v1/orders/{id}", a.cancelOrder)
 	r.Get("/api/v1/orders/{id}", a.getOrder)
 	r.Get("/api/v1/orderbook/{symbol}", a.getOrderBook)
 
+	// Negotiated block trades
+	r.Post("/api/v1/block-trades", a.submitBlockTrade)
+
+	// OCO and bracket order groups
+	r.Post("/api/v1/order-groups", a.placeOrderGroup)
+	r.Get("/api/v1/order-groups/{id}", a.getOrderGroup)
+	r.Delete("/api/v1/order-groups/{id}", a.cancelOrderGroup)
+
 	// Market data endpoints
 	r.Get("/api/v1/market/ohlcv/{symbol}", a.getOHLCV)
 	r.Get("/api/v1/market/trades/{symbol}", a.getTrades)
 	r.Get("/api/v1/market/depth/{symbol}", a.getDepth)
 
+	// Instruments
+	r.Get("/api/v1/instruments", a.listInstruments)
+	r.Get("/api/v1/instruments/{symbol}", a.getInstrument)
+	r.Put("/api/v1/admin/instruments/{symbol}", a.upsertInstrument)
+	r.Delete("/api/v1/admin/instruments/{symbol}", a.deleteInstrument)
+	r.Get("/api/v1/settlements", a.listSettlements)
+	r.Get("/api/v1/instruments/{symbol}/settlement", a.getSettlement)
+	r.Put("/api/v1/admin/instruments/{symbol}/settlement", a.setSettlement)
+
+	// Trading status
+	r.Get("/api/v1/symbols/{symbol}/status", a.getTradingStatus)
+	r.Post("/api/v1/admin/symbols/{symbol}/status", a.setTradingStatus)
+	r.Get("/api/v1/auction/{symbol}", a.getAuction)
+	r.Post("/api/v1/admin/symbols/{symbol}/uncross", a.uncross)
+
+	// Account balances
+	r.Get("/api/v1/accounts/{account}/balances", a.getBalances)
+	r.Post("/api/v1/accounts/{account}/deposit", a.deposit)
+	r.Post("/api/v1/accounts/{account}/withdraw", a.withdraw)
+	r.Get("/api/v1/admin/settlement-failures", a.listSettlementFailures)
+
+	// Positions and P&L
+	r.Get("/api/v1/accounts/{account}/positions", a.getPositions)
+	r.Get("/api/v1/accounts/{account}/positions/{symbol}", a.getPosition)
+
+	// Margin, liquidations and the insurance fund
+	r.Get("/api/v1/accounts/{account}/margin", a.getMargin)
+	r.Post("/api/v1/accounts/{account}/margin/deposit", a.depositMargin)
+	r.Post("/api/v1/accounts/{account}/margin/withdraw", a.withdrawMargin)
+	r.Get("/api/v1/admin/liquidations", a.listLiquidations)
+	r.Get("/api/v1/admin/insurance-fund", a.getInsuranceFund)
+	r.Post("/api/v1/admin/insurance-fund/deposit", a.fundInsurance)
+
+	// Mark and index prices
+	r.Get("/api/v1/prices", a.listPrices)
+	r.Get("/api/v1/prices/{symbol}", a.getPrice)
+	r.Put("/api/v1/admin/prices/{symbol}/index", a.setIndexPrice)
+	r.Put("/api/v1/admin/prices/config", a.setDefaultPriceConfig)
+	r.Put("/api/v1/admin/prices/{symbol}/config", a.setPriceConfig)
+
+	// Mass quotes
+	r.Post("/api/v1/accounts/{account}/quotes", a.massQuote)
+
+	// Request for quote
+	r.Post("/api/v1/rfqs", a.requestQuote)
+	r.Get("/api/v1/rfqs/{id}", a.getRFQ)
+	r.Delete("/api/v1/rfqs/{id}", a.cancelRFQ)
+	r.Post("/api/v1/rfqs/{id}/quotes", a.quoteRFQ)
+	r.Post("/api/v1/rfqs/{id}/accept", a.acceptRFQ)
+	r.Get("/api/v1/admin/rfq/dealers", a.listDealers)
+	r.Put("/api/v1/admin/rfq/dealers/{account}", a.registerDealer)
+	r.Delete("/api/v1/admin/rfq/dealers/{account}", a.unregisterDealer)
+
+	// Dead-man's switch and kill switches
+	r.Get("/api/v1/accounts/{account}/dead-man", a.getDeadMan)
+	r.Post("/api/v1/accounts/{account}/dead-man", a.armDeadMan)
+	r.Delete("/api/v1/accounts/{account}/dead-man", a.disarmDeadMan)
+	r.Get("/api/v1/admin/kill-switch", a.getKillSwitches)
+	r.Put("/api/v1/admin/kill-switch", a.setGlobalKillSwitch)
+	r.Put("/api/v1/admin/kill-switch/accounts/{account}", a.setAccountKillSwitch)
+
+	// Fees
+	r.Get("/api/v1/fees/{account}", a.getFeeReport)
+	r.Get("/api/v1/admin/fees/schedule", a.getFeeSchedule)
+	r.Put("/api/v1/admin/fees/schedule", a.setFeeSchedule)
+	r.Put("/api/v1/admin/fees/accounts/{account}", a.setFeeOverride)
+	r.Delete("/api/v1/admin/fees/accounts/{account}", a.clearFeeOverride)
+
+	// Risk administration
+	r.Get("/api/v1/admin/risk/limits", a.getRiskLimits)
+	r.Put("/api/v1/admin/risk/defaults", a.setDefaultRiskLimits)
+	r.Put("/api/v1/admin/risk/symbols/{symbol}", a.setSymbolRiskLimits)
+	r.Put("/api/v1/admin/risk/accounts/{account}", a.setAccountRiskLimits)
+
 	// WebSocket endpoint
+	r.Get("/ws/session", a.handleSession)
+	r.Get("/ws/account", a.handleAccountWebSocket)
 	r.Get("/ws/{symbol}", a.handleWebSocket)
 
 	return r
@@ -106,8 +259,22 @@ func (a *API) placeOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Session-scoped orders can only be entered over a session, and quotes
+	// only through a mass quote.
+	req.SessionID = ""
+	req.QuoteID = ""
+
 	order, trades, err := a.Engine.PlaceOrder(&req)
 	if err != nil {
+		var rej *risk.RejectError
+		if errors.As(err, &rej) {
+			writeReject(w, string(rej.Code), rej.Error())
+			return
+		}
+		if code, ok := rejectCodes[err]; ok {
+			writeReject(w, code, err.Error())
+			return
+		}
 		switch err {
 		case engine.ErrInvalidOrderData:
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -115,32 +282,33 @@ func (a *API) placeOrder(w http.ResponseWriter, r *http.Request) {
 		case engine.ErrInsufficientLiquidity:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
+		case engine.ErrAccountRequired:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
-	// Broadcast trades via WebSocket & record market data
-	for _, trade := range trades {
-		a.WSHub.BroadcastTrade(order.Symbol, trade)
-		a.MarketData.RecordTrade(trade, order.Symbol)
-	}
-
 	resp := map[string]any{
 		"order_id":           order.ID,
 		"status":             order.Status,
 		"filled_quantity":    order.FilledQty,
 		"remaining_quantity": order.Quantity - order.FilledQty,
+		"fees":               order.Fees,
 		"trades":             trades,
 	}
+	if order.MarketOutcome != "" {
+		resp["market_outcome"] = order.MarketOutcome
+	}
 
 	// Response code rules:
-	// - 201: order accepted (no matches)
+	// - 201: order accepted (no matches) or stop pending
 	// - 202: partial fill
 	// - 200: fully filled
 
-	if order.Status == common.OrderStatusAccepted {
+	if order.Status == common.OrderStatusAccepted || order.Status == common.OrderStatusPending {
 		w.WriteHeader(http.StatusCreated)
 	} else if order.Status == common.OrderStatusPartial {
 		w.WriteHeader(http.StatusAccepted)
@@ -164,6 +332,9 @@ func (a *API) cancelOrder(w http.ResponseWriter, r *http.Request) {
 		case engine.ErrOrderAlreadyFinalized:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
+		case engine.ErrCancelNotAllowed:
+			writeReject(w, "CANCEL_NOT_ALLOWED", err.Error())
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -176,6 +347,26 @@ func (a *API) cancelOrder(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// POST /api/v1/orders/mass-cancel
+func (a *API) massCancel(w http.ResponseWriter, r *http.Request) {
+	var f engine.MassCancelFilter
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := a.Engine.MassCancel(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"cancelled": ids,
+		"count":     len(ids),
+	})
+}
+
 // GET /api/v1/orders/{id}
 func (a *API) getOrder(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -186,6 +377,14 @@ func (a *API) getOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Grouped orders carry their group's lifecycle state.
+	if g, ok := a.Engine.GetGroup(o.GroupID); ok {
+		json.NewEncoder(w).Encode(struct {
+			*common.Order
+			Group *engine.OrderGroup `json:"group"`
+		}{o, g})
+		return
+	}
 	json.NewEncoder(w).Encode(o)
 }
 
@@ -207,43 +406,25 @@ func (a *API) getOrderBook(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Aggregate quantities per price level.
-	bids := make([]map[string]any, 0, depth)
-	asks := make([]map[string]any, 0, depth)
-
-	for i, price := range book.Bids.Prices {
-		if i >= depth {
-			break
-		}
-		level := book.Bids.Levels[price]
-		qty := int64(0)
-		for _, o := range level.Orders {
-			qty += o.Quantity - o.FilledQty
-		}
-		bids = append(bids, map[string]any{
-			"price":    price,
-			"quantity": qty,
-		})
+	// Displayed quantity per price level; hidden orders are left out.
+	resp := map[string]any{
+		"symbol": symbol,
+		"bids":   book.Bids.Depth(depth),
+		"asks":   book.Asks.Depth(depth),
 	}
-
-	for i, price := range book.Asks.Prices {
-		if i >= depth {
-			break
-		}
-		level := book.Asks.Levels[price]
-		qty := int64(0)
-		for _, o := range level.Orders {
-			qty += o.Quantity - o.FilledQty
-		}
-		asks = append(asks, map[string]any{
-			"price":    price,
-			"quantity": qty,
-		})
+	if bid, ask := a.Engine.ImpliedTopOfBook(symbol); bid != nil || ask != nil {
+		resp["implied_bid"] = bid
+		resp["implied_ask"] = ask
 	}
+	json.NewEncoder(w).Encode(resp)
+}
 
+// writeReject reports a business-rule rejection with a machine-readable code.
+func writeReject(w http.ResponseWriter, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
 	json.NewEncoder(w).Encode(map[string]any{
-		"symbol": symbol,
-		"bids":   bids,
-		"asks":   asks,
+		"error":       msg,
+		"reject_code": code,
 	})
 }
@@ -0,0 +1,128 @@
+package api_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"order-matching-engine/internal/api"
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+type sessionReply struct {
+	Type    string         `json:"type"`
+	Payload map[string]any `json:"payload"`
+}
+
+func dialSession(t *testing.T, srv *httptest.Server, logon map[string]any) (*websocket.Conn, sessionReply) {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/session", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.WriteJSON(logon)
+	var reply sessionReply
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return conn, reply
+}
+
+func TestSessionCancelOnDisconnect(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	apiLayer.SessionTokens = map[string]string{"MM1": "secret"}
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, reply := dialSession(t, srv, map[string]any{"type": "logon", "account_id": "MM1", "token": "wrong"})
+	conn.Close()
+	if reply.Type != "reject" || reply.Payload["reject_code"] != "UNAUTHORIZED" {
+		t.Fatalf("expected unauthorized, got %+v", reply)
+	}
+
+	conn, reply = dialSession(t, srv, map[string]any{"type": "logon", "account_id": "MM1", "token": "secret"})
+	if reply.Type != "logon" || reply.Payload["session_id"] == "" {
+		t.Fatalf("unexpected logon reply: %+v", reply)
+	}
+
+	var ids []string
+	for _, cod := range []bool{true, false} {
+		conn.WriteJSON(map[string]any{"type": "order", "order": map[string]any{
+			"symbol": "AAPL", "side": "BUY", "type": "LIMIT", "price": 10000, "quantity": 10,
+			"cancel_on_disconnect": cod,
+		}})
+		var ack struct {
+			Type    string `json:"type"`
+			Payload struct {
+				Order common.Order `json:"order"`
+			} `json:"payload"`
+		}
+		if err := conn.ReadJSON(&ack); err != nil || ack.Type != "order_ack" {
+			t.Fatalf("expected order ack, got %+v (%v)", ack, err)
+		}
+		if ack.Payload.Order.Account != "MM1" {
+			t.Fatalf("session account not applied: %+v", ack.Payload.Order)
+		}
+		ids = append(ids, ack.Payload.Order.ID)
+	}
+
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		o, _ := eng.GetOrder(ids[0])
+		if eng.OrdersInBook() == 1 && o.Status == common.OrderStatusCancelled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cancel-on-disconnect order not cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if o, _ := eng.GetOrder(ids[1]); o.Status != common.OrderStatusAccepted {
+		t.Fatalf("orders without cancel-on-disconnect must survive, got %s", o.Status)
+	}
+}
+
+func TestSessionLogonDeniedWithoutTokensByDefault(t *testing.T) {
+	srv := httptest.NewServer(api.NewAPI(engine.NewMatchingEngine()).Router())
+	defer srv.Close()
+
+	conn, reply := dialSession(t, srv, map[string]any{"type": "logon", "account_id": "MM1"})
+	conn.Close()
+	if reply.Type != "reject" || reply.Payload["reject_code"] != "UNAUTHORIZED" {
+		t.Fatalf("expected unauthorized, got %+v", reply)
+	}
+}
+
+func TestSessionRejectsUndecodableMessages(t *testing.T) {
+	apiLayer := api.NewAPI(engine.NewMatchingEngine())
+	apiLayer.SessionOpen = true
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, reply := dialSession(t, srv, map[string]any{"type": "logon", "account_id": "MM1"})
+	defer conn.Close()
+	if reply.Type != "logon" {
+		t.Fatalf("unexpected logon reply: %+v", reply)
+	}
+
+	// Invalid JSON and a well-formed message with a wrongly typed field
+	// are both rejected without ending the session.
+	for _, raw := range []string{`{"type":`, `{"type": "order", "order": {"price": "high"}}`} {
+		conn.WriteMessage(websocket.TextMessage, []byte(raw))
+		if err := conn.ReadJSON(&reply); err != nil || reply.Type != "reject" || reply.Payload["reject_code"] != "MALFORMED" {
+			t.Fatalf("%s: expected a MALFORMED reject, got %+v (%v)", raw, reply, err)
+		}
+	}
+	conn.WriteJSON(map[string]any{"type": "heartbeat"})
+	if err := conn.ReadJSON(&reply); err != nil || reply.Type != "heartbeat" {
+		t.Fatalf("expected the session to stay up, got %+v (%v)", reply, err)
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/risk"
+)
+
+// sessionMessage is a client message on a trading session. The first
+// message must be a logon; afterwards clients send orders, cancels and
+// heartbeats.
+type sessionMessage struct {
+	Type      string        `json:"type"` // "logon" | "heartbeat" | "order" | "cancel" | "quotes" | "rfq_quote"
+	Account   string        `json:"account_id,omitempty"`
+	Token     string        `json:"token,omitempty"`
+	SessionID string        `json:"session_id,omitempty"` // logon: resume a disconnected session
+	OrderID   string        `json:"order_id,omitempty"`
+	Order     *common.Order `json:"order,omitempty"`
+
+	// quotes: replaces the account's quote set.
+	Quotes             []engine.Quote `json:"quotes,omitempty"`
+	CancelOnDisconnect bool           `json:"cancel_on_disconnect,omitempty"`
+
+	// rfq_quote: a dealer's reply to an RFQ.
+	RFQID    string `json:"rfq_id,omitempty"`
+	Price    int64  `json:"price,omitempty"`
+	Quantity int64  `json:"quantity,omitempty"`
+	TTLMs    int64  `json:"ttl_ms,omitempty"`
+}
+
+// GET /ws/session
+//
+// An order-entry session for one account. Orders it places may set
+// cancel_on_disconnect; they are cancelled after SessionGrace once the
+// connection drops or no message arrives within SessionHeartbeat.
+func (a *API) handleSession(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(a.SessionHeartbeat))
+	logon, err := readSessionMessage(conn)
+	if err != nil || logon.Type != "logon" {
+		writeSessionReject(conn, "LOGON_REQUIRED", "first message must be a logon")
+		return
+	}
+	if !a.authorizeSession(logon.Account, logon.Token) {
+		writeSessionReject(conn, "UNAUTHORIZED", "invalid account or token")
+		return
+	}
+	account := logon.Account
+	id, err := a.Engine.OpenSession(account, logon.SessionID)
+	if err != nil {
+		writeSessionReject(conn, "UNKNOWN_SESSION", err.Error())
+		return
+	}
+	defer a.Engine.CloseSession(id, a.SessionGrace)
+
+	conn.WriteJSON(WSMessage{Type: "logon", Payload: map[string]any{
+		"session_id": id,
+		"account_id": account,
+	}})
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(a.SessionHeartbeat))
+		msg, err := readSessionMessage(conn)
+		if err != nil {
+			if errors.Is(err, errMalformedMessage) {
+				writeSessionReject(conn, "MALFORMED", err.Error())
+				continue
+			}
+			return // connection closed or heartbeat timed out
+		}
+
+		switch msg.Type {
+		case "heartbeat":
+			conn.WriteJSON(WSMessage{Type: "heartbeat"})
+		case "order":
+			if msg.Order == nil {
+				writeSessionReject(conn, "MALFORMED", "order required")
+				continue
+			}
+			req := *msg.Order
+			req.Account = account
+			req.SessionID = id
+			req.QuoteID = ""
+			order, trades, err := a.Engine.PlaceOrder(&req)
+			if err != nil {
+				writeSessionReject(conn, orderRejectCode(err), err.Error())
+				continue
+			}
+			conn.WriteJSON(WSMessage{Type: "order_ack", Symbol: order.Symbol, Payload: map[string]any{
+				"order":  order,
+				"trades": trades,
+			}})
+		case "cancel":
+			if o, ok := a.Engine.GetOrder(msg.OrderID); !ok || o.Account != account {
+				writeSessionReject(conn, "UNKNOWN_ORDER", engine.ErrOrderNotFound.Error())
+				continue
+			}
+			if err := a.Engine.CancelOrder(msg.OrderID); err != nil {
+				writeSessionReject(conn, orderRejectCode(err), err.Error())
+				continue
+			}
+			conn.WriteJSON(WSMessage{Type: "cancel_ack", Payload: map[string]any{
+				"order_id": msg.OrderID,
+				"status":   common.OrderStatusCancelled,
+			}})
+		case "quotes":
+			res, err := a.Engine.MassQuote(engine.MassQuoteRequest{
+				Account:            account,
+				SessionID:          id,
+				CancelOnDisconnect: msg.CancelOnDisconnect,
+				Quotes:             msg.Quotes,
+			})
+			if err != nil {
+				writeSessionReject(conn, orderRejectCode(err), err.Error())
+				continue
+			}
+			conn.WriteJSON(WSMessage{Type: "quote_ack", Payload: massQuoteResponse(res)})
+		case "rfq_quote":
+			q, err := a.Engine.QuoteRFQ(engine.RFQQuoteRequest{
+				RFQID:    msg.RFQID,
+				Dealer:   account,
+				Price:    msg.Price,
+				Quantity: msg.Quantity,
+				TTL:      time.Duration(msg.TTLMs) * time.Millisecond,
+			})
+			if err != nil {
+				writeSessionReject(conn, orderRejectCode(err), err.Error())
+				continue
+			}
+			conn.WriteJSON(WSMessage{Type: "rfq_quote_ack", Payload: q})
+		default:
+			writeSessionReject(conn, "MALFORMED", "unknown message type")
+		}
+	}
+}
+
+// authorizeSession checks a logon against SessionTokens. Without
+// configured tokens no account may log on unless SessionOpen is set.
+func (a *API) authorizeSession(account, token string) bool {
+	if account == "" {
+		return false
+	}
+	if len(a.SessionTokens) == 0 {
+		return a.SessionOpen
+	}
+	want, ok := a.SessionTokens[account]
+	return ok && want == token
+}
+
+// errMalformedMessage wraps any error decoding a session message. The
+// message is rejected but the session stays up.
+var errMalformedMessage = errors.New("malformed message")
+
+func readSessionMessage(conn *websocket.Conn) (sessionMessage, error) {
+	var msg sessionMessage
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return msg, err
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg, fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+	return msg, nil
+}
+
+func writeSessionReject(conn *websocket.Conn, code, msg string) {
+	conn.WriteJSON(WSMessage{Type: "reject", Payload: map[string]any{
+		"error":       msg,
+		"reject_code": code,
+	}})
+}
+
+// orderRejectCode maps an engine error to a reject code for per-message
+// and per-quote rejects.
+func orderRejectCode(err error) string {
+	var rej *risk.RejectError
+	if errors.As(err, &rej) {
+		return string(rej.Code)
+	}
+	if code, ok := rejectCodes[err]; ok {
+		return code
+	}
+	switch err {
+	case engine.ErrCancelNotAllowed:
+		return "CANCEL_NOT_ALLOWED"
+	case engine.ErrOrderAlreadyFinalized:
+		return "ORDER_FINALIZED"
+	case engine.ErrRFQNotFound:
+		return "UNKNOWN_RFQ"
+	}
+	return "INVALID_ORDER"
+}
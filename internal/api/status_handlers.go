@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+)
+
+type statusRequest struct {
+	Status common.TradingStatus `json:"status"`
+	Reason string               `json:"reason"`
+}
+
+// GET /api/v1/symbols/{symbol}/status
+func (a *API) getTradingStatus(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"symbol":  symbol,
+		"status":  a.Engine.TradingStatus(symbol),
+		"history": a.Engine.StatusHistory(symbol),
+	})
+}
+
+// POST /api/v1/admin/symbols/{symbol}/status
+func (a *API) setTradingStatus(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+
+	var req statusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		http.Error(w, "reason required", http.StatusBadRequest)
+		return
+	}
+
+	change, err := a.Engine.SetTradingStatus(symbol, req.Status, req.Reason)
+	if err != nil {
+		switch err {
+		case engine.ErrInvalidTradingState:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case engine.ErrInvalidTransition, instruments.ErrExpired:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case instruments.ErrUnknownSymbol:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(change)
+}
+
+// GET /api/v1/auction/{symbol}
+func (a *API) getAuction(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.Engine.IndicativeAuction(chi.URLParam(r, "symbol"))
+	if !ok {
+		http.Error(w, "Symbol not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(info)
+}
+
+// POST /api/v1/admin/symbols/{symbol}/uncross
+func (a *API) uncross(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+
+	var req statusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		http.Error(w, "reason required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := a.Engine.Uncross(symbol, req.Reason)
+	if err != nil {
+		switch err {
+		case engine.ErrNotInCall:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(res)
+}
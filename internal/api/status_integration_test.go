@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"order-matching-engine/internal/api"
+	"order-matching-engine/internal/engine"
+)
+
+func TestTradingStatusAdminAndBroadcast(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	apiLayer := api.NewAPI(eng)
+	srv := httptest.NewServer(apiLayer.Router())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/AAPL", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Give the handler a moment to register the subscription.
+	time.Sleep(50 * time.Millisecond)
+
+	// Missing reason is rejected.
+	body, _ := json.Marshal(map[string]any{"status": "HALTED"})
+	resp, _ := http.Post(srv.URL+"/api/v1/admin/symbols/AAPL/status", "application/json", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without reason, got %d", resp.StatusCode)
+	}
+
+	body, _ = json.Marshal(map[string]any{"status": "HALTED", "reason": "volatility"})
+	resp, _ = http.Post(srv.URL+"/api/v1/admin/symbols/AAPL/status", "application/json", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg struct {
+		Type    string         `json:"type"`
+		Payload map[string]any `json:"payload"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if msg.Type != "status" || msg.Payload["to"] != "HALTED" || msg.Payload["reason"] != "volatility" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	// Orders are rejected while halted.
+	body, _ = json.Marshal(map[string]any{"symbol": "AAPL", "side": "BUY", "type": "LIMIT", "price": 100, "quantity": 1})
+	resp, _ = http.Post(srv.URL+"/api/v1/orders", "application/json", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 while halted, got %d", resp.StatusCode)
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/gorilla/websocket"
 
 	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/positions"
+	"order-matching-engine/internal/prices"
 )
 
 var upgrader = websocket.Upgrader{
@@ -17,49 +20,226 @@ var upgrader = websocket.Upgrader{
 }
 
 type WSMessage struct {
-	Type    string `json:"type"` // "trade" | "orderbook"
+	Type    string `json:"type"` // "trade" | "orderbook" | "status" | "auction" | "cancel" | "rfq" | "position" | "liquidation"
 	Symbol  string `json:"symbol"`
 	Payload any    `json:"payload"`
 }
 
+// wsQueueSize bounds the messages waiting for a connection. A client that
+// falls this far behind is disconnected rather than allowed to stall the
+// engine's event fan-out.
+const wsQueueSize = 256
+
+// wsClient owns the writes to one connection. A single goroutine drains
+// the queue, so messages go out one at a time and in the order queued.
+type wsClient struct {
+	conn  *websocket.Conn
+	queue chan []byte
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newWSClient(conn *websocket.Conn) *wsClient {
+	c := &wsClient{
+		conn:  conn,
+		queue: make(chan []byte, wsQueueSize),
+		done:  make(chan struct{}),
+	}
+	go c.writeLoop()
+	return c
+}
+
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case data := <-c.queue:
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.conn.Close()
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// enqueue queues a message without blocking.
+func (c *wsClient) enqueue(data []byte) {
+	select {
+	case c.queue <- data:
+	case <-c.done:
+	default:
+		log.Printf("WebSocket client too slow, disconnecting")
+		c.conn.Close()
+		c.close()
+	}
+}
+
+// close stops the writer. The connection's reader notices the closed
+// connection and unsubscribes.
+func (c *wsClient) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
 type WSHub struct {
 	mu          sync.RWMutex
-	subscribers map[string]map[*websocket.Conn]bool // symbol -> connections
+	subscribers map[string]map[*wsClient]bool // symbol -> connections
+	private     map[string]map[*wsClient]bool // account -> connections
 }
 
 func NewWSHub() *WSHub {
 	return &WSHub{
-		subscribers: make(map[string]map[*websocket.Conn]bool),
+		subscribers: make(map[string]map[*wsClient]bool),
+		private:     make(map[string]map[*wsClient]bool),
 	}
 }
 
-func (h *WSHub) Subscribe(symbol string, conn *websocket.Conn) {
+func (h *WSHub) subscribe(symbol string, c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.addLocked(h.subscribers, symbol, c)
+}
+
+func (h *WSHub) unsubscribe(symbol string, c *wsClient) {
+	h.remove(h.subscribers, symbol, c)
+}
+
+// subscribeAccount queues the snapshot and registers the connection for
+// the account's private updates in one step, so updates follow the
+// snapshot and none falls in between. An update already reflected in the
+// snapshot may be delivered again; updates carry full state, so that is
+// harmless.
+func (h *WSHub) subscribeAccount(account string, c *wsClient, snapshot func() WSMessage) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if data, err := json.Marshal(snapshot()); err == nil {
+		c.enqueue(data)
+	}
+	h.addLocked(h.private, account, c)
+}
 
-	if h.subscribers[symbol] == nil {
-		h.subscribers[symbol] = make(map[*websocket.Conn]bool)
+func (h *WSHub) unsubscribeAccount(account string, c *wsClient) {
+	h.remove(h.private, account, c)
+}
+
+func (h *WSHub) addLocked(subscribers map[string]map[*wsClient]bool, key string, c *wsClient) {
+	if subscribers[key] == nil {
+		subscribers[key] = make(map[*wsClient]bool)
 	}
-	h.subscribers[symbol][conn] = true
+	subscribers[key][c] = true
 }
 
-func (h *WSHub) Unsubscribe(symbol string, conn *websocket.Conn) {
+func (h *WSHub) remove(subscribers map[string]map[*wsClient]bool, key string, c *wsClient) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if subs, ok := h.subscribers[symbol]; ok {
-		delete(subs, conn)
+	if subs, ok := subscribers[key]; ok {
+		delete(subs, c)
 		if len(subs) == 0 {
-			delete(h.subscribers, symbol)
+			delete(subscribers, key)
 		}
 	}
 }
 
+// BroadcastTrade publishes the public view of a trade.
 func (h *WSHub) BroadcastTrade(symbol string, trade *common.Trade) {
 	h.broadcast(symbol, WSMessage{
+		Type:    "trade",
+		Symbol:  symbol,
+		Payload: trade.Public(),
+	})
+}
+
+// SendTrade delivers the full trade, counterparties and fees included, to
+// the private subscribers of both accounts.
+func (h *WSHub) SendTrade(symbol string, trade *common.Trade) {
+	msg := WSMessage{
 		Type:    "trade",
 		Symbol:  symbol,
 		Payload: trade,
+	}
+	if trade.BuyAccount != "" {
+		h.send(h.private, trade.BuyAccount, msg)
+	}
+	if trade.SellAccount != "" && trade.SellAccount != trade.BuyAccount {
+		h.send(h.private, trade.SellAccount, msg)
+	}
+}
+
+func (h *WSHub) BroadcastStatus(symbol string, change *engine.StatusChange) {
+	h.broadcast(symbol, WSMessage{
+		Type:    "status",
+		Symbol:  symbol,
+		Payload: change,
+	})
+}
+
+func (h *WSHub) BroadcastAuction(symbol string, info *engine.AuctionInfo) {
+	h.broadcast(symbol, WSMessage{
+		Type:    "auction",
+		Symbol:  symbol,
+		Payload: info,
+	})
+}
+
+// BroadcastCancel publishes a cancellation without its account. Cancels
+// of hidden and dark orders go to their owner only.
+func (h *WSHub) BroadcastCancel(symbol string, c *engine.Cancellation) {
+	if c.Hidden {
+		return
+	}
+	h.broadcast(symbol, WSMessage{
+		Type:    "cancel",
+		Symbol:  symbol,
+		Payload: c.Public(),
+	})
+}
+
+// SendCancel delivers the full cancellation to its account's private
+// subscribers.
+func (h *WSHub) SendCancel(symbol string, c *engine.Cancellation) {
+	if c.Account == "" {
+		return
+	}
+	h.send(h.private, c.Account, WSMessage{
+		Type:    "cancel",
+		Symbol:  symbol,
+		Payload: c,
+	})
+}
+
+func (h *WSHub) BroadcastRFQ(symbol string, n *engine.RFQNotice) {
+	h.broadcast(symbol, WSMessage{
+		Type:    "rfq",
+		Symbol:  symbol,
+		Payload: n,
+	})
+}
+
+func (h *WSHub) BroadcastMarkPrice(p prices.Price) {
+	h.broadcast(p.Symbol, WSMessage{
+		Type:    "mark_price",
+		Symbol:  p.Symbol,
+		Payload: p,
+	})
+}
+
+// SendPosition delivers a position update to its account's private
+// subscribers only.
+func (h *WSHub) SendPosition(p *positions.Position) {
+	h.send(h.private, p.Account, WSMessage{
+		Type:    "position",
+		Symbol:  p.Symbol,
+		Payload: p,
+	})
+}
+
+// SendLiquidation tells an account's private subscribers it was liquidated.
+func (h *WSHub) SendLiquidation(l *engine.Liquidation) {
+	h.send(h.private, l.Account, WSMessage{
+		Type:    "liquidation",
+		Payload: l,
 	})
 }
 
@@ -75,35 +255,22 @@ func (h *WSHub) BroadcastOrderBook(symbol string, bids, asks []map[string]any) {
 }
 
 func (h *WSHub) broadcast(symbol string, msg WSMessage) {
-	h.mu.RLock()
-	subs := h.subscribers[symbol]
-	h.mu.RUnlock()
-
-	if len(subs) == 0 {
-		return
-	}
+	h.send(h.subscribers, symbol, msg)
+}
 
+func (h *WSHub) send(subscribers map[string]map[*wsClient]bool, key string, msg WSMessage) {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		log.Printf("Failed to marshal WS message: %v", err)
 		return
 	}
 
-	for conn := range subs {
-		go func(c *websocket.Conn) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("WebSocket broadcast panic: %v", r)
-					h.Unsubscribe(symbol, c)
-					c.Close()
-				}
-			}()
-
-			if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
-				h.Unsubscribe(symbol, c)
-				c.Close()
-			}
-		}(conn)
+	// Queue under the lock so a subscribe with a snapshot is ordered
+	// against this message.
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range subscribers[key] {
+		c.enqueue(data)
 	}
 }
 
@@ -121,8 +288,10 @@ func (a *API) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	a.WSHub.Subscribe(symbol, conn)
-	defer a.WSHub.Unsubscribe(symbol, conn)
+	client := newWSClient(conn)
+	defer client.close()
+	a.WSHub.subscribe(symbol, client)
+	defer a.WSHub.unsubscribe(symbol, client)
 
 	// Keep connection alive, read messages (ping/pong)
 	for {
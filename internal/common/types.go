@@ -14,37 +14,239 @@ type OrderType string
 const (
 	OrderTypeLimit  OrderType = "LIMIT"
 	OrderTypeMarket OrderType = "MARKET"
+
+	// Stop orders wait off-book until the last trade price reaches
+	// StopPrice, then enter as a market (STOP) or limit (STOP_LIMIT) order.
+	OrderTypeStop      OrderType = "STOP"
+	OrderTypeStopLimit OrderType = "STOP_LIMIT"
+
+	// Trailing stops keep their trigger TrailOffset (or TrailBps) away from
+	// the best trade price seen since entry.
+	OrderTypeTrailingStop OrderType = "TRAILING_STOP"
+
+	// Pegged orders rest at a price derived from the book's best prices and
+	// are repriced as the top of the book moves.
+	OrderTypePeg OrderType = "PEG"
+)
+
+// PegReference is the price a pegged order tracks.
+type PegReference string
+
+const (
+	PegPrimary  PegReference = "PRIMARY"  // same-side best price
+	PegMarket   PegReference = "MARKET"   // opposite best price
+	PegMidpoint PegReference = "MIDPOINT" // midpoint of best bid and ask
+)
+
+func (r PegReference) Valid() bool {
+	return r == PegPrimary || r == PegMarket || r == PegMidpoint
+}
+
+// IsStop reports whether orders of this type wait for a trigger.
+func (t OrderType) IsStop() bool {
+	return t == OrderTypeStop || t == OrderTypeStopLimit || t == OrderTypeTrailingStop
+}
+
+// MarketMode controls what a market order does when it cannot fill
+// completely at acceptable prices.
+type MarketMode string
+
+const (
+	// MarketModeFillOrKill executes in full or is rejected (the default).
+	MarketModeFillOrKill MarketMode = "FILL_OR_KILL"
+	// MarketModeFillAndKill executes what it can and cancels the rest.
+	MarketModeFillAndKill MarketMode = "FILL_AND_KILL"
+	// MarketModeMarketToLimit executes at the best opposite price only and
+	// rests the remainder as a limit order at that price.
+	MarketModeMarketToLimit MarketMode = "MARKET_TO_LIMIT"
+)
+
+func (m MarketMode) Valid() bool {
+	switch m {
+	case "", MarketModeFillOrKill, MarketModeFillAndKill, MarketModeMarketToLimit:
+		return true
+	}
+	return false
+}
+
+// MarketOutcome reports how a market order ended.
+type MarketOutcome string
+
+const (
+	MarketOutcomeFilled             MarketOutcome = "FILLED"
+	MarketOutcomeConverted          MarketOutcome = "CONVERTED_TO_LIMIT"
+	MarketOutcomeProtectionReached  MarketOutcome = "PROTECTION_REACHED"  // remainder cancelled at the protection price
+	MarketOutcomeLiquidityExhausted MarketOutcome = "LIQUIDITY_EXHAUSTED" // remainder cancelled, opposite side empty
+	MarketOutcomePriceBand          MarketOutcome = "PRICE_BAND"          // remainder cancelled at the price band
 )
 
 // OrderStatus represents the current status of an order
 type OrderStatus string
 
 const (
+	OrderStatusPending   OrderStatus = "PENDING" // untriggered stop or inactive group leg
 	OrderStatusAccepted  OrderStatus = "ACCEPTED"
 	OrderStatusPartial   OrderStatus = "PARTIAL_FILL"
 	OrderStatusFilled    OrderStatus = "FILLED"
 	OrderStatusCancelled OrderStatus = "CANCELLED"
 )
 
+// TradingStatus represents the trading state of a symbol
+type TradingStatus string
+
+const (
+	TradingStatusPreOpen           TradingStatus = "PRE_OPEN"
+	TradingStatusOpen              TradingStatus = "OPEN"
+	TradingStatusHalted            TradingStatus = "HALTED"
+	TradingStatusVolatilityAuction TradingStatus = "VOLATILITY_AUCTION"
+	TradingStatusPreClose          TradingStatus = "PRE_CLOSE"
+	TradingStatusCancelOnly        TradingStatus = "CANCEL_ONLY"
+	TradingStatusClosed            TradingStatus = "CLOSED"
+)
+
+func (s TradingStatus) Valid() bool {
+	switch s {
+	case TradingStatusPreOpen, TradingStatusOpen, TradingStatusHalted, TradingStatusVolatilityAuction,
+		TradingStatusPreClose, TradingStatusCancelOnly, TradingStatusClosed:
+		return true
+	}
+	return false
+}
+
+// AcceptsOrders reports whether new orders may be entered.
+func (s TradingStatus) AcceptsOrders() bool {
+	return s == TradingStatusOpen || s.InCall()
+}
+
+// InCall reports whether the symbol is in an auction call period, where
+// orders accumulate without matching until the book is uncrossed.
+func (s TradingStatus) InCall() bool {
+	return s == TradingStatusPreOpen || s == TradingStatusPreClose || s == TradingStatusVolatilityAuction
+}
+
+// AcceptsCancels reports whether resting orders may be cancelled. A halted
+// book is frozen until the halt is lifted.
+func (s TradingStatus) AcceptsCancels() bool {
+	return s != TradingStatusHalted
+}
+
 // Order represents a trading order in the system
 type Order struct {
 	ID        string      `json:"order_id"`
+	Account   string      `json:"account_id,omitempty"`
 	Symbol    string      `json:"symbol"`
 	Side      Side        `json:"side"`
 	Type      OrderType   `json:"type"`
 	Price     int64       `json:"price"`           // cents; required only for LIMIT
 	Quantity  int64       `json:"quantity"`        // total quantity
 	FilledQty int64       `json:"filled_quantity"` // quantity filled so far
+	Fees      int64       `json:"fees"`            // total fees paid in cents, negative for net rebates
 	Status    OrderStatus `json:"status"`
 	Timestamp int64       `json:"timestamp"` // unix ms
+
+	// Market order handling. ProtectionPrice is the worst price a market
+	// order may trade at; 0 means unprotected. A market-to-limit order that
+	// rests shows Type LIMIT with the price it converted at.
+	MarketMode      MarketMode    `json:"market_mode,omitempty"`
+	ProtectionPrice int64         `json:"protection_price,omitempty"`
+	MarketOutcome   MarketOutcome `json:"market_outcome,omitempty"`
+
+	// SessionID is set for orders entered over a trading session. With
+	// CancelOnDisconnect the order is cancelled when that session ends.
+	SessionID          string `json:"session_id,omitempty"`
+	CancelOnDisconnect bool   `json:"cancel_on_disconnect,omitempty"`
+
+	// QuoteID is set for orders entered through a mass quote; the next
+	// quote set from the account replaces them.
+	QuoteID string `json:"quote_id,omitempty"`
+
+	// StopPrice is the trigger of a stop order. Once triggered, Type shows
+	// the order type it entered the book as.
+	StopPrice int64 `json:"stop_price,omitempty"`
+	Triggered bool  `json:"triggered,omitempty"`
+
+	// Trailing stops set exactly one of TrailOffset (price units) or
+	// TrailBps; the engine maintains StopPrice. With LimitOffset they
+	// trigger as a limit order that far beyond the trigger, otherwise as a
+	// market order.
+	TrailOffset int64 `json:"trail_offset,omitempty"`
+	TrailBps    int64 `json:"trail_bps,omitempty"`
+	LimitOffset int64 `json:"limit_offset,omitempty"`
+
+	// Pegged orders track PegReference, PegOffset more aggressive (negative
+	// for less), and never price beyond PegLimit. Price is the current
+	// working price.
+	PegReference PegReference `json:"peg_reference,omitempty"`
+	PegOffset    int64        `json:"peg_offset,omitempty"`
+	PegLimit     int64        `json:"peg_limit,omitempty"`
+
+	// Hidden limit orders match in the lit book but are never displayed and
+	// queue behind displayed orders at their price. Dark limit orders rest
+	// in the symbol's midpoint book and trade only at the lit midpoint.
+	Hidden bool `json:"hidden,omitempty"`
+	Dark   bool `json:"dark,omitempty"`
+
+	// MinQty is the smallest fill a limit order accepts on entry. AllOrNone
+	// orders only ever fill their whole remaining quantity in one match.
+	MinQty    int64 `json:"min_qty,omitempty"`
+	AllOrNone bool  `json:"all_or_none,omitempty"`
+
+	// GroupID links the order to an OCO or bracket group.
+	GroupID string `json:"group_id,omitempty"`
+
+	// ParentOrderID links a leg order to the strategy order it filled for.
+	ParentOrderID string `json:"parent_order_id,omitempty"`
+
+	// Liquidation marks an order the engine entered to close a margin
+	// account's positions. Clients cannot set it.
+	Liquidation bool `json:"liquidation,omitempty"`
 }
 
+// TradeType tells on-book prints apart from trades agreed off the book.
+type TradeType string
+
+const (
+	TradeTypeBook  TradeType = "BOOK"  // matched on the order book, including auctions
+	TradeTypeDark  TradeType = "DARK"  // crossed in the midpoint dark book
+	TradeTypeRFQ   TradeType = "RFQ"   // accepted request-for-quote
+	TradeTypeBlock TradeType = "BLOCK" // negotiated block trade
+)
+
 // Trade represents an executed trade between two orders
 type Trade struct {
-	TradeID   string `json:"trade_id"`
-	BuyOrder  string `json:"buy_order"`
-	SellOrder string `json:"sell_order"`
-	Price     int64  `json:"price"`
-	Quantity  int64  `json:"quantity"`
-	Timestamp int64  `json:"timestamp"`
+	TradeID     string    `json:"trade_id"`
+	Type        TradeType `json:"trade_type"`
+	BuyOrder    string    `json:"buy_order"`
+	SellOrder   string    `json:"sell_order"`
+	BuyAccount  string    `json:"buy_account,omitempty"`
+	SellAccount string    `json:"sell_account,omitempty"`
+	Price       int64     `json:"price"`
+	Quantity    int64     `json:"quantity"`
+	TakerSide   Side      `json:"taker_side"`
+	BuyFee      int64     `json:"buy_fee"`  // cents, negative for rebates
+	SellFee     int64     `json:"sell_fee"` // cents, negative for rebates
+	Timestamp   int64     `json:"timestamp"`
+}
+
+// PublicTrade is the view of a trade published to every market data
+// subscriber. It names neither counterparty, nor their orders or fees.
+type PublicTrade struct {
+	TradeID   string    `json:"trade_id"`
+	Type      TradeType `json:"trade_type"`
+	Price     int64     `json:"price"`
+	Quantity  int64     `json:"quantity"`
+	TakerSide Side      `json:"taker_side"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+// Public returns the trade's public view.
+func (t *Trade) Public() PublicTrade {
+	return PublicTrade{
+		TradeID:   t.TradeID,
+		Type:      t.Type,
+		Price:     t.Price,
+		Quantity:  t.Quantity,
+		TakerSide: t.TakerSide,
+		Timestamp: t.Timestamp,
+	}
 }
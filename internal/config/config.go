@@ -3,19 +3,86 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/prices"
+	"order-matching-engine/internal/risk"
 )
 
 type Config struct {
-	Port           string
-	MetricsEnabled bool
-	WSEnabled      bool
+	Port            string
+	MetricsEnabled  bool
+	WSEnabled       bool
+	LedgerEnabled   bool   // require funded accounts for every order
+	MarginEnabled   bool   // margin checks and liquidations for margined instruments
+	InstrumentsFile string // JSON instrument definitions; empty accepts any symbol
+
+	// RiskLimits are the engine-wide default pre-trade limits.
+	// Per-symbol and per-account limits are managed through the admin API.
+	RiskLimits risk.Limits
+
+	// FeeSchedule is the base fee tier; volume tiers and per-account
+	// overrides are managed through the admin API.
+	FeeSchedule fees.Schedule
+
+	// Order-entry sessions: a session silent for SessionHeartbeat is
+	// dropped, and its cancel-on-disconnect orders are cancelled after
+	// SessionGrace. SessionTokens ("ACC1:token1,ACC2:token2") restricts
+	// logons; when empty no account may log on unless SessionOpen is set.
+	SessionHeartbeat time.Duration
+	SessionGrace     time.Duration
+	SessionTokens    map[string]string
+	SessionOpen      bool
+
+	// LiquidationInterval is how often margin accounts are checked for
+	// liquidation, besides on every mark price update.
+	LiquidationInterval time.Duration
+
+	// MarkPrice is the default mark price config; MARK_PRICE_SOURCES
+	// ("MID,EMA,INDEX") picks its inputs. Marks are recomputed every
+	// MarkPriceInterval, and IndexFile, a JSON map of symbols to index
+	// prices, is re-read whenever it changes.
+	MarkPrice         prices.Config
+	MarkPriceInterval time.Duration
+	IndexFile         string
 }
 
 func Load() *Config {
 	return &Config{
-		Port:           getEnv("PORT", "8080"),
-		MetricsEnabled: getEnvBool("METRICS_ENABLED", true),
-		WSEnabled:      getEnvBool("WS_ENABLED", true),
+		Port:            getEnv("PORT", "8080"),
+		MetricsEnabled:  getEnvBool("METRICS_ENABLED", true),
+		WSEnabled:       getEnvBool("WS_ENABLED", true),
+		LedgerEnabled:   getEnvBool("LEDGER_ENABLED", false),
+		MarginEnabled:   getEnvBool("MARGIN_ENABLED", false),
+		InstrumentsFile: getEnv("INSTRUMENTS_FILE", ""),
+		RiskLimits: risk.Limits{
+			MaxOrderQty:      getEnvInt64("RISK_MAX_ORDER_QTY", 0),
+			MaxNotional:      getEnvInt64("RISK_MAX_NOTIONAL", 0),
+			PriceCollarBps:   getEnvInt64("RISK_PRICE_COLLAR_BPS", 0),
+			MaxOpenOrders:    int(getEnvInt64("RISK_MAX_OPEN_ORDERS", 0)),
+			MaxPosition:      getEnvInt64("RISK_MAX_POSITION", 0),
+			MaxDailyNotional: getEnvInt64("RISK_MAX_DAILY_NOTIONAL", 0),
+		},
+		FeeSchedule: fees.Schedule{Tiers: []fees.Tier{{
+			MakerBps: getEnvInt64("FEE_MAKER_BPS", 0),
+			TakerBps: getEnvInt64("FEE_TAKER_BPS", 0),
+		}}},
+		SessionHeartbeat: time.Duration(getEnvInt64("SESSION_HEARTBEAT_MS", 30000)) * time.Millisecond,
+		SessionGrace:     time.Duration(getEnvInt64("SESSION_GRACE_MS", 0)) * time.Millisecond,
+		SessionTokens:    getEnvPairs("SESSION_TOKENS"),
+		SessionOpen:      getEnvBool("SESSION_OPEN", false),
+
+		LiquidationInterval: time.Duration(getEnvInt64("LIQUIDATION_INTERVAL_MS", 1000)) * time.Millisecond,
+
+		MarkPrice: prices.Config{
+			Sources:       getEnvSources("MARK_PRICE_SOURCES"),
+			EMAPeriod:     int(getEnvInt64("MARK_PRICE_EMA_PERIOD", 0)),
+			IndexMaxAgeMs: getEnvInt64("INDEX_MAX_AGE_MS", 0),
+		},
+		MarkPriceInterval: time.Duration(getEnvInt64("MARK_PRICE_INTERVAL_MS", 1000)) * time.Millisecond,
+		IndexFile:         getEnv("INDEX_FILE", ""),
 	}
 }
 
@@ -34,3 +101,35 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvPairs parses "key:value,key:value" into a map.
+func getEnvPairs(key string) map[string]string {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && k != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// getEnvSources parses a comma-separated list of mark price sources.
+func getEnvSources(key string) []prices.Source {
+	var out []prices.Source
+	for _, s := range strings.Split(os.Getenv(key), ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			out = append(out, prices.Source(s))
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"slices"
+
+	"order-matching-engine/internal/matching"
+)
+
+// builtAlgorithm is an algorithm built from an instrument's matching
+// configuration, kept until the configuration changes.
+type builtAlgorithm struct {
+	config matching.Config
+	alg    matching.Algorithm
+}
+
+// SetMatchingAlgorithm overrides the allocation algorithm of one symbol,
+// taking precedence over its instrument configuration. A nil algorithm
+// removes the override.
+func (m *MatchingEngine) SetMatchingAlgorithm(symbol string, alg matching.Algorithm) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if alg == nil {
+		delete(m.algorithms, symbol)
+		return
+	}
+	m.algorithms[symbol] = alg
+}
+
+// MatchingAlgorithm returns the name of the algorithm a symbol matches with.
+func (m *MatchingEngine) MatchingAlgorithm(symbol string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.algorithmLocked(symbol).Name()
+}
+
+// algorithmLocked resolves a symbol's algorithm: an explicit override, then
+// the instrument's configuration, then FIFO. Algorithms built from an
+// instrument are cached per symbol and rebuilt only when its matching
+// configuration changes. The caller holds m.mu for writing.
+func (m *MatchingEngine) algorithmLocked(symbol string) matching.Algorithm {
+	if alg, ok := m.algorithms[symbol]; ok {
+		return alg
+	}
+	if m.Instruments == nil {
+		return matching.FIFO{}
+	}
+	inst, ok := m.Instruments.Get(symbol)
+	if !ok {
+		return matching.FIFO{}
+	}
+	if b, ok := m.built[symbol]; ok && sameConfig(b.config, inst.Matching) {
+		return b.alg
+	}
+	alg, err := matching.New(inst.Matching)
+	if err != nil {
+		alg = matching.FIFO{}
+	}
+	m.built[symbol] = builtAlgorithm{config: inst.Matching, alg: alg}
+	return alg
+}
+
+func sameConfig(a, b matching.Config) bool {
+	return a.Algorithm == b.Algorithm && a.MinAllocation == b.MinAllocation &&
+		a.TopOrderPct == b.TopOrderPct && a.LMMPct == b.LMMPct &&
+		slices.Equal(a.LMMAccounts, b.LMMAccounts)
+}
@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+var (
+	ErrNotInCall         = errors.New("symbol is not in an auction call period")
+	ErrMarketOrderInCall = errors.New("market orders are not accepted during an auction call")
+)
+
+// AuctionInfo is the outcome of uncrossing a book at a single price. During
+// a call period it is indicative; once Uncrossed is set it is final.
+type AuctionInfo struct {
+	Symbol         string               `json:"symbol"`
+	Phase          common.TradingStatus `json:"phase"`
+	Price          int64                `json:"price"` // 0 if the book does not cross
+	Volume         int64                `json:"volume"`
+	Surplus        int64                `json:"surplus"` // unmatched quantity at Price: >0 buy side, <0 sell side
+	ReferencePrice int64                `json:"reference_price"`
+	Uncrossed      bool                 `json:"uncrossed"`
+	Timestamp      int64                `json:"timestamp"`
+}
+
+// AuctionResult is the final outcome of an uncross with its trades.
+type AuctionResult struct {
+	AuctionInfo
+	Trades []*common.Trade `json:"trades"`
+}
+
+// IndicativeAuction returns the price and volume the book would uncross at
+// right now. The second result is false if the symbol has no book.
+func (m *MatchingEngine) IndicativeAuction(symbol string) (*AuctionInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, ok := m.books[symbol]
+	if !ok {
+		return nil, false
+	}
+	return m.indicativeLocked(book), true
+}
+
+// Uncross ends a call period: the book executes at a single clearing price
+// and moves on to OPEN (opening or volatility auction) or CLOSED (closing
+// auction).
+func (m *MatchingEngine) Uncross(symbol, reason string) (*AuctionResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[symbol]
+	if !ok || !book.Status.InCall() {
+		return nil, ErrNotInCall
+	}
+
+	next := common.TradingStatusOpen
+	if book.Status == common.TradingStatusPreClose {
+		next = common.TradingStatusClosed
+	}
+
+	_, res, err := m.transitionLocked(book, next, reason)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *MatchingEngine) indicativeLocked(book *orderbook.OrderBook) *AuctionInfo {
+	price, volume, surplus := clearingPrice(book, book.LastPrice)
+	return &AuctionInfo{
+		Symbol:         book.Symbol,
+		Phase:          book.Status,
+		Price:          price,
+		Volume:         volume,
+		Surplus:        surplus,
+		ReferencePrice: book.LastPrice,
+		Timestamp:      time.Now().UnixMilli(),
+	}
+}
+
+// emitAuctionLocked publishes the current indicative uncross. The caller holds m.mu.
+func (m *MatchingEngine) emitAuctionLocked(book *orderbook.OrderBook) {
+	m.emit(Event{Type: EventAuction, Symbol: book.Symbol, Auction: m.indicativeLocked(book)})
+}
+
+// uncrossLocked executes all crossing interest at the clearing price,
+// allocating fills by price-time priority on both sides. The caller holds m.mu.
+func (m *MatchingEngine) uncrossLocked(book *orderbook.OrderBook) *AuctionResult {
+	info := m.indicativeLocked(book)
+	info.Uncrossed = true
+	res := &AuctionResult{AuctionInfo: *info, Trades: make([]*common.Trade, 0)}
+	if info.Volume == 0 {
+		return res
+	}
+
+	remaining := info.Volume
+	for remaining > 0 {
+		bidLevel, buy := auctionHead(book.Bids)
+		askLevel, sell := auctionHead(book.Asks)
+
+		qty := remaining
+		if r := buy.Quantity - buy.FilledQty; r < qty {
+			qty = r
+		}
+		if r := sell.Quantity - sell.FilledQty; r < qty {
+			qty = r
+		}
+
+		remaining -= qty
+		for _, o := range [2]*common.Order{buy, sell} {
+			o.FilledQty += qty
+			if o.FilledQty == o.Quantity {
+				o.Status = common.OrderStatusFilled
+			} else {
+				o.Status = common.OrderStatusPartial
+			}
+		}
+		book.Bids.TotalQuantity -= qty
+		book.Asks.TotalQuantity -= qty
+
+		res.Trades = append(res.Trades, m.recordMatch(book, buy, sell, "", info.Price, qty))
+
+		m.removeFilled(book.Bids, bidLevel)
+		m.removeFilled(book.Asks, askLevel)
+	}
+	book.ReferencePrice = info.Price
+
+	m.emit(Event{Type: EventAuction, Symbol: book.Symbol, Auction: info})
+	return res
+}
+
+// auctionHead is the first order in price-time priority that takes part
+// in an uncross. All-or-none orders sit auctions out and keep resting.
+func auctionHead(side *orderbook.SideBook) (*orderbook.PriceLevel, *common.Order) {
+	for _, p := range side.Prices {
+		level := side.Levels[p]
+		for _, o := range level.Orders {
+			if !o.AllOrNone {
+				return level, o
+			}
+		}
+	}
+	return nil, nil
+}
+
+// clearingPrice finds the single price that maximises executable volume.
+// Ties are broken by minimum surplus, then market pressure (highest price
+// for a buy surplus, lowest for a sell surplus), then proximity to the
+// reference price, then the middle of the remaining range.
+func clearingPrice(book *orderbook.OrderBook, ref int64) (price, volume, surplus int64) {
+	bestBid, okBid := book.Bids.BestPrice()
+	bestAsk, okAsk := book.Asks.BestPrice()
+	if !okBid || !okAsk || bestBid < bestAsk {
+		return 0, 0, 0
+	}
+
+	type candidate struct{ price, volume, surplus int64 }
+	var tied []candidate
+
+	for _, p := range candidatePrices(book) {
+		buy := int64(0)
+		for _, bp := range book.Bids.Prices {
+			if bp < p {
+				break
+			}
+			buy += levelQuantity(book.Bids.Levels[bp])
+		}
+		sell := int64(0)
+		for _, ap := range book.Asks.Prices {
+			if ap > p {
+				break
+			}
+			sell += levelQuantity(book.Asks.Levels[ap])
+		}
+		c := candidate{price: p, volume: buy, surplus: buy - sell}
+		if sell < buy {
+			c.volume = sell
+		}
+		if c.volume == 0 {
+			continue
+		}
+
+		switch {
+		case len(tied) == 0 || c.volume > tied[0].volume:
+			tied = []candidate{c}
+		case c.volume == tied[0].volume:
+			if abs(c.surplus) < abs(tied[0].surplus) {
+				tied = []candidate{c}
+			} else if abs(c.surplus) == abs(tied[0].surplus) {
+				tied = append(tied, c)
+			}
+		}
+	}
+	if len(tied) == 0 {
+		return 0, 0, 0
+	}
+
+	pick := func(c candidate) (int64, int64, int64) { return c.price, c.volume, c.surplus }
+	if len(tied) == 1 {
+		return pick(tied[0])
+	}
+
+	// Candidates are in ascending price order.
+	allBuy, allSell := true, true
+	for _, c := range tied {
+		allBuy = allBuy && c.surplus > 0
+		allSell = allSell && c.surplus < 0
+	}
+	if allBuy {
+		return pick(tied[len(tied)-1])
+	}
+	if allSell {
+		return pick(tied[0])
+	}
+
+	if ref > 0 {
+		best := tied[0]
+		for _, c := range tied[1:] {
+			if abs(c.price-ref) < abs(best.price-ref) {
+				best = c
+			}
+		}
+		return pick(best)
+	}
+	return pick(tied[(len(tied)-1)/2])
+}
+
+// candidatePrices returns the distinct limit prices of both sides, ascending.
+func candidatePrices(book *orderbook.OrderBook) []int64 {
+	seen := make(map[int64]bool, len(book.Bids.Prices)+len(book.Asks.Prices))
+	out := make([]int64, 0, len(seen))
+	for _, prices := range [2][]int64{book.Bids.Prices, book.Asks.Prices} {
+		for _, p := range prices {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// levelQuantity is the quantity a level brings to an uncross.
+func levelQuantity(level *orderbook.PriceLevel) int64 {
+	qty := int64(0)
+	for _, o := range level.Orders {
+		if !o.AllOrNone {
+			qty += o.Quantity - o.FilledQty
+		}
+	}
+	return qty
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/orderbook"
+)
+
+var ErrPriceBandBreached = errors.New("execution would breach the price band")
+
+// priceBand is the range trades may print in for one incoming order. A zero
+// bound is open.
+type priceBand struct {
+	low, high int64
+	action    instruments.BandAction
+	auction   time.Duration
+}
+
+func (b priceBand) allows(price int64) bool {
+	return (b.low == 0 || price >= b.low) && (b.high == 0 || price <= b.high)
+}
+
+// narrow intersects the band with ref ± bps.
+func (b *priceBand) narrow(ref, bps int64) {
+	if ref <= 0 || bps <= 0 {
+		return
+	}
+	width := ref * bps / 10000
+	if low := ref - width; low > 0 && low > b.low {
+		b.low = low
+	}
+	if high := ref + width; b.high == 0 || high < b.high {
+		b.high = high
+	}
+}
+
+// priceBandLocked computes the band for the next incoming order from the
+// instrument's configuration. The dynamic band is anchored on the last trade
+// before the order, so a single order cannot walk it. The caller holds m.mu.
+func (m *MatchingEngine) priceBandLocked(book *orderbook.OrderBook) priceBand {
+	var band priceBand
+	if m.Instruments == nil {
+		return band
+	}
+	inst, ok := m.Instruments.Get(book.Symbol)
+	if !ok {
+		return band
+	}
+	band.action = inst.BandAction
+	band.auction = time.Duration(inst.VolatilityAuctionMs) * time.Millisecond
+	band.narrow(book.ReferencePrice, inst.StaticBandBps)
+	band.narrow(book.LastPrice, inst.DynamicBandBps)
+	return band
+}
+
+// breachLocked applies the instrument's band action once matching of o has
+// stopped at the band. It reports whether the symbol left continuous
+// trading, in which case a limit remainder may rest for the later uncross.
+// The caller holds m.mu.
+func (m *MatchingEngine) breachLocked(book *orderbook.OrderBook, o *common.Order, band priceBand) bool {
+	opposite := book.Asks
+	if o.Side == common.SideSell {
+		opposite = book.Bids
+	}
+	price, _ := opposite.BestPrice()
+	reason := fmt.Sprintf("price band breached at %d (band %d-%d)", price, band.low, band.high)
+
+	switch band.action {
+	case instruments.BandActionHalt:
+		_, err := m.setStatusLocked(book, common.TradingStatusHalted, reason)
+		return err == nil
+	case instruments.BandActionAuction:
+		if _, err := m.setStatusLocked(book, common.TradingStatusVolatilityAuction, reason); err != nil {
+			return false
+		}
+		m.scheduleUncrossLocked(book.Symbol, band.auction)
+		return true
+	}
+	return false
+}
+
+// scheduleUncrossLocked reopens the symbol once its volatility auction has
+// run for d. Any other status change in the meantime cancels the timer.
+// The caller holds m.mu.
+func (m *MatchingEngine) scheduleUncrossLocked(symbol string, d time.Duration) {
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.volatilityTimers[symbol] != t {
+			return
+		}
+		if book, ok := m.books[symbol]; ok {
+			_, _ = m.setStatusLocked(book, common.TradingStatusOpen, "volatility auction ended")
+		}
+	})
+	m.volatilityTimers[symbol] = t
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+var (
+	ErrBlockTooSmall    = errors.New("quantity below the minimum block size")
+	ErrBlockPriceBand   = errors.New("block price outside the band around the book")
+	ErrNoReferencePrice = errors.New("no reference price to validate against")
+)
+
+// DefaultBlockBandBps is the block trade price band for symbols whose
+// instrument does not set one.
+const DefaultBlockBandBps = 1000
+
+// BlockTrade is a trade agreed bilaterally between two accounts and reported
+// to the engine for printing.
+type BlockTrade struct {
+	Symbol      string `json:"symbol"`
+	BuyAccount  string `json:"buy_account"`
+	SellAccount string `json:"sell_account"`
+	Price       int64  `json:"price"`
+	Quantity    int64  `json:"quantity"`
+}
+
+// SubmitBlockTrade validates a negotiated trade against the current book
+// and prints it as a BLOCK trade. Neither side is the aggressor, so both
+// pay the maker fee.
+func (m *MatchingEngine) SubmitBlockTrade(req BlockTrade) (*common.Trade, error) {
+	if req.BuyAccount == "" || req.SellAccount == "" {
+		return nil, ErrAccountRequired
+	}
+	if req.BuyAccount == req.SellAccount || req.Price <= 0 {
+		return nil, ErrInvalidOrderData
+	}
+	probe := &common.Order{Symbol: req.Symbol, Side: common.SideBuy, Type: common.OrderTypeLimit,
+		Price: req.Price, Quantity: req.Quantity}
+	if err := validateOrderRequest(probe, m.Instruments); err != nil {
+		return nil, err
+	}
+
+	bandBps, minQty := int64(DefaultBlockBandBps), int64(0)
+	if m.Instruments != nil {
+		if inst, ok := m.Instruments.Get(req.Symbol); ok {
+			minQty = inst.MinBlockQty
+			if inst.BlockBandBps > 0 {
+				bandBps = inst.BlockBandBps
+			}
+		}
+	}
+	if req.Quantity < minQty {
+		return nil, ErrBlockTooSmall
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.killedLocked(req.BuyAccount) || m.killedLocked(req.SellAccount) {
+		return nil, ErrKillSwitchActive
+	}
+	book := m.ensureBook(req.Symbol)
+	if !book.Status.AcceptsOrders() {
+		return nil, ErrSymbolNotOpen
+	}
+
+	ref := blockReference(book)
+	if ref == 0 {
+		return nil, ErrNoReferencePrice
+	}
+	var band priceBand
+	band.narrow(ref, bandBps)
+	if !band.allows(req.Price) {
+		return nil, ErrBlockPriceBand
+	}
+
+	return m.offBookTradeLocked(book, common.TradeTypeBlock, "", req.BuyAccount, req.SellAccount, req.Price, req.Quantity)
+}
+
+// blockReference is the midpoint of the displayed best bid and ask, falling
+// back to the last trade price, or 0 if the book has neither.
+func blockReference(book *orderbook.OrderBook) int64 {
+	bid, okBid := book.Bids.BestVisiblePrice()
+	ask, okAsk := book.Asks.BestVisiblePrice()
+	if okBid && okAsk {
+		return (bid + ask) / 2
+	}
+	return book.LastPrice
+}
+
+// offBookTradeLocked prints a trade agreed outside the book. Each side gets
+// a filled limit order at the trade price, with funds and pre-trade risk
+// checked as for any order; the trade is then stored, settled and
+// published like a book trade. The book and its last price are left
+// untouched. aggressor is empty when neither side took liquidity. The
+// caller holds m.mu.
+func (m *MatchingEngine) offBookTradeLocked(book *orderbook.OrderBook, typ common.TradeType, aggressor common.Side, buyAccount, sellAccount string, price, qty int64) (*common.Trade, error) {
+	buy := m.createOrder(&common.Order{Account: buyAccount, Symbol: book.Symbol, Side: common.SideBuy,
+		Type: common.OrderTypeLimit, Price: price, Quantity: qty})
+	sell := m.createOrder(&common.Order{Account: sellAccount, Symbol: book.Symbol, Side: common.SideSell,
+		Type: common.OrderTypeLimit, Price: price, Quantity: qty})
+
+	// The orders never rest, so open-order limits do not apply.
+	mkt := m.marketState(book, "")
+	if err := m.Risk.Check(buy, mkt); err != nil {
+		return nil, err
+	}
+	if err := m.Risk.Check(sell, mkt); err != nil {
+		return nil, err
+	}
+	if err := m.checkMarginLocked(buy); err != nil {
+		return nil, err
+	}
+	if err := m.checkMarginLocked(sell); err != nil {
+		return nil, err
+	}
+	if err := m.reserveFunds(book, buy); err != nil {
+		return nil, err
+	}
+	if err := m.reserveFunds(book, sell); err != nil {
+		m.releaseFunds(buy)
+		return nil, err
+	}
+
+	fill(buy, qty)
+	fill(sell, qty)
+	m.orders[buy.ID] = buy
+	m.orders[sell.ID] = sell
+
+	trade := newTrade(typ, buy, sell, aggressor, price, qty)
+	m.bookTradeLocked(book.Symbol, trade, buy, sell)
+	atomic.AddUint64(&m.Metrics.TradesExecuted, 1)
+	return trade, nil
+}
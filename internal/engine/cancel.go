@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+// Cancel reasons carried by cancellation events.
+const (
+	CancelReasonUser       = "user"
+	CancelReasonMassCancel = "mass_cancel"
+	CancelReasonDisconnect = "disconnect"
+	CancelReasonOCO        = "oco"             // a linked order filled or triggered
+	CancelReasonGroup      = "group_cancelled" // the bracket was abandoned
+	CancelReasonRejected   = "rejected"        // a stop or exit the book refused on entry
+)
+
+// Cancellation reports a resting order removed from the book.
+type Cancellation struct {
+	OrderID      string      `json:"order_id"`
+	Account      string      `json:"account_id,omitempty"`
+	Symbol       string      `json:"symbol"`
+	Side         common.Side `json:"side"`
+	Price        int64       `json:"price"`
+	CancelledQty int64       `json:"cancelled_quantity"`
+	Reason       string      `json:"reason"`
+	Timestamp    int64       `json:"timestamp"`
+
+	// Hidden is set for hidden and dark orders, whose cancels are not
+	// published to market data.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Public returns the cancellation without the account, for market data.
+func (c *Cancellation) Public() *Cancellation {
+	cp := *c
+	cp.Account = ""
+	return &cp
+}
+
+// MassCancelFilter selects resting orders. Zero fields match everything;
+// the price range is inclusive.
+type MassCancelFilter struct {
+	Account  string      `json:"account_id"`
+	Symbol   string      `json:"symbol"`
+	Side     common.Side `json:"side"`
+	MinPrice int64       `json:"min_price"`
+	MaxPrice int64       `json:"max_price"`
+}
+
+func (f MassCancelFilter) valid() bool {
+	if f.Side != "" && f.Side != common.SideBuy && f.Side != common.SideSell {
+		return false
+	}
+	if f.MinPrice < 0 || f.MaxPrice < 0 {
+		return false
+	}
+	return f.MaxPrice == 0 || f.MinPrice <= f.MaxPrice
+}
+
+func (f MassCancelFilter) matches(o *common.Order) bool {
+	switch {
+	case f.Account != "" && o.Account != f.Account:
+		return false
+	case f.Side != "" && o.Side != f.Side:
+		return false
+	case f.MinPrice > 0 && o.Price < f.MinPrice:
+		return false
+	case f.MaxPrice > 0 && o.Price > f.MaxPrice:
+		return false
+	}
+	return true
+}
+
+// MassCancel cancels every resting order matching the filter and returns
+// their IDs. Books that do not accept cancels (halted) are skipped. Each
+// cancelled order produces its own cancel event.
+func (m *MatchingEngine) MassCancel(f MassCancelFilter) ([]string, error) {
+	if !f.valid() {
+		return nil, ErrInvalidOrderData
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := m.massCancelLocked(f.Symbol, f.matches, CancelReasonMassCancel)
+	atomic.AddUint64(&m.Metrics.OrdersCancelled, uint64(len(ids)))
+	return ids, nil
+}
+
+// massCancelLocked cancels the resting, dark and untriggered stop orders of symbol (all symbols if
+// empty) accepted by match, symbol by symbol, best price first. Halted books
+// are skipped. The caller holds m.mu.
+func (m *MatchingEngine) massCancelLocked(symbol string, match func(*common.Order) bool, reason string) []string {
+	return m.cancelMatchingLocked(symbol, match, reason, false)
+}
+
+// forceCancelLocked is massCancelLocked for risk controls: it cancels on
+// halted books too, so the orders do not come back to life when trading
+// resumes. The caller holds m.mu.
+func (m *MatchingEngine) forceCancelLocked(symbol string, match func(*common.Order) bool, reason string) []string {
+	return m.cancelMatchingLocked(symbol, match, reason, true)
+}
+
+func (m *MatchingEngine) cancelMatchingLocked(symbol string, match func(*common.Order) bool, reason string, force bool) []string {
+	symbols := make([]string, 0, len(m.books))
+	for sym := range m.books {
+		if symbol == "" || sym == symbol {
+			symbols = append(symbols, sym)
+		}
+	}
+	sort.Strings(symbols)
+
+	ids := make([]string, 0)
+	for _, sym := range symbols {
+		book := m.books[sym]
+		if !force && !book.Status.AcceptsCancels() {
+			continue
+		}
+
+		var victims []*common.Order
+		for _, sb := range [2]*orderbook.SideBook{book.Bids, book.Asks} {
+			for _, p := range sb.Prices {
+				for _, o := range sb.Levels[p].Orders {
+					if match(o) {
+						victims = append(victims, o)
+					}
+				}
+			}
+		}
+		for _, o := range m.stops[sym] {
+			if match(o) {
+				victims = append(victims, o)
+			}
+		}
+		if db, ok := m.dark[sym]; ok {
+			for _, side := range [2][]*common.Order{db.bids, db.asks} {
+				for _, o := range side {
+					if match(o) {
+						victims = append(victims, o)
+					}
+				}
+			}
+		}
+		if len(victims) == 0 {
+			continue
+		}
+
+		for _, o := range victims {
+			if m.cancelLocked(book, o, reason) == nil {
+				ids = append(ids, o.ID)
+			}
+		}
+		if book.Status.InCall() {
+			m.emitAuctionLocked(book)
+		}
+		m.processContingentLocked(book)
+	}
+	return ids
+}
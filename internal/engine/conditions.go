@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/matching"
+	"order-matching-engine/internal/orderbook"
+)
+
+var ErrMinQtyNotMet = errors.New("minimum quantity not available")
+
+// allocate runs the matching algorithm over a level in two tiers: displayed
+// orders first, hidden orders only for what is left.
+func allocate(alg matching.Algorithm, level *orderbook.PriceLevel, qty int64) []matching.Fill {
+	split := level.HiddenIndex()
+	fills := allocateTier(alg, level.Price, level.Orders[:split], qty)
+	for _, f := range fills {
+		qty -= f.Qty
+	}
+	if qty > 0 && split < len(level.Orders) {
+		fills = append(fills, allocateTier(alg, level.Price, level.Orders[split:], qty)...)
+	}
+	return fills
+}
+
+// allocateTier runs the algorithm over orders, leaving out all-or-none
+// orders it cannot fill completely. The other orders keep their relative
+// queue order, so FIFO priority among them is unchanged.
+func allocateTier(alg matching.Algorithm, price int64, orders []*common.Order, qty int64) []matching.Fill {
+	eligible := orders
+	skip := func(o *common.Order, got int64) bool {
+		return o.AllOrNone && got < o.Quantity-o.FilledQty
+	}
+	if hasAON(orders) {
+		eligible = withoutAON(orders, func(o *common.Order) bool { return skip(o, qty) })
+	}
+
+	for {
+		if len(eligible) == 0 {
+			return nil
+		}
+		fills := alg.Allocate(&orderbook.PriceLevel{Price: price, Orders: eligible}, qty)
+
+		// Pro-rata shares can leave an all-or-none order partly filled;
+		// drop it and allocate again.
+		partial := make(map[*common.Order]bool)
+		for _, f := range fills {
+			if skip(f.Order, f.Qty) {
+				partial[f.Order] = true
+			}
+		}
+		if len(partial) == 0 {
+			return fills
+		}
+		eligible = withoutAON(eligible, func(o *common.Order) bool { return partial[o] })
+	}
+}
+
+func hasAON(orders []*common.Order) bool {
+	for _, o := range orders {
+		if o.AllOrNone {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutAON(orders []*common.Order, drop func(*common.Order) bool) []*common.Order {
+	out := make([]*common.Order, 0, len(orders))
+	for _, o := range orders {
+		if !drop(o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// executableLocked is how much of o would fill right now: a dry run of
+// execute over the levels crosses accepts within the band. The caller
+// holds m.mu.
+func (m *MatchingEngine) executableLocked(book *orderbook.OrderBook, o *common.Order, band priceBand, crosses func(int64) bool) int64 {
+	opposite := book.Asks
+	if o.Side == common.SideSell {
+		opposite = book.Bids
+	}
+	alg := m.algorithmLocked(book.Symbol)
+
+	need := o.Quantity - o.FilledQty
+	for _, price := range opposite.Prices {
+		if need == 0 || !crosses(price) || !band.allows(price) {
+			break
+		}
+		for _, f := range allocate(alg, opposite.Levels[price], need) {
+			need -= f.Qty
+		}
+	}
+	return o.Quantity - o.FilledQty - need
+}
+
+// checkConditionsLocked applies an incoming limit order's fill conditions.
+// It reports whether the order may trade now; otherwise it only rests. A
+// minimum-quantity order that crosses but cannot reach its minimum is
+// rejected. The caller holds m.mu.
+func (m *MatchingEngine) checkConditionsLocked(book *orderbook.OrderBook, o *common.Order, band priceBand) (bool, error) {
+	if !o.AllOrNone && o.MinQty == 0 {
+		return true, nil
+	}
+	exec := m.executableLocked(book, o, band, func(price int64) bool { return limitCrosses(o, price) })
+	switch {
+	case o.AllOrNone && exec < o.Quantity-o.FilledQty:
+		return false, nil
+	case exec == 0:
+		return false, nil
+	case exec < o.MinQty:
+		return false, ErrMinQtyNotMet
+	}
+	return true, nil
+}
+
+// crossAONLocked re-checks resting all-or-none orders that cross the
+// opposite side. An order entered later may have added enough liquidity
+// for one to fill completely; it is then taken off the book and executed
+// as the aggressor. It reports whether anything traded. The caller holds
+// m.mu.
+func (m *MatchingEngine) crossAONLocked(book *orderbook.OrderBook) bool {
+	if book.Status != common.TradingStatusOpen {
+		return false
+	}
+	if _, ok := m.strategyFor(book.Symbol); ok {
+		return false
+	}
+
+	band := m.priceBandLocked(book)
+	traded := false
+	for _, o := range crossingAON(book) {
+		crosses := func(price int64) bool { return limitCrosses(o, price) }
+		if !live(o) || m.executableLocked(book, o, band, crosses) < o.Quantity-o.FilledQty {
+			continue
+		}
+		if _, ok := m.unlinkLocked(book, o); !ok {
+			continue
+		}
+		trades, _ := m.execute(book, o, band, crosses)
+		o.Status = common.OrderStatusFilled
+		m.untrackOpen(o)
+		m.touchGroupLocked(o)
+		atomic.AddUint64(&m.Metrics.OrdersMatched, 1)
+		atomic.AddUint64(&m.Metrics.TradesExecuted, uint64(len(trades)))
+		traded = true
+	}
+	return traded
+}
+
+// crossingAON lists the resting all-or-none orders priced through the
+// opposite side's best price, best price first.
+func crossingAON(book *orderbook.OrderBook) []*common.Order {
+	var out []*common.Order
+	for _, side := range []*orderbook.SideBook{book.Bids, book.Asks} {
+		opposite := book.Asks
+		if side == book.Asks {
+			opposite = book.Bids
+		}
+		best, ok := opposite.BestPrice()
+		if !ok {
+			continue
+		}
+		for _, price := range side.Prices {
+			if (side == book.Bids && price < best) || (side == book.Asks && price > best) {
+				break
+			}
+			for _, o := range side.Levels[price].Orders {
+				if o.AllOrNone {
+					out = append(out, o)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func limitCrosses(o *common.Order, price int64) bool {
+	if o.Side == common.SideBuy {
+		return price <= o.Price
+	}
+	return price >= o.Price
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+// darkBook is a symbol's midpoint crossing book. Orders never display and
+// trade with each other in time priority, only at the lit book's midpoint
+// and only where that midpoint is within both limits.
+type darkBook struct {
+	bids []*common.Order
+	asks []*common.Order
+}
+
+func (m *MatchingEngine) darkBookLocked(symbol string) *darkBook {
+	db, ok := m.dark[symbol]
+	if !ok {
+		db = &darkBook{}
+		m.dark[symbol] = db
+	}
+	return db
+}
+
+// litMidpoint is the midpoint of the displayed best bid and ask. Prices are
+// whole units, so when the spread is an odd number of units the midpoint
+// falls between lo and hi = lo+1; otherwise lo == hi.
+func litMidpoint(book *orderbook.OrderBook) (lo, hi int64, ok bool) {
+	bid, okBid := book.Bids.BestVisiblePrice()
+	ask, okAsk := book.Asks.BestVisiblePrice()
+	if !okBid || !okAsk || bid >= ask {
+		return 0, 0, false
+	}
+	return (bid + ask) / 2, (bid + ask + 1) / 2, true
+}
+
+// darkAccepts reports whether a dark order's limit allows trading at price.
+func darkAccepts(o *common.Order, price int64) bool {
+	if o.Side == common.SideBuy {
+		return price <= o.Price
+	}
+	return price >= o.Price
+}
+
+// executeDarkLocked matches an incoming dark order against the opposite
+// side of the midpoint book. A midpoint between two units rounds in favour
+// of the resting order: an incoming buy pays hi, an incoming sell gets lo.
+// The caller holds m.mu.
+func (m *MatchingEngine) executeDarkLocked(book *orderbook.OrderBook, o *common.Order) []*common.Trade {
+	lo, hi, ok := litMidpoint(book)
+	if !ok {
+		return nil
+	}
+	price := lo
+	if o.Side == common.SideBuy {
+		price = hi
+	}
+	if !darkAccepts(o, price) {
+		return nil
+	}
+	db := m.darkBookLocked(book.Symbol)
+	opposite := &db.asks
+	if o.Side == common.SideSell {
+		opposite = &db.bids
+	}
+
+	var trades []*common.Trade
+	for _, r := range *opposite {
+		if o.FilledQty == o.Quantity {
+			break
+		}
+		if !darkAccepts(r, price) {
+			continue
+		}
+		qty := min(o.Quantity-o.FilledQty, r.Quantity-r.FilledQty)
+		fill(o, qty)
+		fill(r, qty)
+		buy, sell := o, r
+		if o.Side == common.SideSell {
+			buy, sell = r, o
+		}
+		trades = append(trades, m.darkTradeLocked(book, buy, sell, o.Side, price, qty))
+	}
+	m.pruneDark(opposite)
+	return trades
+}
+
+// crossDarkLocked trades resting dark orders with each other after the lit
+// midpoint moved. Neither side is the aggressor; a midpoint between two
+// units rounds in favour of the order entered first, and the buyer on a
+// tie. It reports whether anything traded. The caller holds m.mu.
+func (m *MatchingEngine) crossDarkLocked(book *orderbook.OrderBook) bool {
+	db, ok := m.dark[book.Symbol]
+	if !ok || book.Status != common.TradingStatusOpen {
+		return false
+	}
+	lo, hi, ok := litMidpoint(book)
+	if !ok {
+		return false
+	}
+
+	traded := false
+	for _, buy := range db.bids {
+		for _, sell := range db.asks {
+			if buy.FilledQty == buy.Quantity {
+				break
+			}
+			price := lo
+			if sell.Timestamp < buy.Timestamp {
+				price = hi
+			}
+			if sell.FilledQty == sell.Quantity || !darkAccepts(buy, price) || !darkAccepts(sell, price) {
+				continue
+			}
+			qty := min(buy.Quantity-buy.FilledQty, sell.Quantity-sell.FilledQty)
+			fill(buy, qty)
+			fill(sell, qty)
+			m.darkTradeLocked(book, buy, sell, "", price, qty)
+			traded = true
+		}
+	}
+	m.pruneDark(&db.bids)
+	m.pruneDark(&db.asks)
+	return traded
+}
+
+// darkTradeLocked stores a midpoint book trade. It is published as a DARK
+// trade and, like off-book trades, leaves the lit book's last and reference
+// prices and trailing stops alone: it did not happen at a lit price. The
+// caller holds m.mu.
+func (m *MatchingEngine) darkTradeLocked(book *orderbook.OrderBook, buy, sell *common.Order, aggressor common.Side, price, qty int64) *common.Trade {
+	trade := newTrade(common.TradeTypeDark, buy, sell, aggressor, price, qty)
+	m.bookTradeLocked(book.Symbol, trade, buy, sell)
+	return trade
+}
+
+// pruneDark drops filled orders from one side of a midpoint book.
+func (m *MatchingEngine) pruneDark(side *[]*common.Order) {
+	kept := (*side)[:0]
+	for _, o := range *side {
+		if o.FilledQty < o.Quantity {
+			kept = append(kept, o)
+			continue
+		}
+		m.untrackOpen(o)
+	}
+	for i := len(kept); i < len(*side); i++ {
+		(*side)[i] = nil
+	}
+	*side = kept
+}
+
+// unlinkDarkLocked takes a resting dark order out of its midpoint book and
+// returns its remaining quantity.
+func (m *MatchingEngine) unlinkDarkLocked(o *common.Order) (int64, bool) {
+	db, ok := m.dark[o.Symbol]
+	if !ok {
+		return 0, false
+	}
+	side := &db.bids
+	if o.Side == common.SideSell {
+		side = &db.asks
+	}
+	for i, r := range *side {
+		if r == o {
+			*side = append((*side)[:i], (*side)[i+1:]...)
+			return o.Quantity - o.FilledQty, true
+		}
+	}
+	return 0, false
+}
+
+// fill adds qty to an order's fills and updates its status.
+func fill(o *common.Order, qty int64) {
+	o.FilledQty += qty
+	if o.FilledQty == o.Quantity {
+		o.Status = common.OrderStatusFilled
+	} else {
+		o.Status = common.OrderStatusPartial
+	}
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+)
+
+var ErrNotExpired = errors.New("instrument has not expired")
+
+// CancelReasonExpired marks orders cancelled when their instrument expired.
+const CancelReasonExpired = "expired"
+
+// SettlementSource is where a final settlement price came from.
+type SettlementSource string
+
+const (
+	SettlementLastTrade SettlementSource = "LAST_TRADE" // futures: the last trade price
+	SettlementIntrinsic SettlementSource = "INTRINSIC"  // options: intrinsic value at the underlying price
+	SettlementPending   SettlementSource = "PENDING"    // no price was available; awaiting an admin price
+	SettlementAdmin     SettlementSource = "ADMIN"      // set by an operator
+)
+
+// Settlement is the final settlement price of an expired derivative. For
+// options, Price is the intrinsic value per contract unit and
+// UnderlyingPrice the price it was derived from.
+type Settlement struct {
+	Symbol          string           `json:"symbol"`
+	Price           int64            `json:"price"`
+	UnderlyingPrice int64            `json:"underlying_price,omitempty"`
+	Source          SettlementSource `json:"source"`
+	Timestamp       int64            `json:"timestamp"`
+}
+
+// multiplier is the contract multiplier of symbol, 1 for spot instruments
+// and unknown symbols.
+func (m *MatchingEngine) multiplier(symbol string) int64 {
+	if m.Instruments != nil {
+		if inst, ok := m.Instruments.Get(symbol); ok && inst.ContractMultiplier > 0 {
+			return inst.ContractMultiplier
+		}
+	}
+	return 1
+}
+
+// notional is the absolute value of qty contracts of symbol at price.
+// Strategy prices can be negative; fees and limits apply to the absolute
+// value.
+func (m *MatchingEngine) notional(symbol string, price, qty int64) int64 {
+	n := price * qty * m.multiplier(symbol)
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ScheduleExpiries arms an expiry timer for every registered future and
+// option that has not settled yet, replacing timers armed earlier. Call it
+// after loading or changing instruments; instruments already past expiry
+// expire at once.
+func (m *MatchingEngine) ScheduleExpiries() {
+	if m.Instruments == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, inst := range m.Instruments.List() {
+		if inst.Expiry == 0 {
+			continue
+		}
+		if _, settled := m.settlements[inst.Symbol]; settled {
+			continue
+		}
+		if t, ok := m.expiryTimers[inst.Symbol]; ok {
+			t.Stop()
+		}
+		symbol := inst.Symbol
+		m.expiryTimers[symbol] = time.AfterFunc(time.Until(time.UnixMilli(inst.Expiry)), func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.expireLocked(symbol)
+		})
+	}
+}
+
+// expireLocked stops trading in an expired derivative: its open orders are
+// cancelled, the book is closed and the final settlement price recorded.
+// It does nothing if the instrument was removed, has not expired (its
+// expiry moved) or has settled already. The caller holds m.mu.
+func (m *MatchingEngine) expireLocked(symbol string) {
+	delete(m.expiryTimers, symbol)
+	inst, ok := m.Instruments.Get(symbol)
+	if !ok || !inst.Expired(time.Now()) {
+		return
+	}
+	if _, settled := m.settlements[symbol]; settled {
+		return
+	}
+
+	// Cancel before closing so that closing does not uncross a call book.
+	// A halted book is frozen, so it moves to cancel-only first.
+	book := m.ensureBook(symbol)
+	if book.Status == common.TradingStatusHalted {
+		m.setStatusLocked(book, common.TradingStatusCancelOnly, "expired")
+	}
+	m.massCancelLocked(symbol, func(*common.Order) bool { return true }, CancelReasonExpired)
+	if book.Status != common.TradingStatusClosed {
+		m.setStatusLocked(book, common.TradingStatusClosed, "expired")
+	}
+
+	s := &Settlement{Symbol: symbol, Source: SettlementPending, Timestamp: time.Now().UnixMilli()}
+	switch inst.Type {
+	case instruments.TypeFuture:
+		if book.LastPrice > 0 {
+			s.Price, s.Source = book.LastPrice, SettlementLastTrade
+		}
+	case instruments.TypeOption:
+		if u := m.underlyingPriceLocked(inst.Underlying); u > 0 {
+			s.UnderlyingPrice, s.Price, s.Source = u, intrinsic(inst, u), SettlementIntrinsic
+		}
+	}
+	m.settlements[symbol] = s
+}
+
+// underlyingPriceLocked is the price an option settles against: the
+// underlying's own settlement if it has one, else its last trade price, or
+// 0 if neither exists. The caller holds m.mu.
+func (m *MatchingEngine) underlyingPriceLocked(symbol string) int64 {
+	if s, ok := m.settlements[symbol]; ok && s.Price > 0 {
+		return s.Price
+	}
+	if book, ok := m.books[symbol]; ok {
+		return book.LastPrice
+	}
+	return 0
+}
+
+// intrinsic is an option's value at underlying price u.
+func intrinsic(inst instruments.Instrument, u int64) int64 {
+	v := u - inst.Strike
+	if inst.OptionType == instruments.OptionPut {
+		v = -v
+	}
+	return max(v, 0)
+}
+
+// Settlement returns the final settlement of an expired derivative.
+func (m *MatchingEngine) Settlement(symbol string) (Settlement, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.settlements[symbol]
+	if !ok {
+		return Settlement{}, false
+	}
+	return *s, true
+}
+
+// Settlements lists every recorded settlement, sorted by symbol.
+func (m *MatchingEngine) Settlements() []Settlement {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Settlement, 0, len(m.settlements))
+	for _, s := range m.settlements {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// SetSettlementPrice records an operator's final settlement price for an
+// expired derivative, overriding or completing the computed one. For
+// options price is the settlement value itself, not the underlying price.
+func (m *MatchingEngine) SetSettlementPrice(symbol string, price int64) (Settlement, error) {
+	if price < 0 {
+		return Settlement{}, ErrInvalidOrderData
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.settlements[symbol]
+	if !ok {
+		return Settlement{}, ErrNotExpired
+	}
+	s.Price, s.UnderlyingPrice, s.Source = price, 0, SettlementAdmin
+	s.Timestamp = time.Now().UnixMilli()
+	return *s, nil
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,8 +10,15 @@ import (
 	"github.com/google/uuid"
 
 	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/margin"
+	"order-matching-engine/internal/matching"
 	"order-matching-engine/internal/metrics"
 	"order-matching-engine/internal/orderbook"
+	"order-matching-engine/internal/positions"
+	"order-matching-engine/internal/risk"
 )
 
 var (
@@ -18,6 +26,8 @@ var (
 	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
 	ErrOrderNotFound         = errors.New("order not found")
 	ErrOrderAlreadyFinalized = errors.New("cannot cancel: order already filled or cancelled")
+	ErrAccountRequired       = errors.New("account required")
+	ErrInsufficientFunds     = ledger.ErrInsufficientFunds
 )
 
 type MatchingEngine struct {
@@ -25,18 +35,94 @@ type MatchingEngine struct {
 	books  map[string]*orderbook.OrderBook // per-symbol books
 	orders map[string]*common.Order        // global order lookup
 
+	// accountOrders indexes resting orders by account for per-account limits.
+	accountOrders map[string]map[string]*common.Order // account -> order ID -> order
+
+	// reservations tracks funds still locked for each live order.
+	reservations map[string]*reservation // order ID -> reservation
+	// settlementFailures holds trades the ledger refused to settle.
+	settlementFailures []SettlementFailure
+
 	tradesMu sync.Mutex
 	trades   []*common.Trade
 
+	statusLog map[string][]*StatusChange // symbol -> audit trail
+	listeners []func(Event)
+
+	// volatilityTimers end running volatility auctions.
+	volatilityTimers map[string]*time.Timer // symbol -> timer
+
+	// algorithms overrides the instrument's matching algorithm per symbol;
+	// built caches the algorithms built from instrument configurations.
+	algorithms map[string]matching.Algorithm
+	built      map[string]builtAlgorithm
+
+	sessions map[string]*session // session ID -> trading session
+
+	deadMen        map[string]*deadMan // account -> armed dead-man's switch
+	killedAccounts map[string]bool     // accounts blocked by a kill switch
+	globalKill     bool                // blocks order entry everywhere
+
+	stops          map[string][]*common.Order // symbol -> untriggered stops, arrival order
+	pegs           map[string][]*common.Order // symbol -> resting pegged orders, arrival order
+	dark           map[string]*darkBook       // symbol -> midpoint crossing book
+	groups         map[string]*OrderGroup     // group ID -> OCO or bracket group
+	dirtyGroups    []string                   // groups to reconcile after fills and cancels
+	contingentBusy bool                       // processContingentLocked is running
+
+	rfqs    map[string]*RFQ // RFQ ID -> open request for quote
+	dealers map[string]bool // accounts allowed to answer RFQs
+
+	expiryTimers map[string]*time.Timer // symbol -> pending derivative expiry
+	settlements  map[string]*Settlement // symbol -> final settlement once expired
+
+	marks        map[string]int64 // symbol -> mark price
+	liquidations []*Liquidation
+
 	Metrics *metrics.Metrics
+	Risk    *risk.Manager  // pre-trade checks; no limits are enforced by default
+	Ledger  *ledger.Ledger // account balances; nil disables funds checks
+	Fees    *fees.Manager  // maker/taker fee schedule; zero fees by default
+
+	// Positions tracks every account's net position and P&L per symbol.
+	Positions *positions.Service
+
+	// Margin holds collateral for margined instruments and the insurance
+	// fund. When nil, margined instruments trade without margin checks.
+	Margin *margin.Manager
+
+	// Instruments restricts trading to registered symbols and enforces their
+	// tick and lot sizes. When nil any symbol is accepted.
+	Instruments *instruments.Registry
 }
 
 func NewMatchingEngine() *MatchingEngine {
 	return &MatchingEngine{
-		books:   make(map[string]*orderbook.OrderBook),
-		orders:  make(map[string]*common.Order),
-		trades:  make([]*common.Trade, 0, 1024),
-		Metrics: metrics.NewMetrics(),
+		books:            make(map[string]*orderbook.OrderBook),
+		orders:           make(map[string]*common.Order),
+		accountOrders:    make(map[string]map[string]*common.Order),
+		reservations:     make(map[string]*reservation),
+		statusLog:        make(map[string][]*StatusChange),
+		volatilityTimers: make(map[string]*time.Timer),
+		algorithms:       make(map[string]matching.Algorithm),
+		built:            make(map[string]builtAlgorithm),
+		sessions:         make(map[string]*session),
+		deadMen:          make(map[string]*deadMan),
+		killedAccounts:   make(map[string]bool),
+		stops:            make(map[string][]*common.Order),
+		pegs:             make(map[string][]*common.Order),
+		dark:             make(map[string]*darkBook),
+		groups:           make(map[string]*OrderGroup),
+		rfqs:             make(map[string]*RFQ),
+		dealers:          make(map[string]bool),
+		expiryTimers:     make(map[string]*time.Timer),
+		settlements:      make(map[string]*Settlement),
+		marks:            make(map[string]int64),
+		trades:           make([]*common.Trade, 0, 1024),
+		Metrics:          metrics.NewMetrics(),
+		Risk:             risk.NewManager(risk.Limits{}),
+		Fees:             fees.NewManager(fees.Schedule{}),
+		Positions:        positions.NewService(),
 	}
 }
 
@@ -46,13 +132,15 @@ func (m *MatchingEngine) ensureBook(symbol string) *orderbook.OrderBook {
 		return book
 	}
 	book := orderbook.NewOrderBook(symbol)
+	book.Status = m.initialStatus(symbol)
 	m.books[symbol] = book
 	return book
 }
 
 func (m *MatchingEngine) createOrder(req *common.Order) *common.Order {
-	return &common.Order{
+	o := &common.Order{
 		ID:        uuid.NewString(),
+		Account:   req.Account,
 		Symbol:    req.Symbol,
 		Side:      req.Side,
 		Type:      req.Type,
@@ -61,10 +149,38 @@ func (m *MatchingEngine) createOrder(req *common.Order) *common.Order {
 		FilledQty: 0,
 		Status:    common.OrderStatusAccepted,
 		Timestamp: time.Now().UnixMilli(),
+
+		MarketMode:      req.MarketMode,
+		ProtectionPrice: req.ProtectionPrice,
+
+		SessionID:          req.SessionID,
+		CancelOnDisconnect: req.CancelOnDisconnect,
+		QuoteID:            req.QuoteID,
+
+		StopPrice:   req.StopPrice,
+		TrailOffset: req.TrailOffset,
+		TrailBps:    req.TrailBps,
+		LimitOffset: req.LimitOffset,
+
+		PegReference: req.PegReference,
+		PegOffset:    req.PegOffset,
+
+		Hidden: req.Hidden,
+		Dark:   req.Dark,
+
+		MinQty:    req.MinQty,
+		AllOrNone: req.AllOrNone,
+	}
+	if o.Type == common.OrderTypePeg {
+		o.PegLimit = req.Price
+	}
+	if o.Type.IsStop() {
+		o.Status = common.OrderStatusPending
 	}
+	return o
 }
 
-func validateOrderRequest(req *common.Order) error {
+func validateOrderRequest(req *common.Order, reg *instruments.Registry) error {
 	if req == nil {
 		return ErrInvalidOrderData
 	}
@@ -77,13 +193,75 @@ func validateOrderRequest(req *common.Order) error {
 	if req.Side != common.SideBuy && req.Side != common.SideSell {
 		return ErrInvalidOrderData
 	}
-	if req.Type != common.OrderTypeLimit && req.Type != common.OrderTypeMarket {
+	switch req.Type {
+	case common.OrderTypeLimit, common.OrderTypeMarket, common.OrderTypeStop, common.OrderTypeStopLimit,
+		common.OrderTypeTrailingStop, common.OrderTypePeg:
+	default:
 		return ErrInvalidOrderData
 	}
-	if req.Type == common.OrderTypeLimit && req.Price <= 0 {
+	var inst instruments.Instrument
+	known := false
+	if reg != nil {
+		inst, known = reg.Get(req.Symbol)
+	}
+	limitPriced := req.Type == common.OrderTypeLimit || req.Type == common.OrderTypeStopLimit ||
+		req.Type == common.OrderTypePeg
+	// Strategy prices are differences of leg prices and may be zero or negative.
+	if limitPriced && req.Price <= 0 && !inst.IsStrategy() {
 		return ErrInvalidOrderData
 	}
-	return nil
+	if (req.Type == common.OrderTypeStop || req.Type == common.OrderTypeTrailingStop) && req.Price != 0 {
+		return ErrInvalidOrderData
+	}
+	if !req.MarketMode.Valid() || req.ProtectionPrice < 0 {
+		return ErrInvalidOrderData
+	}
+	if req.Type != common.OrderTypeMarket && req.MarketMode != "" {
+		return ErrInvalidOrderData
+	}
+	if limitPriced && req.ProtectionPrice != 0 {
+		return ErrInvalidOrderData
+	}
+	if req.Type == common.OrderTypeTrailingStop {
+		if err := validateTrail(req); err != nil {
+			return err
+		}
+	} else if req.Type.IsStop() != (req.StopPrice > 0) || req.StopPrice < 0 ||
+		req.TrailOffset != 0 || req.TrailBps != 0 || req.LimitOffset != 0 {
+		return ErrInvalidOrderData
+	}
+	if (req.Hidden || req.Dark) && (req.Type != common.OrderTypeLimit || req.Hidden == req.Dark) {
+		return ErrInvalidOrderData
+	}
+	if req.MinQty < 0 || req.MinQty > req.Quantity {
+		return ErrInvalidOrderData
+	}
+	if (req.MinQty > 0 || req.AllOrNone) && (req.Type != common.OrderTypeLimit || req.Dark) {
+		return ErrInvalidOrderData
+	}
+	// A peg's request price is its limit; the engine sets PegLimit.
+	if (req.Type == common.OrderTypePeg) != req.PegReference.Valid() ||
+		(req.Type != common.OrderTypePeg && req.PegReference != "") ||
+		(req.Type != common.OrderTypePeg && req.PegOffset != 0) || req.PegLimit != 0 {
+		return ErrInvalidOrderData
+	}
+	if req.CancelOnDisconnect && req.SessionID == "" {
+		return ErrInvalidOrderData
+	}
+	if reg == nil {
+		return nil
+	}
+	if !known {
+		return instruments.ErrUnknownSymbol
+	}
+	if inst.Expired(time.Now()) {
+		return instruments.ErrExpired
+	}
+	if inst.IsStrategy() && (req.Type != common.OrderTypeLimit || req.Hidden || req.Dark ||
+		req.MinQty > 0 || req.AllOrNone) {
+		return ErrInvalidOrderData
+	}
+	return inst.CheckOrder(req)
 }
 
 // PlaceOrder is the main entry point for new incoming orders.
@@ -92,7 +270,7 @@ func (m *MatchingEngine) PlaceOrder(req *common.Order) (*common.Order, []*common
 	start := time.Now()
 	atomic.AddUint64(&m.Metrics.OrdersReceived, 1)
 
-	if err := validateOrderRequest(req); err != nil {
+	if err := validateOrderRequest(req, m.Instruments); err != nil {
 		return nil, nil, err
 	}
 
@@ -102,242 +280,250 @@ func (m *MatchingEngine) PlaceOrder(req *common.Order) (*common.Order, []*common
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	trades, err := m.placeLocked(incoming)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	durationMs := float64(time.Since(start).Microseconds()) / 1000.0
+	m.Metrics.RecordLatency(durationMs)
+
+	return incoming, trades, nil
+}
+
+// placeLocked runs a validated order through pre-trade checks, matching and
+// book insertion. The caller holds m.mu.
+func (m *MatchingEngine) placeLocked(incoming *common.Order) ([]*common.Trade, error) {
+	if m.killedLocked(incoming.Account) && !incoming.Liquidation {
+		return nil, ErrKillSwitchActive
+	}
+	if incoming.SessionID != "" && !m.sessionActiveLocked(incoming.SessionID, incoming.Account) {
+		return nil, ErrUnknownSession
+	}
+
 	book := m.ensureBook(incoming.Symbol)
 
+	if !book.Status.AcceptsOrders() {
+		return nil, ErrSymbolNotOpen
+	}
+	inCall := book.Status.InCall()
+	if inCall && incoming.Type == common.OrderTypeMarket {
+		return nil, ErrMarketOrderInCall
+	}
+	if incoming.Type == common.OrderTypePeg {
+		if err := m.pricePegLocked(book, incoming); err != nil {
+			return nil, err
+		}
+	}
+
+	if !incoming.Liquidation {
+		if err := m.Risk.Check(incoming, m.marketState(book, incoming.Account)); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.checkMarginLocked(incoming); err != nil {
+		return nil, err
+	}
+
+	// Stops wait off-book and reach matching only once triggered.
+	if incoming.Type.IsStop() {
+		m.parkStopLocked(book, incoming)
+		m.processContingentLocked(book)
+		return nil, nil
+	}
+
+	isMarket := incoming.Type == common.OrderTypeMarket
+	if isMarket {
+		if err := m.prepareMarketOrder(book, incoming); err != nil {
+			return nil, err
+		}
+	}
+
+	strategy, isStrategy := m.strategyFor(incoming.Symbol)
+	if err := m.reserveFunds(book, incoming); err != nil {
+		return nil, err
+	}
+
+	// During an auction call orders only accumulate; they execute at uncross.
 	var trades []*common.Trade
-	switch incoming.Type {
-	case common.OrderTypeLimit:
-		trades = m.executeLimitOrder(book, incoming)
-	case common.OrderTypeMarket:
-		// Market orders must either fully execute or be rejected.
-		if !m.hasSufficientLiquidityForMarket(book, incoming) {
-			return nil, nil, ErrInsufficientLiquidity
+	breached := false
+	band := m.priceBandLocked(book)
+	switch {
+	case inCall:
+	case incoming.Dark:
+		trades = m.executeDarkLocked(book, incoming)
+	case isStrategy:
+		trades, breached = m.executeSpreadLocked(book, strategy, incoming, band)
+	case incoming.Type == common.OrderTypeLimit, incoming.Type == common.OrderTypePeg:
+		trade, err := m.checkConditionsLocked(book, incoming, band)
+		if err != nil {
+			m.releaseFunds(incoming)
+			return nil, err
+		}
+		if trade {
+			trades, breached = m.executeLimitOrder(book, incoming, band)
 		}
-		trades = m.executeMarketOrder(book, incoming)
+	case incoming.Type == common.OrderTypeMarket:
+		trades, breached = m.executeMarketOrder(book, incoming, band)
 	default:
-		return nil, nil, ErrInvalidOrderData
+		return nil, ErrInvalidOrderData
+	}
+
+	// Matching stopped at a price band. The remainder is cancelled, unless
+	// the breach halted the symbol or started an auction and the order is a
+	// limit order, which then rests for the uncross.
+	cancelRest := false
+	if breached {
+		left := m.breachLocked(book, incoming, band)
+		cancelRest = !left || incoming.Type == common.OrderTypeMarket
+	}
+	if cancelRest && incoming.FilledQty == 0 {
+		m.releaseFunds(incoming)
+		return nil, ErrPriceBandBreached
+	}
+	// Market order remainders never rest.
+	if incoming.Type == common.OrderTypeMarket && incoming.FilledQty < incoming.Quantity {
+		cancelRest = true
 	}
 
 	// Determine final status and decide whether to keep the order in the book.
 	remaining := incoming.Quantity - incoming.FilledQty
 
-	switch incoming.Type {
-	case common.OrderTypeLimit:
-		if remaining > 0 {
-			// Partially or not filled: add remaining to the book.
-			if incoming.FilledQty > 0 {
-				incoming.Status = common.OrderStatusPartial
-			} else {
-				incoming.Status = common.OrderStatusAccepted
-			}
-			if incoming.Side == common.SideBuy {
-				book.Bids.AddOrder(incoming)
-			} else {
-				book.Asks.AddOrder(incoming)
-			}
+	switch {
+	case remaining == 0:
+		incoming.Status = common.OrderStatusFilled
+	case cancelRest:
+		incoming.Status = common.OrderStatusCancelled
+		m.releaseFunds(incoming)
+	case incoming.Dark:
+		if incoming.FilledQty > 0 {
+			incoming.Status = common.OrderStatusPartial
+		}
+		db := m.darkBookLocked(book.Symbol)
+		if incoming.Side == common.SideBuy {
+			db.bids = append(db.bids, incoming)
 		} else {
-			incoming.Status = common.OrderStatusFilled
+			db.asks = append(db.asks, incoming)
 		}
-	case common.OrderTypeMarket:
-		// By construction, market orders are either fully filled or rejected earlier.
-		if remaining == 0 {
-			incoming.Status = common.OrderStatusFilled
+		m.trackOpen(incoming)
+	case incoming.Type == common.OrderTypeLimit, incoming.Type == common.OrderTypePeg:
+		// Partially or not filled: add remaining to the book.
+		if incoming.FilledQty > 0 {
+			incoming.Status = common.OrderStatusPartial
+		} else {
+			incoming.Status = common.OrderStatusAccepted
+		}
+		if incoming.Side == common.SideBuy {
+			book.Bids.AddOrder(incoming)
+		} else {
+			book.Asks.AddOrder(incoming)
+		}
+		m.trackOpen(incoming)
+		if incoming.Type == common.OrderTypePeg {
+			m.pegs[book.Symbol] = append(m.pegs[book.Symbol], incoming)
 		}
 	}
 
+	if isMarket {
+		incoming.MarketOutcome = marketOutcome(book, incoming, breached)
+	}
+
 	// Store final order state in lookup map.
 	m.orders[incoming.ID] = incoming
 
+	if book.Status.InCall() {
+		m.emitAuctionLocked(book)
+	}
+
 	if len(trades) > 0 {
 		atomic.AddUint64(&m.Metrics.OrdersMatched, 1)
 		atomic.AddUint64(&m.Metrics.TradesExecuted, uint64(len(trades)))
 	}
 
-	durationMs := float64(time.Since(start).Microseconds()) / 1000.0
-	m.Metrics.RecordLatency(durationMs)
+	// Trades may have triggered stops or moved order groups along.
+	m.touchGroupLocked(incoming)
+	m.processContingentLocked(book)
 
-	return incoming, trades, nil
+	return trades, nil
 }
 
-// hasSufficientLiquidityForMarket checks if the opposite side has enough quantity
-// to fully fill the incoming market order.
-func (m *MatchingEngine) hasSufficientLiquidityForMarket(book *orderbook.OrderBook, o *common.Order) bool {
-	var opposite *orderbook.SideBook
-	if o.Side == common.SideBuy {
-		opposite = book.Asks
-	} else {
-		opposite = book.Bids
-	}
-	return opposite.TotalQuantity >= o.Quantity
+// executeLimitOrder walks the opposite book side while prices cross and fills as much
+// as possible, respecting price priority and partial fills. It reports
+// whether matching stopped at the price band.
+func (m *MatchingEngine) executeLimitOrder(book *orderbook.OrderBook, o *common.Order, band priceBand) ([]*common.Trade, bool) {
+	return m.execute(book, o, band, func(price int64) bool { return limitCrosses(o, price) })
 }
 
-// executeLimitOrder walks the opposite book side while prices cross and fills as much
-// as possible, respecting price-time priority and partial fills.
-func (m *MatchingEngine) executeLimitOrder(book *orderbook.OrderBook, o *common.Order) []*common.Trade {
+// executeMarketOrder walks the opposite book side up to the order's
+// protection price. It reports whether matching stopped at the price band.
+func (m *MatchingEngine) executeMarketOrder(book *orderbook.OrderBook, o *common.Order, band priceBand) ([]*common.Trade, bool) {
+	return m.execute(book, o, band, func(price int64) bool { return withinProtection(o, price) })
+}
+
+// execute fills o level by level, best price first, while crosses accepts
+// the level price. Within a level the symbol's matching algorithm decides
+// which resting orders trade, displayed orders ahead of hidden ones. The caller holds m.mu.
+func (m *MatchingEngine) execute(book *orderbook.OrderBook, o *common.Order, band priceBand, crosses func(int64) bool) ([]*common.Trade, bool) {
 	var opposite *orderbook.SideBook
 	if o.Side == common.SideBuy {
 		opposite = book.Asks
 	} else {
 		opposite = book.Bids
 	}
+	alg := m.algorithmLocked(book.Symbol)
 
 	trades := make([]*common.Trade, 0, 4)
-	remaining := o.Quantity - o.FilledQty
-
-	for remaining > 0 {
-		level, ok := opposite.BestLevel()
-		if !ok {
-			break // no liquidity
-		}
-
-		// Check price crossing condition.
-		if o.Side == common.SideBuy && level.Price > o.Price {
+	for i := 0; o.FilledQty < o.Quantity && i < len(opposite.Prices); {
+		level := opposite.Levels[opposite.Prices[i]]
+		if !crosses(level.Price) {
 			break
 		}
-		if o.Side == common.SideSell && level.Price < o.Price {
-			break
+		if !band.allows(level.Price) {
+			return trades, true
 		}
 
-		// Consume orders at this price level in FIFO order.
-		for remaining > 0 && !level.IsEmpty() {
-			existing := level.Orders[0]
-			existingRemaining := existing.Quantity - existing.FilledQty
-			if existingRemaining <= 0 {
-				_, _ = level.Dequeue()
-				continue
-			}
-
-			qty := remaining
-			if existingRemaining < qty {
-				qty = existingRemaining
-			}
-
-			remaining -= qty
-			o.FilledQty += qty
-			existing.FilledQty += qty
-
-			if existing.FilledQty == existing.Quantity {
-				existing.Status = common.OrderStatusFilled
-			} else {
-				existing.Status = common.OrderStatusPartial
-			}
+		fills := allocate(alg, level, o.Quantity-o.FilledQty)
+		for _, f := range fills {
+			existing := f.Order
+			o.FilledQty += f.Qty
+			fill(existing, f.Qty)
 
 			// Update aggregate liquidity.
-			opposite.TotalQuantity -= qty
-
-			// Record trade.
-			buyID := o.ID
-			sellID := existing.ID
-			if o.Side == common.SideSell {
-				buyID = existing.ID
-				sellID = o.ID
-			}
-
-			trade := &common.Trade{
-				TradeID:   uuid.NewString(),
-				BuyOrder:  buyID,
-				SellOrder: sellID,
-				Price:     level.Price,
-				Quantity:  qty,
-				Timestamp: time.Now().UnixMilli(),
-			}
-			trades = append(trades, trade)
-			m.addTrade(trade)
+			opposite.TotalQuantity -= f.Qty
 
-			if existing.FilledQty == existing.Quantity {
-				_, _ = level.Dequeue()
-			}
+			trades = append(trades, m.recordTrade(book, o, existing, level.Price, f.Qty))
 		}
-
-		if level.IsEmpty() {
-			opposite.RemovePrice(level.Price)
-		}
-
-		if remaining == 0 {
-			break
+		// A level that keeps orders, such as all-or-none orders too large
+		// to fill, is passed over for the next price.
+		if !m.removeFilled(opposite, level) {
+			i++
 		}
 	}
 
-	return trades
+	return trades, false
 }
 
-// executeMarketOrder walks the opposite book side regardless of price,
-// assuming sufficient liquidity has already been validated.
-func (m *MatchingEngine) executeMarketOrder(book *orderbook.OrderBook, o *common.Order) []*common.Trade {
-	var opposite *orderbook.SideBook
-	if o.Side == common.SideBuy {
-		opposite = book.Asks
-	} else {
-		opposite = book.Bids
-	}
-
-	trades := make([]*common.Trade, 0, 4)
-	remaining := o.Quantity - o.FilledQty
-
-	for remaining > 0 {
-		level, ok := opposite.BestLevel()
-		if !ok {
-			break // should not happen if liquidity was checked
-		}
-
-		for remaining > 0 && !level.IsEmpty() {
-			existing := level.Orders[0]
-			existingRemaining := existing.Quantity - existing.FilledQty
-			if existingRemaining <= 0 {
-				_, _ = level.Dequeue()
-				continue
-			}
-
-			qty := remaining
-			if existingRemaining < qty {
-				qty = existingRemaining
-			}
-
-			remaining -= qty
-			o.FilledQty += qty
-			existing.FilledQty += qty
-
-			if existing.FilledQty == existing.Quantity {
-				existing.Status = common.OrderStatusFilled
-			} else {
-				existing.Status = common.OrderStatusPartial
-			}
-
-			opposite.TotalQuantity -= qty
-
-			buyID := o.ID
-			sellID := existing.ID
-			if o.Side == common.SideSell {
-				buyID = existing.ID
-				sellID = o.ID
-			}
-
-			trade := &common.Trade{
-				TradeID:   uuid.NewString(),
-				BuyOrder:  buyID,
-				SellOrder: sellID,
-				Price:     level.Price,
-				Quantity:  qty,
-				Timestamp: time.Now().UnixMilli(),
-			}
-			trades = append(trades, trade)
-			m.addTrade(trade)
-
-			if existing.FilledQty == existing.Quantity {
-				_, _ = level.Dequeue()
-			}
-		}
-
-		if level.IsEmpty() {
-			opposite.RemovePrice(level.Price)
-		}
-
-		if remaining == 0 {
-			break
+// removeFilled drops fully filled orders from a level, keeping the queue
+// order of the rest, and removes the level once empty. It reports whether
+// the level was removed. The caller holds m.mu.
+func (m *MatchingEngine) removeFilled(side *orderbook.SideBook, level *orderbook.PriceLevel) bool {
+	kept := level.Orders[:0]
+	for _, o := range level.Orders {
+		if o.FilledQty < o.Quantity {
+			kept = append(kept, o)
+			continue
 		}
+		m.untrackOpen(o)
 	}
-
-	return trades
+	for i := len(kept); i < len(level.Orders); i++ {
+		level.Orders[i] = nil
+	}
+	level.Orders = kept
+	if level.IsEmpty() {
+		side.RemovePrice(level.Price)
+		return true
+	}
+	return false
 }
 
 // CancelOrder removes any remaining quantity of an order from the book and
@@ -362,23 +548,67 @@ func (m *MatchingEngine) CancelOrder(orderID string) error {
 	if !ok {
 		return ErrOrderNotFound
 	}
+	if !book.Status.AcceptsCancels() {
+		return ErrCancelNotAllowed
+	}
 
-	var sideBook *orderbook.SideBook
-	if o.Side == common.SideBuy {
-		sideBook = book.Bids
-	} else {
-		sideBook = book.Asks
+	if err := m.cancelLocked(book, o, CancelReasonUser); err != nil {
+		return err
+	}
+	if book.Status.InCall() {
+		m.emitAuctionLocked(book)
 	}
+	m.processContingentLocked(book)
+	return nil
+}
 
-	level, ok := sideBook.Levels[o.Price]
+// cancelLocked removes a resting or pending order, releases its funds and
+// publishes the cancellation. The caller holds m.mu and has checked that
+// the book accepts cancels.
+func (m *MatchingEngine) cancelLocked(book *orderbook.OrderBook, o *common.Order, reason string) error {
+	if o.Status == common.OrderStatusPending {
+		m.unparkStopLocked(o)
+		o.Status = common.OrderStatusCancelled
+		m.untrackOpen(o)
+		m.touchGroupLocked(o)
+		m.emitCancelLocked(o, o.Quantity-o.FilledQty, reason)
+		return nil
+	}
+
+	remaining, ok := m.unlinkLocked(book, o)
 	if !ok {
 		// It might already be fully matched but status not updated; treat as finalized.
 		return ErrOrderAlreadyFinalized
 	}
 
+	o.Status = common.OrderStatusCancelled
+	m.untrackOpen(o)
+	m.releaseFunds(o)
+	m.touchGroupLocked(o)
+
+	m.emitCancelLocked(o, remaining, reason)
+	return nil
+}
+
+// unlinkLocked takes a resting order out of its price level and returns
+// its remaining quantity. It reports false if the order is not resting.
+// The caller holds m.mu.
+func (m *MatchingEngine) unlinkLocked(book *orderbook.OrderBook, o *common.Order) (int64, bool) {
+	if o.Dark {
+		return m.unlinkDarkLocked(o)
+	}
+	sideBook := book.Asks
+	if o.Side == common.SideBuy {
+		sideBook = book.Bids
+	}
+
+	level, ok := sideBook.Levels[o.Price]
+	if !ok {
+		return 0, false
+	}
 	remaining := o.Quantity - o.FilledQty
 	if remaining <= 0 {
-		return ErrOrderAlreadyFinalized
+		return 0, false
 	}
 
 	// Remove the order from the level's FIFO queue.
@@ -390,18 +620,15 @@ func (m *MatchingEngine) CancelOrder(orderID string) error {
 		}
 	}
 	if idx == -1 {
-		return ErrOrderAlreadyFinalized
+		return 0, false
 	}
 
 	level.Orders = append(level.Orders[:idx], level.Orders[idx+1:]...)
 	sideBook.TotalQuantity -= remaining
-
 	if level.IsEmpty() {
 		sideBook.RemovePrice(level.Price)
 	}
-
-	o.Status = common.OrderStatusCancelled
-	return nil
+	return remaining, true
 }
 
 func (m *MatchingEngine) GetOrder(orderID string) (*common.Order, bool) {
@@ -418,6 +645,137 @@ func (m *MatchingEngine) GetOrderBook(symbol string) (*orderbook.OrderBook, bool
 	return b, ok
 }
 
+// TopOfBook returns the best displayed bid and ask of a symbol. ok is false
+// unless both sides are quoted.
+func (m *MatchingEngine) TopOfBook(symbol string) (bid, ask int64, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, exists := m.books[symbol]
+	if !exists {
+		return 0, 0, false
+	}
+	bid, okBid := book.Bids.BestVisiblePrice()
+	ask, okAsk := book.Asks.BestVisiblePrice()
+	return bid, ask, okBid && okAsk
+}
+
+// Symbols lists the symbols that have a book, sorted.
+func (m *MatchingEngine) Symbols() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]string, 0, len(m.books))
+	for symbol := range m.books {
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// recordTrade builds the trade between an incoming and a resting order.
+// The incoming order is the aggressor. The caller holds m.mu.
+func (m *MatchingEngine) recordTrade(book *orderbook.OrderBook, incoming, resting *common.Order, price, qty int64) *common.Trade {
+	buy, sell := incoming, resting
+	if incoming.Side == common.SideSell {
+		buy, sell = resting, incoming
+	}
+	return m.recordMatch(book, buy, sell, incoming.Side, price, qty)
+}
+
+// recordMatch stores a trade between a buy and a sell order, settles it and
+// updates per-book and per-account state. aggressor is empty for auction
+// trades, which have no taker. The caller holds m.mu.
+func (m *MatchingEngine) recordMatch(book *orderbook.OrderBook, buy, sell *common.Order, aggressor common.Side, price, qty int64) *common.Trade {
+	trade := newTrade(common.TradeTypeBook, buy, sell, aggressor, price, qty)
+
+	book.LastPrice = price
+	if book.ReferencePrice == 0 {
+		book.ReferencePrice = price
+	}
+	m.trailLocked(book, price)
+
+	m.bookTradeLocked(book.Symbol, trade, buy, sell)
+	return trade
+}
+
+func newTrade(typ common.TradeType, buy, sell *common.Order, aggressor common.Side, price, qty int64) *common.Trade {
+	return &common.Trade{
+		TradeID:     uuid.NewString(),
+		Type:        typ,
+		BuyOrder:    buy.ID,
+		SellOrder:   sell.ID,
+		BuyAccount:  buy.Account,
+		SellAccount: sell.Account,
+		Price:       price,
+		Quantity:    qty,
+		TakerSide:   aggressor,
+		Timestamp:   time.Now().UnixMilli(),
+	}
+}
+
+// bookTradeLocked charges fees on a trade, stores and settles it, updates
+// risk state and publishes it. It leaves the symbol's book untouched, so
+// off-book trades use it directly. The caller holds m.mu.
+func (m *MatchingEngine) bookTradeLocked(symbol string, trade *common.Trade, buy, sell *common.Order) {
+	m.chargeFees(symbol, trade, buy, sell)
+	m.addTrade(trade)
+
+	notional := m.notional(symbol, trade.Price, trade.Quantity)
+	m.Risk.OnFillNotional(buy.Account, symbol, common.SideBuy, trade.Quantity, notional)
+	m.Risk.OnFillNotional(sell.Account, symbol, common.SideSell, trade.Quantity, notional)
+
+	m.settleTrade(symbol, trade, buy, sell)
+	for _, o := range [2]*common.Order{buy, sell} {
+		if o.FilledQty == o.Quantity {
+			m.releaseFunds(o)
+		}
+		m.touchGroupLocked(o)
+	}
+
+	m.emit(Event{Type: EventTrade, Symbol: symbol, Trade: trade})
+	updated := m.Positions.OnTrade(symbol, trade, m.multiplier(symbol))
+	for i := range updated {
+		m.emit(Event{Type: EventPosition, Symbol: symbol, Position: &updated[i]})
+	}
+}
+
+// marketState snapshots the book for pre-trade risk checks. The caller holds m.mu.
+func (m *MatchingEngine) marketState(book *orderbook.OrderBook, account string) risk.MarketState {
+	st := risk.MarketState{LastPrice: book.LastPrice, Multiplier: m.multiplier(book.Symbol)}
+	if p, ok := book.Bids.BestPrice(); ok {
+		st.BestBid = p
+	}
+	if p, ok := book.Asks.BestPrice(); ok {
+		st.BestAsk = p
+	}
+	if account != "" {
+		st.OpenOrders = len(m.accountOrders[account])
+	}
+	return st
+}
+
+// trackOpen and untrackOpen maintain the per-account index of resting
+// orders. The caller holds m.mu.
+func (m *MatchingEngine) trackOpen(o *common.Order) {
+	if o.Account == "" {
+		return
+	}
+	open := m.accountOrders[o.Account]
+	if open == nil {
+		open = make(map[string]*common.Order)
+		m.accountOrders[o.Account] = open
+	}
+	open[o.ID] = o
+}
+
+func (m *MatchingEngine) untrackOpen(o *common.Order) {
+	if open, ok := m.accountOrders[o.Account]; ok {
+		delete(open, o.ID)
+		if len(open) == 0 {
+			delete(m.accountOrders, o.Account)
+		}
+	}
+}
+
 func (m *MatchingEngine) addTrade(t *common.Trade) {
 	m.tradesMu.Lock()
 	m.trades = append(m.trades, t)
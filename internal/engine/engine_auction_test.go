@@ -0,0 +1,147 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+// openingCall moves a fresh symbol into the opening call period.
+func openingCall(t *testing.T, eng *engine.MatchingEngine, symbol string) {
+	t.Helper()
+	if _, err := eng.SetTradingStatus(symbol, common.TradingStatusClosed, "end of day"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, err := eng.SetTradingStatus(symbol, common.TradingStatusPreOpen, "opening call"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+}
+
+func TestOpeningAuctionUncross(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	openingCall(t, eng, "AAPL")
+
+	var indicative []*engine.AuctionInfo
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventAuction {
+			indicative = append(indicative, ev.Auction)
+		}
+	})
+
+	b1, trades, _ := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10100, 100))
+	b2, _, _ := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 200))
+	a1, _, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 9900, 150))
+	a2, _, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 10000, 100))
+	eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 10200, 100))
+
+	if len(trades) != 0 || b1.Status != common.OrderStatusAccepted {
+		t.Fatalf("orders must not match during the call")
+	}
+	if _, _, err := eng.PlaceOrder(&common.Order{Symbol: "AAPL", Side: common.SideBuy, Type: common.OrderTypeMarket, Quantity: 1}); err != engine.ErrMarketOrderInCall {
+		t.Fatalf("expected market order reject, got %v", err)
+	}
+
+	info, _ := eng.IndicativeAuction("AAPL")
+	if info.Price != 10000 || info.Volume != 250 || info.Surplus != 50 {
+		t.Fatalf("unexpected indicative: %+v", info)
+	}
+	if len(indicative) != 5 || indicative[4].Volume != 250 {
+		t.Fatalf("expected an indicative update per order, got %d", len(indicative))
+	}
+
+	res, err := eng.Uncross("AAPL", "open")
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if res.Price != 10000 || res.Volume != 250 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, tr := range res.Trades {
+		if tr.Price != 10000 || tr.TakerSide != "" {
+			t.Fatalf("auction trades must print at the clearing price without aggressor: %+v", tr)
+		}
+	}
+
+	// Price-time allocation: the better-priced bid fills first.
+	if b1.Status != common.OrderStatusFilled || b2.FilledQty != 150 {
+		t.Fatalf("unexpected bid fills: b1 %v, b2 %d", b1.Status, b2.FilledQty)
+	}
+	if a1.Status != common.OrderStatusFilled || a2.Status != common.OrderStatusFilled {
+		t.Fatalf("crossing asks should be filled")
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusOpen {
+		t.Fatalf("expected OPEN after opening auction, got %s", s)
+	}
+
+	book, _ := eng.GetOrderBook("AAPL")
+	bid, _ := book.Bids.BestPrice()
+	ask, _ := book.Asks.BestPrice()
+	if bid >= ask || book.LastPrice != 10000 {
+		t.Fatalf("book still crossed: bid %d ask %d", bid, ask)
+	}
+}
+
+func TestAuctionTieBreakers(t *testing.T) {
+	cases := []struct {
+		name string
+		ref  int64 // 0 means no prior trade
+		bids [][2]int64
+		asks [][2]int64
+		want int64
+	}{
+		{"market pressure buy surplus picks highest", 0, [][2]int64{{10100, 300}}, [][2]int64{{9900, 100}, {10000, 100}}, 10100},
+		{"reference price nearest above", 10080, [][2]int64{{10100, 100}}, [][2]int64{{9900, 100}}, 10100},
+		{"reference price nearest below", 9950, [][2]int64{{10100, 100}}, [][2]int64{{9900, 100}}, 9900},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			eng := engine.NewMatchingEngine()
+			if tc.ref > 0 {
+				eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, tc.ref, 1))
+				eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, tc.ref, 1))
+			}
+			openingCall(t, eng, "AAPL")
+
+			for _, b := range tc.bids {
+				eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, b[0], b[1]))
+			}
+			for _, a := range tc.asks {
+				eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, a[0], a[1]))
+			}
+
+			res, err := eng.Uncross("AAPL", "open")
+			if err != nil {
+				t.Fatalf("unexpected: %v", err)
+			}
+			if res.Price != tc.want {
+				t.Fatalf("expected clearing price %d, got %d", tc.want, res.Price)
+			}
+		})
+	}
+}
+
+func TestClosingAuction(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 100))
+
+	if _, err := eng.SetTradingStatus("AAPL", common.TradingStatusPreClose, "closing call"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 9800, 60))
+
+	res, err := eng.Uncross("AAPL", "close")
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if res.Price != 9900 || res.Volume != 60 {
+		t.Fatalf("unexpected closing result: %+v", res)
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusClosed {
+		t.Fatalf("expected CLOSED after closing auction, got %s", s)
+	}
+	if _, err := eng.Uncross("AAPL", "again"); err != engine.ErrNotInCall {
+		t.Fatalf("expected not in call, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+)
+
+// bandedEngine returns an engine whose AAPL book last traded at 10000 with
+// a 5% dynamic band and asks at 10100, 10400 and 11000.
+func bandedEngine(t *testing.T, action instruments.BandAction) *engine.MatchingEngine {
+	t.Helper()
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{
+		Symbol:              "AAPL",
+		DynamicBandBps:      500,
+		BandAction:          action,
+		VolatilityAuctionMs: 20,
+	})
+
+	eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 10000, 10))
+	eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	for _, p := range []int64{10100, 10400, 11000} {
+		eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, p, 100))
+	}
+	return eng
+}
+
+func TestPriceBandCancelsRemainder(t *testing.T) {
+	eng := bandedEngine(t, instruments.BandActionReject)
+
+	// Fill-or-kill only counts liquidity inside the band, so it is rejected
+	// up front rather than partly filled.
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeMarket, 0, 300)); err != engine.ErrInsufficientLiquidity {
+		t.Fatalf("expected ErrInsufficientLiquidity, got %v", err)
+	}
+	if book, _ := eng.GetOrderBook("AAPL"); book.Asks.TotalQuantity != 300 {
+		t.Fatalf("expected nothing filled, asks left %d", book.Asks.TotalQuantity)
+	}
+
+	req := newReq("AAPL", common.SideBuy, common.OrderTypeMarket, 0, 300)
+	req.MarketMode = common.MarketModeFillAndKill
+	o, trades, err := eng.PlaceOrder(req)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if len(trades) != 2 || o.FilledQty != 200 || o.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected fills up to the band and the rest cancelled: %d trades, %+v", len(trades), o)
+	}
+
+	book, _ := eng.GetOrderBook("AAPL")
+	if p, _ := book.Asks.BestPrice(); p != 11000 {
+		t.Fatalf("liquidity outside the band must be untouched, best ask %d", p)
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusOpen {
+		t.Fatalf("reject action must not change status, got %s", s)
+	}
+
+	// Nothing executable inside the band: the order is rejected outright.
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 11000, 10)); err != engine.ErrPriceBandBreached {
+		t.Fatalf("expected band reject, got %v", err)
+	}
+}
+
+func TestPriceBandHalts(t *testing.T) {
+	eng := bandedEngine(t, instruments.BandActionHalt)
+
+	o, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 11000, 300))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if o.FilledQty != 200 || o.Status != common.OrderStatusPartial {
+		t.Fatalf("limit remainder should rest through the halt: %+v", o)
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusHalted {
+		t.Fatalf("expected HALTED, got %s", s)
+	}
+}
+
+func TestPriceBandVolatilityAuction(t *testing.T) {
+	eng := bandedEngine(t, instruments.BandActionAuction)
+
+	done := make(chan *engine.AuctionInfo, 4)
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventAuction && ev.Auction.Uncrossed {
+			done <- ev.Auction
+		}
+	})
+
+	o, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 11000, 250))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusVolatilityAuction {
+		t.Fatalf("expected VOLATILITY_AUCTION, got %s", s)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeMarket, 0, 10)); err != engine.ErrMarketOrderInCall {
+		t.Fatalf("expected market orders rejected in the auction, got %v", err)
+	}
+
+	select {
+	case info := <-done:
+		if info.Price != 11000 || info.Volume != 50 {
+			t.Fatalf("unexpected uncross: %+v", info)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("volatility auction did not end")
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusOpen {
+		t.Fatalf("expected OPEN after the auction, got %s", s)
+	}
+	if got, _ := eng.GetOrder(o.ID); got.Status != common.OrderStatusFilled {
+		t.Fatalf("expected resting order filled at uncross, got %s", got.Status)
+	}
+}
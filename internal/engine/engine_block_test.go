@@ -0,0 +1,69 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+)
+
+func TestBlockTradeValidatedAgainstBook(t *testing.T) {
+	eng := newFundedEngine("A", "B", "MM")
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "AAPL", MinBlockQty: 100, BlockBandBps: 500})
+
+	block := engine.BlockTrade{Symbol: "AAPL", BuyAccount: "A", SellAccount: "B", Price: 10000, Quantity: 500}
+	if _, err := eng.SubmitBlockTrade(block); err != engine.ErrNoReferencePrice {
+		t.Fatalf("expected ErrNoReferencePrice on an empty book, got %v", err)
+	}
+
+	// Book midpoint 10000, 5% band: 9500-10500.
+	eng.PlaceOrder(accountReq("MM", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(accountReq("MM", common.SideSell, common.OrderTypeLimit, 10100, 10))
+
+	for _, tc := range []struct {
+		price, qty int64
+		want       error
+	}{
+		{10600, 500, engine.ErrBlockPriceBand},
+		{9400, 500, engine.ErrBlockPriceBand},
+		{10000, 50, engine.ErrBlockTooSmall},
+	} {
+		block.Price, block.Quantity = tc.price, tc.qty
+		if _, err := eng.SubmitBlockTrade(block); err != tc.want {
+			t.Fatalf("%d x %d: expected %v, got %v", tc.qty, tc.price, tc.want, err)
+		}
+	}
+
+	var published []*common.Trade
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventTrade {
+			published = append(published, ev.Trade)
+		}
+	})
+
+	block.Price, block.Quantity = 10500, 500
+	trade, err := eng.SubmitBlockTrade(block)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if trade.Type != common.TradeTypeBlock || trade.TakerSide != "" || trade.BuyAccount != "A" || trade.SellAccount != "B" {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+	if len(published) != 1 || published[0] != trade {
+		t.Fatalf("block trade should be published")
+	}
+	if got := eng.Ledger.Balance("A", "AAPL").Available; got != 10_500 {
+		t.Fatalf("buyer should receive 500 AAPL, has %d", got)
+	}
+	book, _ := eng.GetOrderBook("AAPL")
+	if book.LastPrice != 0 || book.Bids.TotalQuantity != 10 || book.Asks.TotalQuantity != 10 {
+		t.Fatalf("block trades must not touch the book")
+	}
+
+	_, trades, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10100, 10))
+	if len(trades) != 1 || trades[0].Type != common.TradeTypeBook {
+		t.Fatalf("on-book prints should be BOOK trades: %+v", trades)
+	}
+}
@@ -0,0 +1,136 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestMassCancelFilters(t *testing.T) {
+	eng := newFundedEngine("A", "B")
+
+	a1, _, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	a2, _, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9500, 10))
+	a3, _, _ := eng.PlaceOrder(accountReq("A", common.SideSell, common.OrderTypeLimit, 10100, 10))
+	b1, _, _ := eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+
+	var events []*engine.Cancellation
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventCancel {
+			events = append(events, ev.Cancel)
+		}
+	})
+
+	ids, err := eng.MassCancel(engine.MassCancelFilter{Account: "A", Side: common.SideBuy, MinPrice: 9800})
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != a1.ID || a1.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected only a1 cancelled, got %v", ids)
+	}
+	if a2.Status != common.OrderStatusAccepted || b1.Status != common.OrderStatusAccepted {
+		t.Fatalf("orders outside the filter must survive")
+	}
+	if len(events) != 1 || events[0].OrderID != a1.ID || events[0].Reason != engine.CancelReasonMassCancel {
+		t.Fatalf("unexpected cancel events: %+v", events)
+	}
+
+	ids, _ = eng.MassCancel(engine.MassCancelFilter{Account: "A"})
+	if len(ids) != 2 || a3.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected remaining account orders cancelled, got %v", ids)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 0 {
+		t.Fatalf("funds not released: %+v", b)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected one event per order, got %d", len(events))
+	}
+
+	if _, err := eng.MassCancel(engine.MassCancelFilter{MinPrice: 100, MaxPrice: 50}); err != engine.ErrInvalidOrderData {
+		t.Fatalf("expected invalid range, got %v", err)
+	}
+}
+
+func TestMassCancelSkipsHaltedBooks(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(newReq("MSFT", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "frozen")
+
+	ids, _ := eng.MassCancel(engine.MassCancelFilter{})
+	if len(ids) != 1 {
+		t.Fatalf("expected only the open book cancelled, got %v", ids)
+	}
+	if eng.OrdersInBook() != 1 {
+		t.Fatalf("halted book must keep its orders")
+	}
+}
+
+func TestSessionGracePeriodAndResume(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+
+	id, err := eng.OpenSession("MM1", "")
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	req := newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 10)
+	req.Account, req.SessionID, req.CancelOnDisconnect = "MM1", id, true
+	o, _, err := eng.PlaceOrder(req)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	// Another account cannot use the session.
+	hijack := newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 10)
+	hijack.Account, hijack.SessionID = "OTHER", id
+	if _, _, err := eng.PlaceOrder(hijack); err != engine.ErrUnknownSession {
+		t.Fatalf("expected unknown session, got %v", err)
+	}
+
+	// Resuming within the grace period keeps the order.
+	eng.CloseSession(id, 30*time.Millisecond)
+	if _, err := eng.OpenSession("MM1", id); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if o.Status != common.OrderStatusAccepted {
+		t.Fatalf("resumed session must keep its orders, got %s", o.Status)
+	}
+
+	eng.CloseSession(id, 20*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for eng.OrdersInBook() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("order not cancelled after grace period")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := eng.OpenSession("MM1", id); err != engine.ErrUnknownSession {
+		t.Fatalf("ended session must not resume, got %v", err)
+	}
+}
+
+func TestCancelOnDisconnectOnHaltedBook(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	id, _ := eng.OpenSession("MM1", "")
+	req := newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 10)
+	req.Account, req.SessionID, req.CancelOnDisconnect = "MM1", id, true
+	o, _, err := eng.PlaceOrder(req)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "frozen")
+
+	eng.CloseSession(id, 0)
+	if o.Status != common.OrderStatusCancelled || eng.OrdersInBook() != 0 {
+		t.Fatalf("expected the halted order cancelled on disconnect, got %s", o.Status)
+	}
+
+	// It must not trade once the halt is lifted.
+	eng.SetTradingStatus("AAPL", common.TradingStatusOpen, "resume")
+	if _, trades, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 9900, 10)); len(trades) != 0 {
+		t.Fatalf("cancelled order traded: %+v", trades)
+	}
+}
@@ -0,0 +1,121 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/matching"
+)
+
+func aonReq(account string, side common.Side, price, qty int64) *common.Order {
+	req := accountReq(account, side, common.OrderTypeLimit, price, qty)
+	req.AllOrNone = true
+	return req
+}
+
+func TestRestingAllOrNoneSkippedWithoutBreakingFIFO(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	aon, _, _ := eng.PlaceOrder(aonReq("B", common.SideSell, 10000, 10))
+	c1, _, _ := eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10000, 5))
+	c2, _, _ := eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10000, 5))
+
+	eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 6))
+	if aon.FilledQty != 0 || c1.Status != common.OrderStatusFilled || c2.FilledQty != 1 {
+		t.Fatalf("expected AON skipped and FIFO among the rest: aon %d, c1 %s, c2 %d", aon.FilledQty, c1.Status, c2.FilledQty)
+	}
+
+	eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	if aon.Status != common.OrderStatusFilled || c2.FilledQty != 1 {
+		t.Fatalf("an order large enough must take the AON whole: aon %s, c2 %d", aon.Status, c2.FilledQty)
+	}
+}
+
+func TestAllOrNoneLevelPassedOverForNextPrice(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	aon, _, _ := eng.PlaceOrder(aonReq("B", common.SideSell, 10000, 10))
+	eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10100, 5))
+
+	_, trades, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10100, 5))
+	if len(trades) != 1 || trades[0].Price != 10100 || aon.FilledQty != 0 {
+		t.Fatalf("expected to trade through the AON level at 10100, got %+v", trades)
+	}
+}
+
+func TestAllOrNoneUnderProRata(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.SetMatchingAlgorithm("AAPL", matching.ProRata{})
+	plain, _, _ := eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 10))
+	aon, _, _ := eng.PlaceOrder(aonReq("C", common.SideSell, 10000, 10))
+
+	eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	if aon.FilledQty != 0 || plain.Status != common.OrderStatusFilled {
+		t.Fatalf("pro-rata must not part-fill an AON order: aon %d, plain %d", aon.FilledQty, plain.FilledQty)
+	}
+}
+
+func TestIncomingAllOrNoneRestsUnlessFullyFillable(t *testing.T) {
+	eng := newFundedEngine("A", "B")
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 5))
+
+	o, trades, err := eng.PlaceOrder(aonReq("A", common.SideBuy, 10000, 8))
+	if err != nil || len(trades) != 0 || o.Status != common.OrderStatusAccepted {
+		t.Fatalf("AON that cannot fill should rest untouched: %v %d %s", err, len(trades), o.Status)
+	}
+
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10100, 5))
+	o, trades, _ = eng.PlaceOrder(aonReq("A", common.SideBuy, 10100, 8))
+	if o.Status != common.OrderStatusFilled || len(trades) != 2 {
+		t.Fatalf("AON should fill across levels when it can fill whole: %s %d", o.Status, len(trades))
+	}
+}
+
+func TestCrossingAllOrNoneFillsOnceLiquidityArrives(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 5))
+	aon, _, _ := eng.PlaceOrder(aonReq("A", common.SideBuy, 10000, 8))
+
+	// The new ask does not fill the AON on its own, but together with the
+	// one it rests against it does.
+	_, trades, _ := eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10000, 3))
+	if len(trades) != 0 {
+		t.Fatalf("the incoming sell cannot fill the AON by itself, got %+v", trades)
+	}
+	if aon.Status != common.OrderStatusFilled {
+		t.Fatalf("expected the crossing AON filled, got %s %d", aon.Status, aon.FilledQty)
+	}
+	book, _ := eng.GetOrderBook("AAPL")
+	if book.Bids.TotalQuantity != 0 || book.Asks.TotalQuantity != 0 {
+		t.Fatalf("expected an empty book, got bids %d asks %d", book.Bids.TotalQuantity, book.Asks.TotalQuantity)
+	}
+	if b := eng.Ledger.Balance("A", "AAPL"); b.Available != 10_008 {
+		t.Fatalf("expected the AON buyer to hold 8, got %+v", b)
+	}
+}
+
+func TestMinQtyRejectsSmallCrossAndRestsOtherwise(t *testing.T) {
+	eng := newFundedEngine("A", "B")
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 3))
+
+	req := accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10)
+	req.MinQty = 5
+	if _, _, err := eng.PlaceOrder(req); err != engine.ErrMinQtyNotMet {
+		t.Fatalf("expected ErrMinQtyNotMet, got %v", err)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 0 {
+		t.Fatalf("rejected order must release its funds, got %d", b.Reserved)
+	}
+
+	req = accountReq("A", common.SideBuy, common.OrderTypeLimit, 9000, 10)
+	req.MinQty = 5
+	if o, _, err := eng.PlaceOrder(req); err != nil || o.Status != common.OrderStatusAccepted {
+		t.Fatalf("non-crossing min-qty order should rest: %v", err)
+	}
+
+	req = accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10)
+	req.MinQty = 3
+	o, _, _ := eng.PlaceOrder(req)
+	if o.FilledQty != 3 || o.Status != common.OrderStatusPartial {
+		t.Fatalf("expected 3 filled and the rest resting, got %d %s", o.FilledQty, o.Status)
+	}
+}
@@ -0,0 +1,138 @@
+package engine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/risk"
+)
+
+func TestContractMultiplierScalesNotional(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ6", Type: instruments.TypeFuture,
+		Expiry: time.Now().Add(time.Hour).UnixMilli(), ContractMultiplier: 50})
+	eng.Fees.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: 0, TakerBps: 10}}})
+	eng.Risk.SetDefaultLimits(risk.Limits{MaxNotional: 1_000_000})
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("B", "USD", 10_000_000)
+	eng.Ledger.Deposit("S", "ESZ6", 10)
+
+	// 3 contracts at 10000 are worth 1,500,000 with a multiplier of 50.
+	var rej *risk.RejectError
+	if _, _, err := eng.PlaceOrder(symbolReq("ESZ6", "B", common.SideBuy, common.OrderTypeLimit, 10000, 3)); !errors.As(err, &rej) || rej.Code != risk.RejectMaxNotional {
+		t.Fatalf("expected MAX_NOTIONAL reject, got %v", err)
+	}
+
+	eng.PlaceOrder(symbolReq("ESZ6", "S", common.SideSell, common.OrderTypeLimit, 10000, 2))
+	_, trades, err := eng.PlaceOrder(symbolReq("ESZ6", "B", common.SideBuy, common.OrderTypeLimit, 10000, 2))
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("expected a trade, got %v %v", trades, err)
+	}
+	if trades[0].BuyFee != 1000 {
+		t.Fatalf("taker fee should apply to 1,000,000 notional, got %d", trades[0].BuyFee)
+	}
+	if b := eng.Ledger.Balance("B", "USD"); b.Available != 10_000_000-1_000_000-1000 || b.Reserved != 0 {
+		t.Fatalf("buyer should pay the full contract value: %+v", b)
+	}
+	if b := eng.Ledger.Balance("S", "USD"); b.Available != 1_000_000 {
+		t.Fatalf("seller should receive the full contract value: %+v", b)
+	}
+}
+
+func TestDerivativeExpiry(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	expiry := time.Now().Add(50 * time.Millisecond).UnixMilli()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ6", Type: instruments.TypeFuture, Expiry: expiry, ContractMultiplier: 50})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ6-C9500", Type: instruments.TypeOption, Expiry: expiry,
+		Underlying: "ESZ6", Strike: 9500, OptionType: instruments.OptionCall})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ6-P9500", Type: instruments.TypeOption, Expiry: expiry,
+		Underlying: "ESZ6", Strike: 9500, OptionType: instruments.OptionPut})
+
+	var cancels []*engine.Cancellation
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventCancel {
+			cancels = append(cancels, ev.Cancel)
+		}
+	})
+
+	eng.PlaceOrder(symbolReq("ESZ6", "S", common.SideSell, common.OrderTypeLimit, 10000, 1))
+	eng.PlaceOrder(symbolReq("ESZ6", "B", common.SideBuy, common.OrderTypeLimit, 10000, 1))
+	resting, _, _ := eng.PlaceOrder(symbolReq("ESZ6", "B", common.SideBuy, common.OrderTypeLimit, 9900, 5))
+	eng.ScheduleExpiries()
+
+	deadline := time.Now().Add(time.Second)
+	for len(eng.Settlements()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("instruments did not expire: %+v", eng.Settlements())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if o, _ := eng.GetOrder(resting.ID); o.Status != common.OrderStatusCancelled {
+		t.Fatalf("open order should be cancelled at expiry, got %s", o.Status)
+	}
+	if len(cancels) != 1 || cancels[0].Reason != engine.CancelReasonExpired {
+		t.Fatalf("expected one expiry cancel, got %+v", cancels)
+	}
+	if s := eng.TradingStatus("ESZ6"); s != common.TradingStatusClosed {
+		t.Fatalf("expected CLOSED, got %s", s)
+	}
+	if _, _, err := eng.PlaceOrder(symbolReq("ESZ6", "B", common.SideBuy, common.OrderTypeLimit, 9900, 1)); err != instruments.ErrExpired {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+	if _, err := eng.SetTradingStatus("ESZ6", common.TradingStatusOpen, "reopen"); err != instruments.ErrExpired {
+		t.Fatalf("expired instruments must not reopen, got %v", err)
+	}
+
+	if s, _ := eng.Settlement("ESZ6"); s.Price != 10000 || s.Source != engine.SettlementLastTrade {
+		t.Fatalf("future should settle at the last trade: %+v", s)
+	}
+	if s, _ := eng.Settlement("ESZ6-C9500"); s.Price != 500 || s.UnderlyingPrice != 10000 || s.Source != engine.SettlementIntrinsic {
+		t.Fatalf("call should settle at its intrinsic value: %+v", s)
+	}
+	if s, _ := eng.Settlement("ESZ6-P9500"); s.Price != 0 || s.Source != engine.SettlementIntrinsic {
+		t.Fatalf("out-of-the-money put should settle at zero: %+v", s)
+	}
+
+	if s, err := eng.SetSettlementPrice("ESZ6", 10010); err != nil || s.Price != 10010 || s.Source != engine.SettlementAdmin {
+		t.Fatalf("unexpected override %+v: %v", s, err)
+	}
+}
+
+func TestSettlementPendingWithoutTrades(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "NQZ6", Type: instruments.TypeFuture,
+		Expiry: time.Now().Add(-time.Second).UnixMilli()})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "NQH7", Type: instruments.TypeFuture,
+		Expiry: time.Now().Add(time.Hour).UnixMilli()})
+
+	if _, err := eng.SetSettlementPrice("NQH7", 100); err != engine.ErrNotExpired {
+		t.Fatalf("expected ErrNotExpired, got %v", err)
+	}
+	eng.ScheduleExpiries()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if s, ok := eng.Settlement("NQZ6"); ok {
+			if s.Source != engine.SettlementPending || s.Price != 0 {
+				t.Fatalf("expected a pending settlement, got %+v", s)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("past-expiry instrument did not expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := eng.Settlement("NQH7"); ok {
+		t.Fatalf("unexpired future must not settle")
+	}
+}
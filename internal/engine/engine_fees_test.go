@@ -0,0 +1,83 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/ledger"
+)
+
+func TestFeesRecordedOnTrade(t *testing.T) {
+	eng := newFundedEngine("MAKER", "TAKER")
+	eng.Ledger.AllowOverdraft(fees.CollectorAccount)
+	eng.Fees.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: -2, TakerBps: 10}}})
+	before := eng.Ledger.Totals()
+
+	maker, _, _ := eng.PlaceOrder(accountReq("MAKER", common.SideSell, common.OrderTypeLimit, 10000, 100))
+	taker, trades, err := eng.PlaceOrder(accountReq("TAKER", common.SideBuy, common.OrderTypeLimit, 10000, 100))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	// Notional 1,000,000: taker pays 10 bps, maker earns 2 bps.
+	tr := trades[0]
+	if tr.TakerSide != common.SideBuy || tr.BuyFee != 1000 || tr.SellFee != -200 {
+		t.Fatalf("unexpected trade fees: %+v", tr)
+	}
+	if taker.Fees != 1000 || maker.Fees != -200 {
+		t.Fatalf("unexpected order fees: taker %d maker %d", taker.Fees, maker.Fees)
+	}
+
+	if b := eng.Ledger.Balance("TAKER", "USD"); b.Available != 100_000_000-1_001_000 || b.Reserved != 0 {
+		t.Fatalf("unexpected taker USD: %+v", b)
+	}
+	if b := eng.Ledger.Balance("MAKER", "USD"); b.Available != 100_000_000+1_000_200 {
+		t.Fatalf("unexpected maker USD: %+v", b)
+	}
+	if b := eng.Ledger.Balance(fees.CollectorAccount, "USD"); b.Available != 800 {
+		t.Fatalf("unexpected collected fees: %+v", b)
+	}
+
+	after := eng.Ledger.Totals()
+	if after["USD"] != before["USD"] || after["AAPL"] != before["AAPL"] {
+		t.Fatalf("assets not conserved: %v -> %v", before, after)
+	}
+
+	if rep := eng.Fees.Report("TAKER", 0, 0); rep.TakerFees != 1000 {
+		t.Fatalf("fee report missing taker fee: %+v", rep)
+	}
+}
+
+func TestFeeScheduleChangeWhileResting(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("BUYER", "USD", 1_000_000) // exactly the notional, no fee headroom at 0 bps
+	eng.Ledger.Deposit("SELLER", "AAPL", 100)
+
+	buy, _, err := eng.PlaceOrder(accountReq("BUYER", common.SideBuy, common.OrderTypeLimit, 10000, 100))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	eng.Fees.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: 10, TakerBps: 10}}})
+
+	_, trades, err := eng.PlaceOrder(accountReq("SELLER", common.SideSell, common.OrderTypeLimit, 10000, 100))
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("expected a fill, got %v %v", trades, err)
+	}
+
+	// The resting buy pays the 0 bps it was entered at; the new sell pays 10.
+	if trades[0].BuyFee != 0 || trades[0].SellFee != 1000 || buy.Fees != 0 {
+		t.Fatalf("unexpected fees %+v", trades[0])
+	}
+	if failures := eng.SettlementFailures(); len(failures) != 0 {
+		t.Fatalf("unexpected settlement failures %+v", failures)
+	}
+	if b := eng.Ledger.Balance("BUYER", "AAPL"); b.Available != 100 {
+		t.Fatalf("buyer not settled: %+v", b)
+	}
+	if b := eng.Ledger.Balance("SELLER", "USD"); b.Available != 999_000 {
+		t.Fatalf("seller not settled: %+v", b)
+	}
+}
@@ -0,0 +1,248 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+)
+
+func stopReq(account string, side common.Side, stop, qty int64) *common.Order {
+	req := accountReq(account, side, common.OrderTypeStop, 0, qty)
+	req.StopPrice = stop
+	return req
+}
+
+func TestStopOrderTriggersOnLastPrice(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 5))
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10100, 5))
+
+	stop, _, err := eng.PlaceOrder(stopReq("A", common.SideBuy, 10000, 5))
+	if err != nil || stop.Status != common.OrderStatusPending {
+		t.Fatalf("expected pending stop, got %v / %+v", err, stop)
+	}
+	if book, _ := eng.GetOrderBook("AAPL"); book.Bids.TotalQuantity != 0 {
+		t.Fatalf("untriggered stops must stay off the book")
+	}
+
+	eng.PlaceOrder(accountReq("C", common.SideBuy, common.OrderTypeLimit, 10000, 1))
+
+	if !stop.Triggered || stop.Type != common.OrderTypeMarket {
+		t.Fatalf("stop should have triggered as a market order: %+v", stop)
+	}
+	if stop.Status != common.OrderStatusFilled || stop.FilledQty != 5 {
+		t.Fatalf("expected triggered stop filled, got %s %d", stop.Status, stop.FilledQty)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 0 {
+		t.Fatalf("expected no funds left reserved, got %d", b.Reserved)
+	}
+}
+
+func TestOCOPartialFillReducesSibling(t *testing.T) {
+	eng := newFundedEngine("A", "B")
+
+	var cancels []*engine.Cancellation
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventCancel {
+			cancels = append(cancels, ev.Cancel)
+		}
+	})
+
+	g, legs, _, err := eng.PlaceOCO([]*common.Order{
+		accountReq("A", common.SideSell, common.OrderTypeLimit, 10500, 10),
+		stopReq("A", common.SideSell, 9500, 10),
+	})
+	if err != nil || g.State != engine.GroupActive {
+		t.Fatalf("unexpected: %v %+v", err, g)
+	}
+	limit, stop := legs[0], legs[1]
+
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 10500, 4))
+	if stop.Quantity != 6 {
+		t.Fatalf("expected stop reduced to 6, got %d", stop.Quantity)
+	}
+	if b := eng.Ledger.Balance("A", "AAPL"); b.Reserved != 6 {
+		t.Fatalf("expected 6 AAPL reserved, got %d", b.Reserved)
+	}
+
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 10500, 6))
+	if limit.Status != common.OrderStatusFilled || stop.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected limit filled and stop cancelled, got %s / %s", limit.Status, stop.Status)
+	}
+	if got, _ := eng.GetGroup(g.ID); got.State != engine.GroupCompleted {
+		t.Fatalf("expected group completed, got %s", got.State)
+	}
+	if len(cancels) != 1 || cancels[0].OrderID != stop.ID || cancels[0].Reason != engine.CancelReasonOCO {
+		t.Fatalf("unexpected cancel events: %+v", cancels)
+	}
+}
+
+func TestOCOReductionReleasesScaledReservation(t *testing.T) {
+	newEngine := func() *engine.MatchingEngine {
+		eng := newSpreadEngine(false)
+		eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ5-ESH6", Type: instruments.TypePerp, ContractMultiplier: 10, Legs: []instruments.Leg{
+			{Symbol: "ESZ5", Ratio: 1},
+			{Symbol: "ESH6", Ratio: -1},
+		}})
+		eng.Ledger = ledger.New()
+		for _, acct := range []string{"A", "B"} {
+			eng.Ledger.Deposit(acct, "USD", 10_000)
+			eng.Ledger.Deposit(acct, "ESZ5-ESH6", 20)
+		}
+		return eng
+	}
+
+	// A buy reserves price * qty * multiplier per leg.
+	eng := newEngine()
+	eng.PlaceOCO([]*common.Order{
+		symbolReq("ESZ5-ESH6", "A", common.SideBuy, common.OrderTypeLimit, 20, 10),
+		symbolReq("ESZ5-ESH6", "A", common.SideBuy, common.OrderTypeLimit, 15, 10),
+	})
+	eng.PlaceOrder(symbolReq("ESZ5-ESH6", "B", common.SideSell, common.OrderTypeLimit, 20, 4))
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 6*20*10+6*15*10 {
+		t.Fatalf("expected both buy legs reserved for 6, got %+v", b)
+	}
+
+	// A sell below zero owes up to -price * qty * multiplier per leg.
+	eng = newEngine()
+	eng.PlaceOCO([]*common.Order{
+		symbolReq("ESZ5-ESH6", "B", common.SideSell, common.OrderTypeLimit, -30, 10),
+		symbolReq("ESZ5-ESH6", "B", common.SideSell, common.OrderTypeLimit, -25, 10),
+	})
+	eng.PlaceOrder(symbolReq("ESZ5-ESH6", "A", common.SideBuy, common.OrderTypeLimit, -30, 4))
+	if b := eng.Ledger.Balance("B", "USD"); b.Reserved != 6*30*10+6*25*10 {
+		t.Fatalf("expected both sell legs to owe for 6, got %+v", b)
+	}
+}
+
+func TestOCOStopTriggerCancelsSibling(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.PlaceOrder(accountReq("C", common.SideBuy, common.OrderTypeLimit, 9500, 20))
+
+	g, legs, _, _ := eng.PlaceOCO([]*common.Order{
+		accountReq("A", common.SideSell, common.OrderTypeLimit, 10500, 10),
+		stopReq("A", common.SideSell, 9500, 10),
+	})
+	limit, stop := legs[0], legs[1]
+
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 9500, 1))
+
+	if limit.Status != common.OrderStatusCancelled {
+		t.Fatalf("sibling must be cancelled when the stop triggers, got %s", limit.Status)
+	}
+	if stop.Status != common.OrderStatusFilled || stop.FilledQty != 10 {
+		t.Fatalf("expected stop filled, got %s %d", stop.Status, stop.FilledQty)
+	}
+	if got, _ := eng.GetGroup(g.ID); got.State != engine.GroupCompleted || got.TriggeredID != stop.ID {
+		t.Fatalf("unexpected group: %+v", got)
+	}
+	if b := eng.Ledger.Balance("A", "AAPL"); b.Reserved != 0 {
+		t.Fatalf("expected nothing reserved, got %d", b.Reserved)
+	}
+}
+
+func TestOCORejectedLegReturnsEarlierFills(t *testing.T) {
+	eng := newFundedEngine("B")
+	eng.Ledger.Deposit("A", "AAPL", 10)
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 10500, 4))
+
+	// The first leg locks all of A's AAPL, so the second cannot be funded.
+	g, legs, trades, err := eng.PlaceOCO([]*common.Order{
+		accountReq("A", common.SideSell, common.OrderTypeLimit, 10500, 10),
+		accountReq("A", common.SideSell, common.OrderTypeLimit, 10600, 10),
+	})
+	if err == nil {
+		t.Fatalf("expected the second leg rejected")
+	}
+	if g == nil || g.State != engine.GroupCancelled || len(legs) != 2 {
+		t.Fatalf("expected the cancelled group returned, got %+v %+v", g, legs)
+	}
+	if len(trades) != 1 || trades[0].Quantity != 4 || trades[0].SellOrder != legs[0].ID {
+		t.Fatalf("expected the first leg's fill returned, got %+v", trades)
+	}
+	for _, o := range legs {
+		if o.Status != common.OrderStatusCancelled {
+			t.Fatalf("expected every leg cancelled, got %+v", o)
+		}
+	}
+	if b := eng.Ledger.Balance("A", "AAPL"); b.Available != 6 || b.Reserved != 0 {
+		t.Fatalf("unexpected AAPL balance %+v", b)
+	}
+}
+
+func TestBracketActivatesExitsOnEntryFill(t *testing.T) {
+	eng := newFundedEngine("A", "B")
+
+	g, entry, _, err := eng.PlaceBracket(engine.BracketRequest{
+		Entry:      accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10),
+		TakeProfit: 11000,
+		StopLoss:   9000,
+	})
+	if err != nil || g.State != engine.GroupPending {
+		t.Fatalf("unexpected: %v %+v", err, g)
+	}
+	tp, _ := eng.GetOrder(g.LegIDs[0])
+	sl, _ := eng.GetOrder(g.LegIDs[1])
+	if tp.Status != common.OrderStatusPending || sl.Status != common.OrderStatusPending {
+		t.Fatalf("exits must wait for the entry")
+	}
+
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 4))
+	if got, _ := eng.GetGroup(g.ID); got.State != engine.GroupPending {
+		t.Fatalf("partial entry fill must not activate the exits")
+	}
+
+	eng.CancelOrder(entry.ID)
+	got, _ := eng.GetGroup(g.ID)
+	if got.State != engine.GroupActive || got.Quantity != 4 {
+		t.Fatalf("expected exits active for the filled 4, got %+v", got)
+	}
+	if tp.Status != common.OrderStatusAccepted || tp.Quantity != 4 || tp.Side != common.SideSell {
+		t.Fatalf("unexpected take-profit: %+v", tp)
+	}
+	if sl.Status != common.OrderStatusPending || sl.Quantity != 4 {
+		t.Fatalf("unexpected stop-loss: %+v", sl)
+	}
+
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 11000, 4))
+	if got, _ := eng.GetGroup(g.ID); got.State != engine.GroupCompleted || sl.Status != common.OrderStatusCancelled {
+		t.Fatalf("take-profit fill must complete the bracket: %+v / %s", got, sl.Status)
+	}
+}
+
+func TestBracketCancelledWithoutFill(t *testing.T) {
+	eng := newFundedEngine("A")
+
+	g, entry, _, _ := eng.PlaceBracket(engine.BracketRequest{
+		Entry:         accountReq("A", common.SideSell, common.OrderTypeLimit, 10000, 10),
+		TakeProfit:    9000,
+		StopLoss:      11000,
+		StopLossLimit: 11100,
+	})
+	eng.CancelOrder(entry.ID)
+
+	got, _ := eng.GetGroup(g.ID)
+	if got.State != engine.GroupCancelled {
+		t.Fatalf("expected bracket cancelled, got %s", got.State)
+	}
+	for _, id := range got.LegIDs {
+		if o, _ := eng.GetOrder(id); o.Status != common.OrderStatusCancelled {
+			t.Fatalf("exit %s should be cancelled, got %s", id, o.Status)
+		}
+	}
+	if err := eng.CancelGroup(g.ID); err != engine.ErrGroupFinalized {
+		t.Fatalf("expected ErrGroupFinalized, got %v", err)
+	}
+
+	_, _, _, err := eng.PlaceBracket(engine.BracketRequest{
+		Entry:      accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 10),
+		TakeProfit: 9000,
+		StopLoss:   11000,
+	})
+	if err != engine.ErrInvalidOrderData {
+		t.Fatalf("exits on the wrong side of each other must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestHiddenOrdersQueueBehindDisplayed(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+
+	hiddenReq := accountReq("B", common.SideSell, common.OrderTypeLimit, 10000, 5)
+	hiddenReq.Hidden = true
+	hidden, _, _ := eng.PlaceOrder(hiddenReq)
+	shown, _, _ := eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10000, 5))
+
+	book, _ := eng.GetOrderBook("AAPL")
+	if d := book.Asks.Depth(10); len(d) != 1 || d[0].Quantity != 5 {
+		t.Fatalf("depth must show only the displayed 5, got %+v", d)
+	}
+
+	eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 10000, 6))
+	if shown.Status != common.OrderStatusFilled || hidden.FilledQty != 1 {
+		t.Fatalf("displayed order must fill first: shown %s, hidden filled %d", shown.Status, hidden.FilledQty)
+	}
+	if d := book.Asks.Depth(10); len(d) != 0 {
+		t.Fatalf("a level of hidden orders must not be displayed, got %+v", d)
+	}
+	if book.Asks.TotalQuantity != 4 {
+		t.Fatalf("hidden quantity still counts as liquidity, got %d", book.Asks.TotalQuantity)
+	}
+}
+
+func darkReq(account string, side common.Side, limit, qty int64) *common.Order {
+	req := accountReq(account, side, common.OrderTypeLimit, limit, qty)
+	req.Dark = true
+	return req
+}
+
+func TestDarkBookCrossesAtLitMidpoint(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C", "D")
+	eng.PlaceOrder(accountReq("D", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(accountReq("D", common.SideSell, common.OrderTypeLimit, 10100, 10))
+
+	buy, _, _ := eng.PlaceOrder(darkReq("A", common.SideBuy, 10050, 5))
+	_, trades, _ := eng.PlaceOrder(darkReq("B", common.SideSell, 9950, 3))
+	if len(trades) != 1 || trades[0].Price != 10000 || trades[0].Quantity != 3 {
+		t.Fatalf("expected 3 at the 10000 midpoint, got %+v", trades)
+	}
+	book, _ := eng.GetOrderBook("AAPL")
+	if book.Bids.TotalQuantity != 10 || book.Asks.TotalQuantity != 10 {
+		t.Fatalf("dark orders must not touch the lit book")
+	}
+
+	sell, _, _ := eng.PlaceOrder(darkReq("C", common.SideSell, 10020, 2))
+	if sell.FilledQty != 0 {
+		t.Fatalf("midpoint below the sell limit must not trade")
+	}
+
+	var crossed []*common.Trade
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventTrade {
+			crossed = append(crossed, ev.Trade)
+		}
+	})
+
+	// Lifting the lit bid moves the midpoint to 10030, inside both limits.
+	eng.PlaceOrder(accountReq("D", common.SideBuy, common.OrderTypeLimit, 9960, 1))
+	if buy.Status != common.OrderStatusFilled || sell.Status != common.OrderStatusFilled {
+		t.Fatalf("resting dark orders should cross on the new midpoint: %s / %s", buy.Status, sell.Status)
+	}
+	if len(crossed) != 1 || crossed[0].Price != 10030 || crossed[0].Type != common.TradeTypeDark {
+		t.Fatalf("expected a DARK cross at 10030, got %+v", crossed)
+	}
+	// Dark prints leave the lit book's prices alone.
+	if book.LastPrice != 0 || book.ReferencePrice != 0 {
+		t.Fatalf("dark trades moved lit prices: last %d reference %d", book.LastPrice, book.ReferencePrice)
+	}
+}
+
+func TestDarkBookRoundsHalfUnitMidpoint(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C", "D")
+	// A one-unit spread: the midpoint 10000.5 lies between price units.
+	eng.PlaceOrder(accountReq("D", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	eng.PlaceOrder(accountReq("D", common.SideSell, common.OrderTypeLimit, 10001, 10))
+
+	// The resting order gets the better unit: a resting buy pays 10000.
+	eng.PlaceOrder(darkReq("A", common.SideBuy, 10001, 5))
+	_, trades, _ := eng.PlaceOrder(darkReq("B", common.SideSell, 10000, 5))
+	if len(trades) != 1 || trades[0].Price != 10000 || trades[0].Type != common.TradeTypeDark {
+		t.Fatalf("expected a DARK trade at 10000, got %+v", trades)
+	}
+
+	// A resting sell receives 10001.
+	eng.PlaceOrder(darkReq("C", common.SideSell, 10000, 5))
+	_, trades, _ = eng.PlaceOrder(darkReq("A", common.SideBuy, 10001, 5))
+	if len(trades) != 1 || trades[0].Price != 10001 {
+		t.Fatalf("expected a trade at 10001, got %+v", trades)
+	}
+
+	// A limit that excludes the rounded price does not trade.
+	eng.PlaceOrder(darkReq("C", common.SideSell, 10001, 5))
+	buy, trades, _ := eng.PlaceOrder(darkReq("A", common.SideBuy, 10000, 5))
+	if len(trades) != 0 {
+		t.Fatalf("a buy limited at 10000 must not pay 10001: %+v", trades)
+	}
+	if err := eng.CancelOrder(buy.ID); err != nil || buy.Status != common.OrderStatusCancelled {
+		t.Fatalf("dark orders must be cancellable: %v", err)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 0 {
+		t.Fatalf("expected dark reservation released, got %d", b.Reserved)
+	}
+}
@@ -0,0 +1,91 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/matching"
+)
+
+func TestInstrumentRegistryValidation(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "AAPL", TickSize: 5, LotSize: 10})
+
+	if _, _, err := eng.PlaceOrder(newReq("APPL", common.SideBuy, common.OrderTypeLimit, 10000, 10)); err != instruments.ErrUnknownSymbol {
+		t.Fatalf("expected unknown symbol, got %v", err)
+	}
+	if _, ok := eng.GetOrderBook("APPL"); ok {
+		t.Fatalf("typo must not create a book")
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10001, 10)); err != instruments.ErrInvalidTick {
+		t.Fatalf("expected off-tick reject, got %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 15)); err != instruments.ErrInvalidLot {
+		t.Fatalf("expected off-lot reject, got %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 20)); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+}
+
+func TestInstrumentAssetsUsedForSettlement(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "BTC-EUR", BaseAsset: "BTC", QuoteAsset: "EUR"})
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("B", "EUR", 1_000_000)
+	eng.Ledger.Deposit("S", "BTC", 10)
+
+	sell := &common.Order{Account: "S", Symbol: "BTC-EUR", Side: common.SideSell, Type: common.OrderTypeLimit, Price: 50000, Quantity: 2}
+	buy := &common.Order{Account: "B", Symbol: "BTC-EUR", Side: common.SideBuy, Type: common.OrderTypeLimit, Price: 50000, Quantity: 2}
+	eng.PlaceOrder(sell)
+	if _, _, err := eng.PlaceOrder(buy); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	if b := eng.Ledger.Balance("B", "BTC"); b.Available != 2 {
+		t.Fatalf("buyer BTC not credited: %+v", b)
+	}
+	if b := eng.Ledger.Balance("S", "EUR"); b.Available != 100000 {
+		t.Fatalf("seller EUR not credited: %+v", b)
+	}
+}
+
+func TestInstrumentMatchingAlgorithm(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ES", Matching: matching.Config{Algorithm: matching.KindProRata}})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "AAPL"})
+
+	for _, sym := range []string{"ES", "AAPL"} {
+		eng.PlaceOrder(newReq(sym, common.SideSell, common.OrderTypeLimit, 10000, 100))
+		eng.PlaceOrder(newReq(sym, common.SideSell, common.OrderTypeLimit, 10000, 300))
+	}
+
+	_, trades, _ := eng.PlaceOrder(newReq("ES", common.SideBuy, common.OrderTypeLimit, 10000, 200))
+	if len(trades) != 2 || trades[0].Quantity != 50 || trades[1].Quantity != 150 {
+		t.Fatalf("expected pro-rata fills of 50 and 150, got %+v", trades)
+	}
+	_, trades, _ = eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 200))
+	if len(trades) != 2 || trades[0].Quantity != 100 || trades[1].Quantity != 100 {
+		t.Fatalf("expected FIFO fills of 100 and 100, got %+v", trades)
+	}
+
+	book, _ := eng.GetOrderBook("ES")
+	if level := book.Asks.Levels[10000]; len(level.Orders) != 2 || book.Asks.TotalQuantity != 200 {
+		t.Fatalf("partially filled orders should keep their place: %+v", level.Orders)
+	}
+	if eng.MatchingAlgorithm("ES") != "PRO_RATA" || eng.MatchingAlgorithm("AAPL") != "FIFO" {
+		t.Fatalf("unexpected algorithms")
+	}
+
+	// A changed instrument configuration replaces the cached algorithm.
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ES", Matching: matching.Config{Algorithm: matching.KindFIFO}})
+	if got := eng.MatchingAlgorithm("ES"); got != "FIFO" {
+		t.Fatalf("expected the new configuration to apply, got %s", got)
+	}
+}
@@ -0,0 +1,114 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestDeadManSwitch(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	mine, _, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+
+	var cancels []*engine.Cancellation
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventCancel {
+			cancels = append(cancels, ev.Cancel)
+		}
+	})
+
+	if _, err := eng.ArmDeadManSwitch("A", 40*time.Millisecond); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	// Refreshing pushes the expiry out.
+	time.Sleep(25 * time.Millisecond)
+	eng.ArmDeadManSwitch("A", 40*time.Millisecond)
+	time.Sleep(25 * time.Millisecond)
+	if eng.OrdersInBook() != 2 {
+		t.Fatalf("refreshed switch must not fire")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for eng.OrdersInBook() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("switch did not fire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got, _ := eng.GetOrder(mine.ID); got.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected account order cancelled")
+	}
+	if _, armed := eng.DeadManSwitch("A"); armed {
+		t.Fatalf("fired switch should disarm")
+	}
+	eng.MassCancel(engine.MassCancelFilter{}) // synchronise with the timer goroutine
+	if cancels[0].Reason != engine.CancelReasonDeadMan {
+		t.Fatalf("unexpected reason %q", cancels[0].Reason)
+	}
+}
+
+func TestKillSwitches(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+
+	ids, _ := eng.SetAccountKillSwitch("A", true)
+	if len(ids) != 1 || eng.OrdersInBook() != 1 {
+		t.Fatalf("expected the account's order cancelled, got %v", ids)
+	}
+	if _, _, err := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10)); err != engine.ErrKillSwitchActive {
+		t.Fatalf("expected blocked entry, got %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10)); err != nil {
+		t.Fatalf("other accounts keep trading: %v", err)
+	}
+
+	if ids := eng.SetGlobalKillSwitch(true); len(ids) != 2 {
+		t.Fatalf("expected every order cancelled, got %v", ids)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 9900, 10)); err != engine.ErrKillSwitchActive {
+		t.Fatalf("expected global block, got %v", err)
+	}
+	if st := eng.KillSwitches(); !st.Global || len(st.Accounts) != 1 {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+
+	eng.SetGlobalKillSwitch(false)
+	eng.SetAccountKillSwitch("A", false)
+	if _, _, err := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10)); err != nil {
+		t.Fatalf("entry should resume: %v", err)
+	}
+}
+
+func TestKillSwitchesCancelOnHaltedBook(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	killed, _, _ := eng.PlaceOrder(accountReq("A", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	dead, _, _ := eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	if _, err := eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "test"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	if ids, _ := eng.SetAccountKillSwitch("A", true); len(ids) != 1 || ids[0] != killed.ID {
+		t.Fatalf("expected the halted order cancelled, got %v", ids)
+	}
+
+	eng.ArmDeadManSwitch("B", 10*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for eng.OrdersInBook() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("dead-man's switch left the halted order resting")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Nothing comes back when trading resumes.
+	eng.SetTradingStatus("AAPL", common.TradingStatusOpen, "test")
+	for _, id := range []string{killed.ID, dead.ID} {
+		if got, _ := eng.GetOrder(id); got.Status != common.OrderStatusCancelled {
+			t.Fatalf("expected %s cancelled, got %s", id, got.Status)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package engine_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/ledger"
+)
+
+func newFundedEngine(accounts ...string) *engine.MatchingEngine {
+	eng := engine.NewMatchingEngine()
+	eng.Ledger = ledger.New()
+	for _, acc := range accounts {
+		eng.Ledger.Deposit(acc, "USD", 100_000_000)
+		eng.Ledger.Deposit(acc, "AAPL", 10_000)
+	}
+	return eng
+}
+
+func accountReq(account string, side common.Side, typ common.OrderType, price, qty int64) *common.Order {
+	req := newReq("AAPL", side, typ, price, qty)
+	req.Account = account
+	return req
+}
+
+func TestLedgerReservesAndSettles(t *testing.T) {
+	eng := newFundedEngine("BUYER", "SELLER")
+
+	// Buyer rests 100 @ 10000 -> 1,000,000 USD reserved.
+	buy, _, err := eng.PlaceOrder(accountReq("BUYER", common.SideBuy, common.OrderTypeLimit, 10000, 100))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if b := eng.Ledger.Balance("BUYER", "USD"); b.Reserved != 1_000_000 {
+		t.Fatalf("expected 1,000,000 reserved, got %+v", b)
+	}
+
+	// Seller hits 60.
+	eng.PlaceOrder(accountReq("SELLER", common.SideSell, common.OrderTypeLimit, 10000, 60))
+
+	if b := eng.Ledger.Balance("BUYER", "AAPL"); b.Available != 10_060 {
+		t.Fatalf("buyer base not credited: %+v", b)
+	}
+	if b := eng.Ledger.Balance("SELLER", "USD"); b.Available != 100_600_000 {
+		t.Fatalf("seller quote not credited: %+v", b)
+	}
+	if b := eng.Ledger.Balance("BUYER", "USD"); b.Reserved != 400_000 {
+		t.Fatalf("expected 400,000 still reserved, got %+v", b)
+	}
+
+	// Cancelling the remainder releases the rest of the reservation.
+	eng.CancelOrder(buy.ID)
+	if b := eng.Ledger.Balance("BUYER", "USD"); b.Reserved != 0 || b.Available != 99_400_000 {
+		t.Fatalf("unexpected buyer USD after cancel: %+v", b)
+	}
+}
+
+func TestLedgerPriceImprovementRefund(t *testing.T) {
+	eng := newFundedEngine("BUYER", "SELLER")
+
+	eng.PlaceOrder(accountReq("SELLER", common.SideSell, common.OrderTypeLimit, 9000, 10))
+	eng.PlaceOrder(accountReq("BUYER", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+
+	if b := eng.Ledger.Balance("BUYER", "USD"); b.Reserved != 0 || b.Available != 100_000_000-90_000 {
+		t.Fatalf("buyer should pay the resting price, got %+v", b)
+	}
+}
+
+func TestLedgerRejectsUnfundedOrders(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("POOR", "USD", 500)
+
+	if _, _, err := eng.PlaceOrder(accountReq("POOR", common.SideBuy, common.OrderTypeLimit, 100, 10)); err != engine.ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(accountReq("POOR", common.SideSell, common.OrderTypeLimit, 100, 1)); err != engine.ErrInsufficientFunds {
+		t.Fatalf("expected insufficient base, got %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 1, 1)); err != engine.ErrAccountRequired {
+		t.Fatalf("expected account required, got %v", err)
+	}
+	if book, ok := eng.GetOrderBook("AAPL"); ok && (len(book.Bids.Prices) != 0 || len(book.Asks.Prices) != 0) {
+		t.Fatalf("rejected orders must not rest")
+	}
+}
+
+// Invariant: trading only moves assets between accounts, and reserved
+// balances always equal what resting orders still need.
+func TestLedgerInvariants_AssetsConserved(t *testing.T) {
+	accounts := []string{"A", "B", "C", "D"}
+	eng := newFundedEngine(accounts...)
+	before := eng.Ledger.Totals()
+
+	rng := rand.New(rand.NewSource(42))
+	var placed []*common.Order
+	for i := 0; i < 2000; i++ {
+		acc := accounts[rng.Intn(len(accounts))]
+		side := common.SideBuy
+		if rng.Intn(2) == 0 {
+			side = common.SideSell
+		}
+		typ := common.OrderTypeLimit
+		if rng.Intn(10) == 0 {
+			typ = common.OrderTypeMarket
+		}
+		o, _, err := eng.PlaceOrder(accountReq(acc, side, typ, 9900+int64(rng.Intn(200)), 1+int64(rng.Intn(50))))
+		if err == nil {
+			placed = append(placed, o)
+		}
+		if rng.Intn(5) == 0 && len(placed) > 0 {
+			eng.CancelOrder(placed[rng.Intn(len(placed))].ID)
+		}
+	}
+
+	after := eng.Ledger.Totals()
+	for asset, total := range before {
+		if after[asset] != total {
+			t.Fatalf("%s not conserved: before %d after %d", asset, total, after[asset])
+		}
+	}
+
+	book, _ := eng.GetOrderBook("AAPL")
+	wantUSD, wantAAPL := int64(0), int64(0)
+	for _, lvl := range book.Bids.Levels {
+		for _, o := range lvl.Orders {
+			wantUSD += o.Price * (o.Quantity - o.FilledQty)
+		}
+	}
+	for _, lvl := range book.Asks.Levels {
+		for _, o := range lvl.Orders {
+			wantAAPL += o.Quantity - o.FilledQty
+		}
+	}
+	gotUSD, gotAAPL := int64(0), int64(0)
+	for _, acc := range accounts {
+		gotUSD += eng.Ledger.Balance(acc, "USD").Reserved
+		gotAAPL += eng.Ledger.Balance(acc, "AAPL").Reserved
+	}
+	if gotUSD != wantUSD || gotAAPL != wantAAPL {
+		t.Fatalf("reserved USD %d/%d AAPL %d/%d do not match resting orders", gotUSD, wantUSD, gotAAPL, wantAAPL)
+	}
+}
+
+func TestLedgerCollectorFundsRebates(t *testing.T) {
+	eng := newFundedEngine("BUYER", "SELLER")
+	eng.Fees.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: -5, TakerBps: -2}}})
+
+	eng.PlaceOrder(accountReq("BUYER", common.SideBuy, common.OrderTypeLimit, 10000, 100))
+	if _, trades, err := eng.PlaceOrder(accountReq("SELLER", common.SideSell, common.OrderTypeLimit, 10000, 100)); err != nil || len(trades) != 1 {
+		t.Fatalf("unexpected: %v %v", trades, err)
+	}
+	if failures := eng.SettlementFailures(); len(failures) != 0 {
+		t.Fatalf("rebates must settle, got %+v", failures)
+	}
+	// 5 bps to the maker and 2 bps to the taker on 1,000,000.
+	if b := eng.Ledger.Balance(fees.CollectorAccount, "USD"); b.Available != -700 {
+		t.Fatalf("expected the collector to pay 700 in rebates, got %+v", b)
+	}
+}
+
+func TestLedgerSettlementFailureHoldsReservations(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("BUYER", "USD", 1_000_000)
+	eng.Ledger.Deposit("SELLER", "AAPL", 100)
+
+	eng.PlaceOrder(accountReq("SELLER", common.SideSell, common.OrderTypeLimit, 10000, 100))
+	// The seller's AAPL is released behind the engine's back.
+	eng.Ledger.Release("SELLER", "AAPL", 100)
+
+	_, trades, err := eng.PlaceOrder(accountReq("BUYER", common.SideBuy, common.OrderTypeLimit, 10000, 100))
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("expected the match to stand, got %v %v", trades, err)
+	}
+
+	failures := eng.SettlementFailures()
+	if len(failures) != 1 || failures[0].TradeID != trades[0].TradeID || failures[0].SellAccount != "SELLER" {
+		t.Fatalf("expected the trade quarantined, got %+v", failures)
+	}
+	if failures[0].BuyHeld != 1_000_000 || failures[0].SellHeld != 100 || failures[0].QuoteAsset != "USD" {
+		t.Fatalf("unexpected held amounts %+v", failures[0])
+	}
+	// Nothing moved, and the buyer's funds stay reserved for reconciliation.
+	if b := eng.Ledger.Balance("BUYER", "USD"); b.Available != 0 || b.Reserved != 1_000_000 {
+		t.Fatalf("unexpected buyer USD %+v", b)
+	}
+	if b := eng.Ledger.Balance("BUYER", "AAPL"); b.Available != 0 {
+		t.Fatalf("unexpected buyer AAPL %+v", b)
+	}
+}
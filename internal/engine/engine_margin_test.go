@@ -0,0 +1,167 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/margin"
+)
+
+// newMarginEngine trades BTC-PERP with 10% initial and 5% maintenance margin.
+func newMarginEngine() *engine.MatchingEngine {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "BTC-PERP", Type: instruments.TypePerp,
+		InitialMarginBps: 1000, MaintenanceMarginBps: 500})
+	eng.Margin = margin.NewManager()
+	return eng
+}
+
+func TestMarginCheckedOnEntry(t *testing.T) {
+	eng := newMarginEngine()
+	eng.Ledger = ledger.New() // margined symbols do not use ledger balances
+	eng.Margin.Deposit("A", 10_000)
+	eng.Margin.Deposit("MM", 10_000_000)
+
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideSell, common.OrderTypeLimit, 10000, 20))
+	if _, _, err := eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideBuy, common.OrderTypeLimit, 10000, 11)); err != engine.ErrInsufficientMargin {
+		t.Fatalf("expected ErrInsufficientMargin, got %v", err)
+	}
+	if _, trades, err := eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideBuy, common.OrderTypeLimit, 10000, 10)); err != nil || len(trades) != 1 {
+		t.Fatalf("expected a fill within margin, got %v %v", trades, err)
+	}
+
+	acc := eng.MarginStatus("A")
+	if acc.Equity != 10_000 || acc.InitialMargin != 10_000 || acc.MaintenanceMargin != 5_000 || acc.Available != 0 {
+		t.Fatalf("unexpected margin state %+v", acc)
+	}
+	// Reducing orders pass even with no margin to spare.
+	if _, _, err := eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideSell, common.OrderTypeLimit, 12000, 5)); err != nil {
+		t.Fatalf("reducing order should pass, got %v", err)
+	}
+	if err := eng.WithdrawMargin("A", 1); err != engine.ErrInsufficientMargin {
+		t.Fatalf("expected withdrawal to be refused, got %v", err)
+	}
+}
+
+func TestLiquidationCoversShortfallFromInsuranceFund(t *testing.T) {
+	eng := newMarginEngine()
+	eng.Margin.Deposit("A", 10_000)
+	eng.Margin.Deposit("MM", 10_000_000)
+	eng.Margin.FundInsurance(100_000)
+
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideSell, common.OrderTypeLimit, 10000, 10))
+	eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	resting, _, _ := eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideSell, common.OrderTypeLimit, 12000, 1))
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideBuy, common.OrderTypeLimit, 9000, 5))
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideBuy, common.OrderTypeLimit, 8000, 5))
+
+	var liquidations []*engine.Liquidation
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventLiquidation {
+			liquidations = append(liquidations, ev.Liquidation)
+		}
+	})
+
+	// Above maintenance: equity 10,000 - 10 x 300 = 7,000 against 4,850.
+	eng.SetMarkPrice("BTC-PERP", 9700)
+	if len(eng.Liquidations()) != 0 {
+		t.Fatalf("account above maintenance must not be liquidated")
+	}
+
+	// Equity 2,000 against maintenance 4,600: the long is sold into the bids
+	// at 9000 and 8000, realizing -15,000.
+	eng.SetMarkPrice("BTC-PERP", 9200)
+	if len(liquidations) != 1 {
+		t.Fatalf("expected one liquidation, got %d", len(liquidations))
+	}
+	l := liquidations[0]
+	if l.Account != "A" || l.Equity != 2_000 || l.MaintenanceMargin != 4_600 {
+		t.Fatalf("unexpected liquidation %+v", l)
+	}
+	if len(l.Cancelled) != 1 || l.Cancelled[0] != resting.ID || len(l.Orders) != 1 {
+		t.Fatalf("expected the resting order cancelled and one liquidation order: %+v", l)
+	}
+	if o, _ := eng.GetOrder(l.Orders[0]); !o.Liquidation || o.FilledQty != 10 {
+		t.Fatalf("unexpected liquidation order %+v", o)
+	}
+	if p, _ := eng.Positions.Get("A", "BTC-PERP"); p.Quantity != 0 || p.RealizedPnL != -15_000 {
+		t.Fatalf("position should be closed: %+v", p)
+	}
+
+	if l.Shortfall != 5_000 {
+		t.Fatalf("expected a 5,000 shortfall, got %d", l.Shortfall)
+	}
+	if acc := eng.MarginStatus("A"); acc.Equity != 0 {
+		t.Fatalf("insurance fund should restore equity to zero: %+v", acc)
+	}
+	if balance, entries := eng.Margin.Insurance(); balance != 95_000 || len(entries) != 2 || entries[1].Account != "A" {
+		t.Fatalf("unexpected insurance fund %d %+v", balance, entries)
+	}
+	if got := eng.CheckLiquidations(); len(got) != 0 {
+		t.Fatalf("flat account must not be liquidated again: %+v", got)
+	}
+}
+
+func TestLiquidationRetriesLeaveNoRecordWithoutLiquidity(t *testing.T) {
+	eng := newMarginEngine()
+	eng.Margin.Deposit("A", 10_000)
+	eng.Margin.Deposit("MM", 10_000_000)
+
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideSell, common.OrderTypeLimit, 10000, 10))
+	eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+
+	events := 0
+	eng.Subscribe(func(ev engine.Event) {
+		if ev.Type == engine.EventLiquidation {
+			events++
+		}
+	})
+
+	// Underwater with no bids to sell into: every check is a no-op.
+	eng.SetMarkPrice("BTC-PERP", 9200)
+	for i := 0; i < 5; i++ {
+		eng.CheckLiquidations()
+	}
+	if events != 0 || len(eng.Liquidations()) != 0 {
+		t.Fatalf("expected no records while the book is empty, got %d events %+v", events, eng.Liquidations())
+	}
+
+	// Once a bid arrives the position is closed and recorded once.
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideBuy, common.OrderTypeLimit, 9200, 10))
+	eng.CheckLiquidations()
+	if events != 1 || len(eng.Liquidations()) != 1 {
+		t.Fatalf("expected one liquidation, got %d events", events)
+	}
+	if p, _ := eng.Positions.Get("A", "BTC-PERP"); p.Quantity != 0 {
+		t.Fatalf("position should be closed: %+v", p)
+	}
+}
+
+func TestLiquidationCancelsOrdersOnHaltedBook(t *testing.T) {
+	eng := newMarginEngine()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ETH-PERP", Type: instruments.TypePerp,
+		InitialMarginBps: 1000, MaintenanceMarginBps: 500})
+	eng.Margin.Deposit("A", 10_000)
+	eng.Margin.Deposit("MM", 10_000_000)
+
+	eng.PlaceOrder(symbolReq("BTC-PERP", "MM", common.SideSell, common.OrderTypeLimit, 10000, 9))
+	eng.PlaceOrder(symbolReq("BTC-PERP", "A", common.SideBuy, common.OrderTypeLimit, 10000, 9))
+	resting, _, err := eng.PlaceOrder(symbolReq("ETH-PERP", "A", common.SideBuy, common.OrderTypeLimit, 1000, 1))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	eng.SetTradingStatus("ETH-PERP", common.TradingStatusHalted, "frozen")
+
+	eng.SetMarkPrice("BTC-PERP", 9200)
+	ls := eng.Liquidations()
+	if len(ls) != 1 || len(ls[0].Cancelled) != 1 || ls[0].Cancelled[0] != resting.ID {
+		t.Fatalf("expected the halted order cancelled, got %+v", ls)
+	}
+	if resting.Status != common.OrderStatusCancelled {
+		t.Fatalf("halted order still %s", resting.Status)
+	}
+}
@@ -0,0 +1,74 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+// askLadder rests 100 at each of the given ask prices.
+func askLadder(eng *engine.MatchingEngine, prices ...int64) {
+	for _, p := range prices {
+		eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, p, 100))
+	}
+}
+
+func marketReq(mode common.MarketMode, protection, qty int64) *common.Order {
+	req := newReq("AAPL", common.SideBuy, common.OrderTypeMarket, 0, qty)
+	req.MarketMode = mode
+	req.ProtectionPrice = protection
+	return req
+}
+
+func TestMarketOrderProtectionPrice(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	askLadder(eng, 10000, 10100, 10500)
+
+	// Fill-or-kill counts only liquidity inside the protection price.
+	if _, _, err := eng.PlaceOrder(marketReq("", 10100, 250)); err != engine.ErrInsufficientLiquidity {
+		t.Fatalf("expected reject, got %v", err)
+	}
+
+	o, trades, err := eng.PlaceOrder(marketReq(common.MarketModeFillAndKill, 10100, 250))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if len(trades) != 2 || o.FilledQty != 200 || o.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected fills up to the protection price: %+v", o)
+	}
+	if o.MarketOutcome != common.MarketOutcomeProtectionReached {
+		t.Fatalf("unexpected outcome %s", o.MarketOutcome)
+	}
+
+	o, _, _ = eng.PlaceOrder(marketReq(common.MarketModeFillAndKill, 0, 250))
+	if o.FilledQty != 100 || o.MarketOutcome != common.MarketOutcomeLiquidityExhausted {
+		t.Fatalf("expected the book to be swept: %+v", o)
+	}
+}
+
+func TestMarketToLimit(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	askLadder(eng, 10000, 10100)
+
+	o, trades, err := eng.PlaceOrder(marketReq(common.MarketModeMarketToLimit, 0, 150))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Price != 10000 {
+		t.Fatalf("market-to-limit must only trade at the best price: %+v", trades)
+	}
+	if o.Type != common.OrderTypeLimit || o.Price != 10000 || o.Status != common.OrderStatusPartial ||
+		o.MarketOutcome != common.MarketOutcomeConverted {
+		t.Fatalf("expected remainder resting as a limit order: %+v", o)
+	}
+
+	book, _ := eng.GetOrderBook("AAPL")
+	if bid, _ := book.Bids.BestPrice(); bid != 10000 || book.Bids.TotalQuantity != 50 {
+		t.Fatalf("unexpected bid side: %d x %d", bid, book.Bids.TotalQuantity)
+	}
+
+	if _, _, err := eng.PlaceOrder(marketReq("NOT_A_MODE", 0, 1)); err != engine.ErrInvalidOrderData {
+		t.Fatalf("expected invalid mode reject, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func pegReq(account string, side common.Side, ref common.PegReference, offset, limit, qty int64) *common.Order {
+	req := accountReq(account, side, common.OrderTypePeg, limit, qty)
+	req.PegReference = ref
+	req.PegOffset = offset
+	return req
+}
+
+func TestPrimaryPegFollowsBestBid(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 10100, 10))
+
+	peg, _, err := eng.PlaceOrder(pegReq("A", common.SideBuy, common.PegPrimary, 0, 10000, 5))
+	if err != nil || peg.Price != 9900 || peg.PegLimit != 10000 {
+		t.Fatalf("expected peg at 9900 capped at 10000: %v %+v", err, peg)
+	}
+
+	b2, _, _ := eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9950, 10))
+	if peg.Price != 9950 {
+		t.Fatalf("peg should follow the best bid to 9950, got %d", peg.Price)
+	}
+	book, _ := eng.GetOrderBook("AAPL")
+	level := book.Bids.Levels[9950]
+	if len(level.Orders) != 2 || level.Orders[0] != b2 || level.Orders[1] != peg {
+		t.Fatalf("repriced peg must join the back of the new level")
+	}
+	if len(book.Bids.Levels[9900].Orders) != 1 || book.Bids.TotalQuantity != 25 {
+		t.Fatalf("peg must leave its old level: total %d", book.Bids.TotalQuantity)
+	}
+
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 10050, 1))
+	if peg.Price != 10000 {
+		t.Fatalf("peg must not price beyond its limit, got %d", peg.Price)
+	}
+
+	eng.CancelOrder(b2.ID)
+	if peg.Price != 10000 {
+		t.Fatalf("peg should stay at its limit below the 10050 bid, got %d", peg.Price)
+	}
+}
+
+func TestMidpointPegRoundsHalfTicks(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	eng.PlaceOrder(accountReq("B", common.SideBuy, common.OrderTypeLimit, 9900, 10))
+	ask, _, _ := eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 9905, 10))
+
+	buy, _, _ := eng.PlaceOrder(pegReq("A", common.SideBuy, common.PegMidpoint, 0, 20000, 1))
+	sell, _, _ := eng.PlaceOrder(pegReq("A", common.SideSell, common.PegMidpoint, 0, 1, 1))
+	if buy.Price != 9902 || sell.Price != 9903 {
+		t.Fatalf("expected half-tick midpoint 9902.5 to round to 9902/9903, got %d/%d", buy.Price, sell.Price)
+	}
+
+	eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 9902, 1))
+	if buy.Status != common.OrderStatusFilled {
+		t.Fatalf("an ask at 9902 should have traded with the buy peg, got %s", buy.Status)
+	}
+	eng.CancelOrder(ask.ID)
+	eng.PlaceOrder(accountReq("C", common.SideSell, common.OrderTypeLimit, 9920, 10))
+	if sell.Price != 9910 {
+		t.Fatalf("expected sell peg repriced to the new midpoint 9910, got %d", sell.Price)
+	}
+}
+
+func TestPegWithoutReferenceRejected(t *testing.T) {
+	eng := newFundedEngine("A")
+	_, _, err := eng.PlaceOrder(pegReq("A", common.SideBuy, common.PegMidpoint, 0, 10000, 1))
+	if err != engine.ErrNoPegReference {
+		t.Fatalf("expected ErrNoPegReference, got %v", err)
+	}
+	req := pegReq("A", common.SideBuy, "BEST", 0, 10000, 1)
+	if _, _, err := eng.PlaceOrder(req); err != engine.ErrInvalidOrderData {
+		t.Fatalf("expected ErrInvalidOrderData, got %v", err)
+	}
+}
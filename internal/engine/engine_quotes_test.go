@@ -0,0 +1,98 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestMassQuoteReplacesQuoteSet(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	manual, _, _ := eng.PlaceOrder(accountReq("MM", common.SideBuy, common.OrderTypeLimit, 9000, 10))
+
+	res, err := eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "a-bid", Symbol: "AAPL", Side: common.SideBuy, Price: 9900, Quantity: 10},
+		{QuoteID: "a-ask", Symbol: "AAPL", Side: common.SideSell, Price: 10100, Quantity: 10},
+		{QuoteID: "m-bid", Symbol: "MSFT", Side: common.SideBuy, Price: 30000, Quantity: 5},
+		{QuoteID: "a-ask", Symbol: "AAPL", Side: common.SideSell, Price: 10200, Quantity: 10},
+		{QuoteID: "bad", Symbol: "AAPL", Side: "HOLD", Price: 10000, Quantity: 10},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if len(res.Cancelled) != 0 || len(res.Acks) != 5 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for i, want := range []error{nil, nil, nil, engine.ErrDuplicateQuoteID, engine.ErrInvalidOrderData} {
+		if res.Acks[i].Err != want {
+			t.Fatalf("ack %d: expected %v, got %v", i, want, res.Acks[i].Err)
+		}
+	}
+	first := res.Acks[0].Order
+	if first.QuoteID != "a-bid" || first.Status != common.OrderStatusAccepted {
+		t.Fatalf("quote should rest as a normal order: %+v", first)
+	}
+
+	// A taker trades against a quote like any other order.
+	_, trades, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 9900, 4))
+	if len(trades) != 1 || trades[0].BuyOrder != first.ID {
+		t.Fatalf("expected trade against the quote, got %+v", trades)
+	}
+
+	res, _ = eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "a-bid", Symbol: "AAPL", Side: common.SideBuy, Price: 9950, Quantity: 10},
+	}})
+	if len(res.Cancelled) != 3 || first.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected the previous set withdrawn, got %v", res.Cancelled)
+	}
+	if manual.Status != common.OrderStatusAccepted {
+		t.Fatalf("non-quote orders must survive a quote replace")
+	}
+
+	res, _ = eng.MassQuote(engine.MassQuoteRequest{Account: "MM"})
+	if len(res.Cancelled) != 1 || eng.OrdersInBook() != 1 {
+		t.Fatalf("empty quote set should withdraw all quotes")
+	}
+}
+
+func TestMassQuoteWithdrawsQuotesOnHaltedBook(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	res, _ := eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "a-bid", Symbol: "AAPL", Side: common.SideBuy, Price: 9900, Quantity: 10},
+	}})
+	old := res.Acks[0].Order
+	eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "frozen")
+
+	res, _ = eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "m-bid", Symbol: "MSFT", Side: common.SideBuy, Price: 30000, Quantity: 5},
+	}})
+	if len(res.Cancelled) != 1 || old.Status != common.OrderStatusCancelled {
+		t.Fatalf("expected the halted quote withdrawn, got %v (%s)", res.Cancelled, old.Status)
+	}
+
+	eng.SetTradingStatus("AAPL", common.TradingStatusOpen, "resume")
+	if _, trades, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 9900, 10)); len(trades) != 0 {
+		t.Fatalf("replaced quote traded: %+v", trades)
+	}
+}
+
+func TestMassQuoteRejectsSelfCrossingSet(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "bid", Symbol: "AAPL", Side: common.SideBuy, Price: 9900, Quantity: 10},
+	}})
+
+	// A bid at the ask of the same symbol would trade with itself.
+	_, err := eng.MassQuote(engine.MassQuoteRequest{Account: "MM", Quotes: []engine.Quote{
+		{QuoteID: "bid", Symbol: "AAPL", Side: common.SideBuy, Price: 10000, Quantity: 10},
+		{QuoteID: "ask", Symbol: "AAPL", Side: common.SideSell, Price: 10000, Quantity: 10},
+		{QuoteID: "other", Symbol: "MSFT", Side: common.SideSell, Price: 9000, Quantity: 10},
+	}})
+	if err != engine.ErrCrossedQuotes {
+		t.Fatalf("expected ErrCrossedQuotes, got %v", err)
+	}
+	if eng.OrdersInBook() != 1 {
+		t.Fatalf("a rejected set must leave the previous quotes in place")
+	}
+}
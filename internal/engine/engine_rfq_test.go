@@ -0,0 +1,125 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/fees"
+)
+
+func TestRFQAcceptedQuoteTrades(t *testing.T) {
+	eng := newFundedEngine("REQ", "D1", "D2")
+	eng.Fees.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: 0, TakerBps: 10}}})
+	eng.RegisterDealer("D1")
+	eng.RegisterDealer("D2")
+
+	var trades []*common.Trade
+	var notices []*engine.RFQNotice
+	eng.Subscribe(func(ev engine.Event) {
+		switch ev.Type {
+		case engine.EventTrade:
+			trades = append(trades, ev.Trade)
+		case engine.EventRFQ:
+			notices = append(notices, ev.RFQ)
+		}
+	})
+
+	rfq, err := eng.RequestQuote(engine.RFQRequest{Account: "REQ", Symbol: "AAPL", Side: common.SideBuy, Quantity: 100})
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, err := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "OUTSIDER", Price: 10000}); err != engine.ErrNotDealer {
+		t.Fatalf("expected ErrNotDealer, got %v", err)
+	}
+	q1, err := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D1", Price: 10100})
+	if err != nil || q1.Quantity != 100 {
+		t.Fatalf("unexpected quote %+v: %v", q1, err)
+	}
+	q2, _ := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D2", Price: 10050})
+	// A dealer's new quote replaces its old one.
+	q1b, _ := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D1", Price: 10040})
+	if got, _ := eng.GetRFQ(rfq.ID, "REQ"); len(got.Quotes) != 2 {
+		t.Fatalf("expected 2 live quotes, got %+v", got.Quotes)
+	}
+	// A dealer sees only its own quote and not the requester.
+	if got, ok := eng.GetRFQ(rfq.ID, "D2"); !ok || got.Account != "" || len(got.Quotes) != 1 || got.Quotes[0].QuoteID != q2.QuoteID {
+		t.Fatalf("unexpected dealer view %+v", got)
+	}
+	if _, ok := eng.GetRFQ(rfq.ID, "OUTSIDER"); ok {
+		t.Fatalf("other accounts must not see the RFQ")
+	}
+	if _, err := eng.AcceptRFQQuote(rfq.ID, "REQ", q1.QuoteID); err != engine.ErrRFQQuoteNotFound {
+		t.Fatalf("replaced quote must not be accepted, got %v", err)
+	}
+	if _, err := eng.AcceptRFQQuote(rfq.ID, "D2", q2.QuoteID); err != engine.ErrRFQNotFound {
+		t.Fatalf("only the requester may accept, got %v", err)
+	}
+
+	trade, err := eng.AcceptRFQQuote(rfq.ID, "REQ", q1b.QuoteID)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if trade.Price != 10040 || trade.Quantity != 100 || trade.BuyAccount != "REQ" || trade.SellAccount != "D1" {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+	if trade.TakerSide != common.SideBuy || trade.BuyFee != 1004 || trade.SellFee != 0 {
+		t.Fatalf("requester should pay the taker fee: %+v", trade)
+	}
+	if len(trades) != 1 || trades[0] != trade {
+		t.Fatalf("accepted quote should be published as a trade")
+	}
+	if got := eng.Ledger.Balance("REQ", "AAPL").Available; got != 10_100 {
+		t.Fatalf("requester should receive 100 AAPL, has %d", got)
+	}
+	if got := eng.Ledger.Balance("D1", "USD").Available; got != 100_000_000+1_004_000 {
+		t.Fatalf("dealer should receive proceeds, has %d", got)
+	}
+	if buy, _ := eng.GetOrder(trade.BuyOrder); buy.Status != common.OrderStatusFilled {
+		t.Fatalf("expected a filled order for the requester")
+	}
+	if _, ok := eng.GetRFQ(rfq.ID, "REQ"); ok {
+		t.Fatalf("filled RFQ should be closed")
+	}
+	if last := notices[len(notices)-1]; last.State != engine.RFQStateFilled {
+		t.Fatalf("expected FILLED notice, got %+v", last)
+	}
+	if book, _ := eng.GetOrderBook("AAPL"); book.LastPrice != 0 {
+		t.Fatalf("RFQ trades must not touch the book")
+	}
+}
+
+func TestRFQQuotesAndRequestsExpire(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.RegisterDealer("D1")
+	eng.RegisterDealer("D2")
+
+	rfq, _ := eng.RequestQuote(engine.RFQRequest{Account: "REQ", Symbol: "AAPL", Side: common.SideSell,
+		Quantity: 50, TTL: 80 * time.Millisecond})
+	short, _ := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D1", Price: 9900, TTL: 10 * time.Millisecond})
+	eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D2", Price: 9800, Quantity: 20})
+
+	time.Sleep(30 * time.Millisecond)
+	got, ok := eng.GetRFQ(rfq.ID, "REQ")
+	if !ok || len(got.Quotes) != 1 || got.Quotes[0].Dealer != "D2" {
+		t.Fatalf("expected the short-lived quote to expire: %+v", got)
+	}
+	if _, err := eng.AcceptRFQQuote(rfq.ID, "REQ", short.QuoteID); err != engine.ErrRFQQuoteNotFound {
+		t.Fatalf("expected ErrRFQQuoteNotFound, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := eng.GetRFQ(rfq.ID, "REQ"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("RFQ did not expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := eng.QuoteRFQ(engine.RFQQuoteRequest{RFQID: rfq.ID, Dealer: "D1", Price: 9900}); err != engine.ErrRFQNotFound {
+		t.Fatalf("expired RFQ should not take quotes, got %v", err)
+	}
+}
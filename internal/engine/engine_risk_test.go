@@ -0,0 +1,63 @@
+package engine_test
+
+import (
+	"errors"
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/risk"
+)
+
+func TestRiskRejectsBeforeMatching(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Risk.SetSymbolLimits("AAPL", risk.Limits{MaxOrderQty: 100})
+
+	eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 10000, 100))
+
+	_, trades, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 101))
+	var rej *risk.RejectError
+	if !errors.As(err, &rej) || rej.Code != risk.RejectMaxOrderQty {
+		t.Fatalf("expected MAX_ORDER_QTY reject, got %v", err)
+	}
+	if len(trades) != 0 {
+		t.Fatalf("rejected order must not trade")
+	}
+
+	book, _ := eng.GetOrderBook("AAPL")
+	if book.Asks.TotalQuantity != 100 {
+		t.Fatalf("resting liquidity changed: %d", book.Asks.TotalQuantity)
+	}
+}
+
+func TestRiskOpenOrdersAndPosition(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+	eng.Risk.SetAccountLimits("MM1", risk.Limits{MaxOpenOrders: 2, MaxPosition: 150})
+
+	place := func(side common.Side, price, qty int64) (*common.Order, error) {
+		req := newReq("AAPL", side, common.OrderTypeLimit, price, qty)
+		req.Account = "MM1"
+		o, _, err := eng.PlaceOrder(req)
+		return o, err
+	}
+
+	first, _ := place(common.SideSell, 10100, 10)
+	if _, err := place(common.SideSell, 10200, 10); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, err := place(common.SideSell, 10300, 10); err == nil {
+		t.Fatalf("expected MAX_OPEN_ORDERS reject")
+	}
+
+	// Cancelling frees a slot.
+	eng.CancelOrder(first.ID)
+	if _, err := place(common.SideSell, 10300, 10); err != nil {
+		t.Fatalf("unexpected after cancel: %v", err)
+	}
+
+	// Fills against the account update its position.
+	eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10300, 20))
+	if pos := eng.Risk.Position("MM1", "AAPL"); pos != -20 {
+		t.Fatalf("expected position -20, got %d", pos)
+	}
+}
@@ -0,0 +1,193 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/ledger"
+)
+
+// newSpreadEngine registers two futures and the calendar spread buying the
+// front month and selling the back month.
+func newSpreadEngine(implied bool) *engine.MatchingEngine {
+	eng := engine.NewMatchingEngine()
+	eng.Instruments = instruments.NewRegistry()
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ5"})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESH6"})
+	eng.Instruments.Upsert(instruments.Instrument{Symbol: "ESZ5-ESH6", ImpliedIn: implied, Legs: []instruments.Leg{
+		{Symbol: "ESZ5", Ratio: 1},
+		{Symbol: "ESH6", Ratio: -1},
+	}})
+	return eng
+}
+
+func symbolReq(symbol, account string, side common.Side, typ common.OrderType, price, qty int64) *common.Order {
+	req := newReq(symbol, side, typ, price, qty)
+	req.Account = account
+	return req
+}
+
+func TestStrategyDefinitionValidated(t *testing.T) {
+	reg := instruments.NewRegistry()
+	reg.Upsert(instruments.Instrument{Symbol: "A"})
+	for _, legs := range [][]instruments.Leg{
+		{{Symbol: "A", Ratio: 1}},
+		{{Symbol: "A", Ratio: 1}, {Symbol: "MISSING", Ratio: -1}},
+		{{Symbol: "A", Ratio: 1}, {Symbol: "A", Ratio: -1}},
+	} {
+		if _, err := reg.Upsert(instruments.Instrument{Symbol: "S", Legs: legs}); err != instruments.ErrInvalidInstrument {
+			t.Fatalf("legs %+v: expected ErrInvalidInstrument, got %v", legs, err)
+		}
+	}
+}
+
+func TestSpreadOrdersMatchDirectly(t *testing.T) {
+	eng := newSpreadEngine(false)
+
+	// Spread prices may be negative.
+	sell, _, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "A", common.SideSell, common.OrderTypeLimit, -25, 10))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	buy, trades, _ := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "B", common.SideBuy, common.OrderTypeLimit, -20, 4))
+	if len(trades) != 1 || trades[0].Price != -25 || trades[0].Quantity != 4 || buy.Status != common.OrderStatusFilled {
+		t.Fatalf("expected a direct spread trade at -25, got %+v", trades)
+	}
+	if sell.FilledQty != 4 {
+		t.Fatalf("expected resting spread order partly filled")
+	}
+
+	if _, _, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "B", common.SideBuy, common.OrderTypeMarket, 0, 1)); err != engine.ErrInvalidOrderData {
+		t.Fatalf("strategy orders must be limit orders, got %v", err)
+	}
+}
+
+func TestSpreadImpliedFromLegs(t *testing.T) {
+	eng := newSpreadEngine(true)
+	eng.PlaceOrder(symbolReq("ESZ5", "MM", common.SideSell, common.OrderTypeLimit, 5010, 6))
+	eng.PlaceOrder(symbolReq("ESH6", "MM", common.SideBuy, common.OrderTypeLimit, 5030, 10))
+
+	// Buying the spread buys ESZ5 at 5010 and sells ESH6 at 5030: -20.
+	bid, ask := eng.ImpliedTopOfBook("ESZ5-ESH6")
+	if bid != nil || ask == nil || ask.Price != -20 || ask.Quantity != 6 {
+		t.Fatalf("unexpected implied prices %+v %+v", bid, ask)
+	}
+
+	// A resting spread offer at -15 is worse than the implied -20.
+	eng.PlaceOrder(symbolReq("ESZ5-ESH6", "C", common.SideSell, common.OrderTypeLimit, -15, 5))
+
+	o, trades, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "T", common.SideBuy, common.OrderTypeLimit, -15, 8))
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if o.FilledQty != 8 || len(trades) != 3 {
+		t.Fatalf("expected 6 implied and 2 direct, got filled %d trades %+v", o.FilledQty, trades)
+	}
+	if trades[0].Price != 5010 || trades[0].Quantity != 6 || trades[1].Price != 5030 || trades[1].Quantity != 6 {
+		t.Fatalf("expected both legs filled for 6, got %+v %+v", trades[0], trades[1])
+	}
+	if trades[2].Price != -15 || trades[2].Quantity != 2 {
+		t.Fatalf("expected the remainder against the resting spread, got %+v", trades[2])
+	}
+	leg, _ := eng.GetOrder(trades[0].BuyOrder)
+	if leg.ParentOrderID != o.ID || leg.Account != "T" || leg.Status != common.OrderStatusFilled {
+		t.Fatalf("unexpected leg order %+v", leg)
+	}
+	if book, _ := eng.GetOrderBook("ESH6"); book.Bids.TotalQuantity != 4 {
+		t.Fatalf("expected 4 left on the ESH6 bid, got %d", book.Bids.TotalQuantity)
+	}
+}
+
+func TestSpreadImpliedIsAllOrNothingAcrossLegs(t *testing.T) {
+	eng := newSpreadEngine(true)
+	eng.PlaceOrder(symbolReq("ESZ5", "MM", common.SideSell, common.OrderTypeLimit, 5010, 6))
+
+	// The ESH6 bid is missing, so no leg trades.
+	o, trades, _ := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "T", common.SideBuy, common.OrderTypeLimit, 0, 5))
+	if len(trades) != 0 || o.Status != common.OrderStatusAccepted {
+		t.Fatalf("expected the spread order to rest untouched, got %+v", trades)
+	}
+	if book, _ := eng.GetOrderBook("ESZ5"); book.Asks.TotalQuantity != 6 {
+		t.Fatalf("leg book must be untouched")
+	}
+}
+
+func TestSpreadDirectTradeBelowZeroSettles(t *testing.T) {
+	eng := newSpreadEngine(false)
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("A", "ESZ5-ESH6", 10)
+	eng.Ledger.Deposit("A", "USD", 1_000)
+
+	// Selling at -25 may pay the buyer up to 250.
+	if _, _, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "A", common.SideSell, common.OrderTypeLimit, -25, 10)); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Reserved != 250 {
+		t.Fatalf("expected the worst-case debit reserved, got %+v", b)
+	}
+
+	// Buying at or below zero reserves nothing.
+	_, trades, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "B", common.SideBuy, common.OrderTypeLimit, -20, 4))
+	if err != nil || len(trades) != 1 || trades[0].Price != -25 {
+		t.Fatalf("expected a trade at -25, got %+v (%v)", trades, err)
+	}
+	if b := eng.Ledger.Balance("B", "USD"); b.Available != 100 || b.Reserved != 0 {
+		t.Fatalf("expected the buyer paid 100, got %+v", b)
+	}
+	if b := eng.Ledger.Balance("B", "ESZ5-ESH6"); b.Available != 4 {
+		t.Fatalf("expected 4 spread units, got %+v", b)
+	}
+	if b := eng.Ledger.Balance("A", "USD"); b.Available != 750 || b.Reserved != 150 {
+		t.Fatalf("unexpected seller USD %+v", b)
+	}
+	if len(eng.SettlementFailures()) != 0 {
+		t.Fatalf("unexpected settlement failures %+v", eng.SettlementFailures())
+	}
+
+	// An unfunded negative sell is rejected.
+	if _, _, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "C", common.SideSell, common.OrderTypeLimit, -25, 1)); err != ledger.ErrInsufficientFunds {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+}
+
+func TestSpreadImpliedReservesPerLeg(t *testing.T) {
+	eng := newSpreadEngine(true)
+	eng.Ledger = ledger.New()
+	eng.Ledger.Deposit("MM", "USD", 1_000_000)
+	eng.Ledger.Deposit("MM", "ESZ5", 6)
+	eng.Ledger.Deposit("T", "USD", 1_000_000)
+	eng.PlaceOrder(symbolReq("ESZ5", "MM", common.SideSell, common.OrderTypeLimit, 5010, 6))
+	eng.PlaceOrder(symbolReq("ESH6", "MM", common.SideBuy, common.OrderTypeLimit, 5030, 10))
+
+	// T holds no ESH6 to sell, so the implied fill cannot be funded and the
+	// spread order rests.
+	o, trades, err := eng.PlaceOrder(symbolReq("ESZ5-ESH6", "T", common.SideBuy, common.OrderTypeLimit, 0, 6))
+	if err != nil || len(trades) != 0 || o.Status != common.OrderStatusAccepted {
+		t.Fatalf("expected the spread order to rest, got %+v (%v)", trades, err)
+	}
+	if book, _ := eng.GetOrderBook("ESZ5"); book.Asks.TotalQuantity != 6 {
+		t.Fatalf("leg book must be untouched")
+	}
+	eng.CancelOrder(o.ID)
+
+	eng.Ledger.Deposit("T", "ESH6", 6)
+	o, trades, err = eng.PlaceOrder(symbolReq("ESZ5-ESH6", "T", common.SideBuy, common.OrderTypeLimit, 0, 6))
+	if err != nil || len(trades) != 2 || o.Status != common.OrderStatusFilled {
+		t.Fatalf("expected both legs to trade, got %+v (%v)", trades, err)
+	}
+	// Paid 6 x 5010 for ESZ5 and received 6 x 5030 for ESH6.
+	if b := eng.Ledger.Balance("T", "USD"); b.Available != 1_000_120 || b.Reserved != 0 {
+		t.Fatalf("unexpected USD %+v", b)
+	}
+	if b := eng.Ledger.Balance("T", "ESZ5"); b.Available != 6 {
+		t.Fatalf("unexpected ESZ5 %+v", b)
+	}
+	if b := eng.Ledger.Balance("T", "ESH6"); b.Available != 0 || b.Reserved != 0 {
+		t.Fatalf("unexpected ESH6 %+v", b)
+	}
+	if len(eng.SettlementFailures()) != 0 {
+		t.Fatalf("unexpected settlement failures %+v", eng.SettlementFailures())
+	}
+}
@@ -0,0 +1,65 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func TestTradingStatusEnforcement(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+
+	resting, _, _ := eng.PlaceOrder(newReq("AAPL", common.SideSell, common.OrderTypeLimit, 10000, 100))
+
+	if _, err := eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "incident 42"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 100)); err != engine.ErrSymbolNotOpen {
+		t.Fatalf("expected halted symbol to reject orders, got %v", err)
+	}
+	if err := eng.CancelOrder(resting.ID); err != engine.ErrCancelNotAllowed {
+		t.Fatalf("expected halted book to be frozen, got %v", err)
+	}
+
+	eng.SetTradingStatus("AAPL", common.TradingStatusCancelOnly, "unwinding")
+	if _, _, err := eng.PlaceOrder(newReq("AAPL", common.SideBuy, common.OrderTypeLimit, 10000, 100)); err != engine.ErrSymbolNotOpen {
+		t.Fatalf("expected cancel-only symbol to reject orders, got %v", err)
+	}
+	if err := eng.CancelOrder(resting.ID); err != nil {
+		t.Fatalf("cancel should be allowed in CANCEL_ONLY: %v", err)
+	}
+
+	// Other symbols keep trading.
+	if _, _, err := eng.PlaceOrder(newReq("MSFT", common.SideBuy, common.OrderTypeLimit, 10000, 100)); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+}
+
+func TestTradingStatusTransitionsAndAudit(t *testing.T) {
+	eng := engine.NewMatchingEngine()
+
+	var events []engine.Event
+	eng.Subscribe(func(ev engine.Event) { events = append(events, ev) })
+
+	if _, err := eng.SetTradingStatus("AAPL", common.TradingStatusPreOpen, "bad"); err != engine.ErrInvalidTransition {
+		t.Fatalf("OPEN -> PRE_OPEN should be rejected, got %v", err)
+	}
+	if _, err := eng.SetTradingStatus("AAPL", "PAUSED", "bad"); err != engine.ErrInvalidTradingState {
+		t.Fatalf("expected invalid state, got %v", err)
+	}
+
+	eng.SetTradingStatus("AAPL", common.TradingStatusHalted, "news pending")
+	eng.SetTradingStatus("AAPL", common.TradingStatusOpen, "news out")
+
+	history := eng.StatusHistory("AAPL")
+	if len(history) != 2 || history[0].Reason != "news pending" || history[1].From != common.TradingStatusHalted {
+		t.Fatalf("unexpected audit trail: %+v", history)
+	}
+	if len(events) != 2 || events[0].Type != engine.EventStatusChange || events[0].Status.To != common.TradingStatusHalted {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if s := eng.TradingStatus("AAPL"); s != common.TradingStatusOpen {
+		t.Fatalf("expected OPEN, got %s", s)
+	}
+}
@@ -0,0 +1,94 @@
+package engine_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/engine"
+)
+
+func trailReq(account string, side common.Side, offset, bps, qty int64) *common.Order {
+	req := accountReq(account, side, common.OrderTypeTrailingStop, 0, qty)
+	req.TrailOffset = offset
+	req.TrailBps = bps
+	return req
+}
+
+// trade prints one lot between B and C at price.
+func trade(eng *engine.MatchingEngine, price int64) {
+	eng.PlaceOrder(accountReq("B", common.SideSell, common.OrderTypeLimit, price, 1))
+	eng.PlaceOrder(accountReq("C", common.SideBuy, common.OrderTypeLimit, price, 1))
+}
+
+func TestTrailingStopFollowsTradesAndTriggers(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	trade(eng, 10000)
+
+	stop, _, err := eng.PlaceOrder(trailReq("A", common.SideSell, 300, 0, 5))
+	if err != nil || stop.StopPrice != 9700 {
+		t.Fatalf("expected initial trigger 9700, got %v / %d", err, stop.StopPrice)
+	}
+
+	trade(eng, 10500)
+	if stop.StopPrice != 10200 {
+		t.Fatalf("trigger should follow the market up, got %d", stop.StopPrice)
+	}
+	trade(eng, 10300)
+	if stop.StopPrice != 10200 || stop.Status != common.OrderStatusPending {
+		t.Fatalf("a sell trigger must never move down: %d %s", stop.StopPrice, stop.Status)
+	}
+
+	eng.PlaceOrder(accountReq("C", common.SideBuy, common.OrderTypeLimit, 10000, 10))
+	trade(eng, 10200)
+
+	got, _ := eng.GetOrder(stop.ID)
+	if !got.Triggered || got.Type != common.OrderTypeMarket || got.StopPrice != 10200 {
+		t.Fatalf("expected a triggered market order at 10200: %+v", got)
+	}
+	if got.Status != common.OrderStatusFilled || got.FilledQty != 5 {
+		t.Fatalf("expected stop filled, got %s %d", got.Status, got.FilledQty)
+	}
+}
+
+func TestTrailingStopPercentWithLimit(t *testing.T) {
+	eng := newFundedEngine("A", "B", "C")
+	trade(eng, 10000)
+
+	req := trailReq("A", common.SideBuy, 0, 200, 5) // 2%
+	req.LimitOffset = 50
+	stop, _, _ := eng.PlaceOrder(req)
+	if stop.StopPrice != 10200 {
+		t.Fatalf("expected initial trigger 10200, got %d", stop.StopPrice)
+	}
+
+	trade(eng, 9000)
+	if stop.StopPrice != 9180 {
+		t.Fatalf("trigger should follow the market down, got %d", stop.StopPrice)
+	}
+
+	trade(eng, 9200)
+	if !stop.Triggered || stop.Type != common.OrderTypeLimit || stop.Price != 9230 {
+		t.Fatalf("expected a limit order at 9230: %+v", stop)
+	}
+	if stop.Status != common.OrderStatusAccepted {
+		t.Fatalf("unfilled triggered limit should rest, got %s", stop.Status)
+	}
+}
+
+func TestTrailingStopValidation(t *testing.T) {
+	eng := newFundedEngine("A")
+	for name, req := range map[string]*common.Order{
+		"no trail":   trailReq("A", common.SideSell, 0, 0, 1),
+		"both trail": trailReq("A", common.SideSell, 100, 100, 1),
+		"full bps":   trailReq("A", common.SideSell, 0, 10_000, 1),
+	} {
+		if _, _, err := eng.PlaceOrder(req); err != engine.ErrInvalidOrderData {
+			t.Errorf("%s: expected ErrInvalidOrderData, got %v", name, err)
+		}
+	}
+	req := trailReq("A", common.SideSell, 100, 0, 1)
+	req.StopPrice = 9000
+	if _, _, err := eng.PlaceOrder(req); err != engine.ErrInvalidOrderData {
+		t.Errorf("client-set trigger: expected ErrInvalidOrderData, got %v", err)
+	}
+}
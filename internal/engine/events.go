@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/positions"
+)
+
+// EventType identifies an engine event delivered to listeners.
+type EventType string
+
+const (
+	EventTrade        EventType = "trade"
+	EventStatusChange EventType = "status"
+	EventAuction      EventType = "auction"
+	EventCancel       EventType = "cancel"
+	EventRFQ          EventType = "rfq"
+	EventPosition     EventType = "position"
+	EventLiquidation  EventType = "liquidation"
+)
+
+// Event is published by the engine after a state change. Exactly one of the
+// payload fields is set, matching Type.
+type Event struct {
+	Type        EventType
+	Symbol      string
+	Trade       *common.Trade
+	Status      *StatusChange
+	Auction     *AuctionInfo
+	Cancel      *Cancellation
+	RFQ         *RFQNotice
+	Position    *positions.Position // private to Position.Account
+	Liquidation *Liquidation        // private to Liquidation.Account
+}
+
+// Subscribe registers a listener for engine events. Listeners run
+// synchronously while the engine lock is held, so they must be fast and must
+// not call back into the engine.
+func (m *MatchingEngine) Subscribe(fn func(Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// emit delivers an event to all listeners. The caller holds m.mu.
+func (m *MatchingEngine) emit(ev Event) {
+	for _, fn := range m.listeners {
+		fn(ev)
+	}
+}
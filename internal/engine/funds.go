@@ -0,0 +1,315 @@
+package engine
+
+import (
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/fees"
+	"order-matching-engine/internal/ledger"
+	"order-matching-engine/internal/matching"
+	"order-matching-engine/internal/orderbook"
+)
+
+// DefaultQuoteAsset is the settlement currency of symbols without an
+// instrument definition. Prices are quoted in its minor unit (cents).
+const DefaultQuoteAsset = "USD"
+
+// reservation is the amount still locked for a live order. BUY orders also
+// lock fee headroom on top of the principal. The order pays the fee rates
+// in force at entry, which the headroom was sized at, so a schedule or
+// tier change while it rests cannot outgrow the reservation.
+type reservation struct {
+	asset    string
+	amount   int64 // principal
+	fees     int64 // fee headroom, quote asset
+	makerBps int64
+	takerBps int64
+
+	// A strategy SELL below zero pays the buyer; owed is what it may pay,
+	// fee included, in quote.
+	quote string
+	owed  int64
+}
+
+// SettlementFailure is a trade the ledger refused to settle. Reservations
+// and fee limits make this impossible unless the ledger is changed behind
+// the engine's back. The trade stands and the funds its orders reserved for
+// it stay reserved, never released, for an operator to reconcile.
+type SettlementFailure struct {
+	TradeID     string `json:"trade_id"`
+	Symbol      string `json:"symbol"`
+	BuyAccount  string `json:"buy_account"`
+	SellAccount string `json:"sell_account"`
+	BaseAsset   string `json:"base_asset"`
+	QuoteAsset  string `json:"quote_asset"`
+
+	// Held is what stays reserved for the trade: the buyer's principal and
+	// fee headroom in quote, the seller's quantity in base and, for a
+	// strategy sold below zero, what the seller owes in quote.
+	BuyHeld       int64 `json:"buy_held"`
+	SellHeld      int64 `json:"sell_held"`
+	SellHeldQuote int64 `json:"sell_held_quote,omitempty"`
+
+	Error     string `json:"error"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// SettlementFailures returns the trades that failed to settle, oldest
+// first.
+func (m *MatchingEngine) SettlementFailures() []SettlementFailure {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]SettlementFailure(nil), m.settlementFailures...)
+}
+
+// assetsFor returns the base and quote asset traded on a symbol.
+func (m *MatchingEngine) assetsFor(symbol string) (base, quote string) {
+	if m.Instruments != nil {
+		if inst, ok := m.Instruments.Get(symbol); ok {
+			return inst.BaseAsset, inst.QuoteAsset
+		}
+	}
+	return symbol, DefaultQuoteAsset
+}
+
+// reserveFunds locks the funds backing a new order: quote currency plus the
+// maximum fee for BUY orders and base quantity for SELL orders. Market buys
+// reserve the exact cost of walking the book. Quote amounts are scaled by
+// the contract multiplier. Strategy orders reserve their worst-case net
+// debit: a buy at or below zero never pays, and a sell below zero also
+// locks what it may pay. Margined symbols are backed by margin collateral
+// instead and reserve nothing. The caller holds m.mu.
+func (m *MatchingEngine) reserveFunds(book *orderbook.OrderBook, o *common.Order) error {
+	if m.Ledger == nil || m.margined(o.Symbol) {
+		return nil
+	}
+	if o.Account == "" {
+		return ErrAccountRequired
+	}
+
+	res := m.sizeReservation(book, o, o.Quantity)
+	if err := m.Ledger.Reserve(o.Account, res.asset, res.amount+res.fees); err != nil {
+		return err
+	}
+	if res.owed > 0 {
+		if err := m.Ledger.Reserve(o.Account, res.quote, res.owed); err != nil {
+			_ = m.Ledger.Release(o.Account, res.asset, res.amount+res.fees)
+			return err
+		}
+	}
+	m.reservations[o.ID] = res
+	return nil
+}
+
+// sizeReservation returns what reserveFunds locks for qty of an order.
+func (m *MatchingEngine) sizeReservation(book *orderbook.OrderBook, o *common.Order, qty int64) *reservation {
+	base, quote := m.assetsFor(o.Symbol)
+	res := &reservation{asset: base, amount: qty, quote: quote}
+	res.makerBps, res.takerBps = m.Fees.Rates(o.Account)
+	mult := m.multiplier(o.Symbol)
+	switch price := limitPrice(o); {
+	case o.Side == common.SideBuy:
+		res.asset = quote
+		switch {
+		case price > 0:
+			res.amount = price * qty
+		case o.Type == common.OrderTypeLimit:
+			res.amount = 0 // strategy limit at or below zero
+		default:
+			res.amount = marketCost(m.algorithmLocked(book.Symbol), book.Asks, qty, o.ProtectionPrice)
+		}
+		res.amount *= mult
+		res.fees = fees.MaxFeeAt(res.amount, res.makerBps, res.takerBps)
+	case price < 0:
+		res.owed = -price * qty * mult
+		res.owed += fees.MaxFeeAt(res.owed, res.makerBps, res.takerBps)
+	}
+	return res
+}
+
+// releaseUnitsLocked returns the share of an order's reservation backing
+// units that filled away from its own book, as a strategy order's implied
+// fills do through its leg orders. The caller holds m.mu.
+func (m *MatchingEngine) releaseUnitsLocked(o *common.Order, units int64) {
+	res, ok := m.reservations[o.ID]
+	if !ok {
+		return
+	}
+	part := m.sizeReservation(nil, o, units)
+	part.amount = min(part.amount, res.amount)
+	part.fees = min(part.fees, res.fees)
+	part.owed = min(part.owed, res.owed)
+	res.amount -= part.amount
+	res.fees -= part.fees
+	res.owed -= part.owed
+	if left := part.amount + part.fees; left > 0 {
+		_ = m.Ledger.Release(o.Account, res.asset, left)
+	}
+	if part.owed > 0 {
+		_ = m.Ledger.Release(o.Account, res.quote, part.owed)
+	}
+}
+
+// chargeFees computes both sides' fees for a trade. The aggressor side pays
+// the taker rate; auction trades have no aggressor and both sides are makers.
+// Orders with a funds reservation pay the rates they were entered at.
+func (m *MatchingEngine) chargeFees(symbol string, t *common.Trade, buy, sell *common.Order) {
+	buyRole, sellRole := fees.RoleMaker, fees.RoleMaker
+	switch t.TakerSide {
+	case common.SideBuy:
+		buyRole = fees.RoleTaker
+	case common.SideSell:
+		sellRole = fees.RoleTaker
+	}
+	notional := m.notional(symbol, t.Price, t.Quantity)
+
+	t.BuyFee = m.chargeFee(t, buy, symbol, buyRole, notional)
+	t.SellFee = m.chargeFee(t, sell, symbol, sellRole, notional)
+	buy.Fees += t.BuyFee
+	sell.Fees += t.SellFee
+}
+
+func (m *MatchingEngine) chargeFee(t *common.Trade, o *common.Order, symbol string, role fees.Role, notional int64) int64 {
+	if res, ok := m.reservations[o.ID]; ok {
+		return m.Fees.ChargeAt(t.TradeID, o.Account, symbol, role, res.makerBps, res.takerBps, notional, t.Timestamp)
+	}
+	return m.Fees.Charge(t.TradeID, o.Account, symbol, role, notional, t.Timestamp)
+}
+
+// settleTrade moves funds between buyer, seller and the fee collector for
+// one fill, consuming their reservations. A limit buy reserved at its limit
+// price, so any price improvement is returned to the buyer. The seller's fee
+// comes out of the proceeds; the buyer's fee comes out of the reserved
+// headroom first and available funds second. The fee collector funds
+// rebates, so it may go below zero. The match has already happened, so a
+// trade the ledger refuses is recorded as a settlement failure rather than
+// undone, and what the orders reserved for it stays reserved.
+func (m *MatchingEngine) settleTrade(symbol string, t *common.Trade, buy, sell *common.Order) {
+	if m.Ledger == nil || m.margined(symbol) {
+		return
+	}
+	base, quote := m.assetsFor(symbol)
+
+	mult := m.multiplier(symbol)
+	cost := t.Price * t.Quantity * mult
+	principal := cost
+	if price := limitPrice(buy); price > 0 {
+		principal = price * t.Quantity * mult
+	} else if buy.Type == common.OrderTypeLimit {
+		principal = 0 // strategy limit at or below zero reserved nothing
+	}
+
+	buyRes := m.reservations[buy.ID]
+	feeFromReserve := int64(0)
+	if t.BuyFee > 0 && buyRes != nil {
+		feeFromReserve = t.BuyFee
+		if feeFromReserve > buyRes.fees {
+			feeFromReserve = buyRes.fees
+		}
+	}
+
+	// A seller below zero pays the buyer, and its fee, from what it owes.
+	sellRes := m.reservations[sell.ID]
+	owedFromReserve := int64(0)
+	if cost < 0 && sellRes != nil {
+		owedFromReserve = min(-cost+max(t.SellFee, 0), sellRes.owed)
+	}
+
+	if t.BuyFee+t.SellFee < 0 {
+		m.Ledger.AllowOverdraft(fees.CollectorAccount)
+	}
+	err := m.Ledger.Apply(
+		ledger.Posting{
+			Account:   buy.Account,
+			Asset:     quote,
+			Available: principal - cost - (t.BuyFee - feeFromReserve),
+			Reserved:  -principal - feeFromReserve,
+		},
+		ledger.Posting{Account: buy.Account, Asset: base, Available: t.Quantity},
+		ledger.Posting{Account: sell.Account, Asset: base, Reserved: -t.Quantity},
+		ledger.Posting{
+			Account:   sell.Account,
+			Asset:     quote,
+			Available: cost - t.SellFee + owedFromReserve,
+			Reserved:  -owedFromReserve,
+		},
+		ledger.Posting{Account: fees.CollectorAccount, Asset: quote, Available: t.BuyFee + t.SellFee},
+	)
+	if err != nil {
+		f := SettlementFailure{
+			TradeID:     t.TradeID,
+			Symbol:      symbol,
+			BuyAccount:  buy.Account,
+			SellAccount: sell.Account,
+			BaseAsset:   base,
+			QuoteAsset:  quote,
+			Error:       err.Error(),
+			Timestamp:   time.Now().UnixMilli(),
+		}
+		if buyRes != nil {
+			f.BuyHeld = principal + feeFromReserve
+		}
+		if sellRes != nil {
+			f.SellHeld, f.SellHeldQuote = t.Quantity, owedFromReserve
+		}
+		m.settlementFailures = append(m.settlementFailures, f)
+	}
+
+	// Either way the trade's share no longer backs the orders: settled, or
+	// held for the failure.
+	if buyRes != nil {
+		buyRes.amount -= principal
+		buyRes.fees -= feeFromReserve
+	}
+	if sellRes != nil {
+		sellRes.amount -= t.Quantity
+		sellRes.owed -= owedFromReserve
+	}
+}
+
+// limitPrice is the worst price an order may trade at, which its funds are
+// reserved at, or 0 for market orders. Pegged orders reserve at their
+// limit since their working price moves.
+func limitPrice(o *common.Order) int64 {
+	switch o.Type {
+	case common.OrderTypeLimit:
+		return o.Price
+	case common.OrderTypePeg:
+		return o.PegLimit
+	}
+	return 0
+}
+
+// releaseFunds returns whatever is still reserved for an order that has
+// left the book. The caller holds m.mu.
+func (m *MatchingEngine) releaseFunds(o *common.Order) {
+	res, ok := m.reservations[o.ID]
+	if !ok {
+		return
+	}
+	delete(m.reservations, o.ID)
+	if left := res.amount + res.fees; left > 0 {
+		_ = m.Ledger.Release(o.Account, res.asset, left)
+	}
+	if res.owed > 0 {
+		_ = m.Ledger.Release(o.Account, res.quote, res.owed)
+	}
+}
+
+// marketCost is the quote amount needed to take qty from the given side,
+// best price first, up to the protection price if one is set. Each level
+// yields what the matching algorithm would allocate from it, which leaves
+// out all-or-none orders too large to fill.
+func marketCost(alg matching.Algorithm, side *orderbook.SideBook, qty, protection int64) int64 {
+	cost := int64(0)
+	for _, price := range side.Prices {
+		if qty == 0 || (protection > 0 && price > protection) {
+			return cost
+		}
+		for _, f := range allocate(alg, side.Levels[price], qty) {
+			cost += f.Qty * price
+			qty -= f.Qty
+		}
+	}
+	return cost
+}
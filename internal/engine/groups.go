@@ -0,0 +1,475 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+var (
+	ErrGroupNotFound  = errors.New("order group not found")
+	ErrGroupFinalized = errors.New("order group already completed or cancelled")
+)
+
+// GroupType is the kind of link between the orders of a group.
+type GroupType string
+
+const (
+	// GroupOCO legs share one quantity: fills on any leg reduce the others,
+	// and once the quantity is done or a stop leg triggers, the rest are
+	// cancelled.
+	GroupOCO GroupType = "OCO"
+	// GroupBracket is a limit entry whose take-profit and stop-loss exits
+	// become an OCO pair once the entry has filled.
+	GroupBracket GroupType = "BRACKET"
+)
+
+// GroupState is the lifecycle of an order group.
+type GroupState string
+
+const (
+	GroupPending   GroupState = "PENDING" // bracket entry not yet filled
+	GroupActive    GroupState = "ACTIVE"
+	GroupCompleted GroupState = "COMPLETED"
+	GroupCancelled GroupState = "CANCELLED"
+)
+
+// OrderGroup links orders of one account and symbol.
+type OrderGroup struct {
+	ID        string     `json:"group_id"`
+	Type      GroupType  `json:"type"`
+	State     GroupState `json:"state"`
+	Account   string     `json:"account_id,omitempty"`
+	Symbol    string     `json:"symbol"`
+	Quantity  int64      `json:"quantity"` // quantity shared by the legs
+	EntryID   string     `json:"entry_order_id,omitempty"`
+	LegIDs    []string   `json:"leg_order_ids"`
+	Timestamp int64      `json:"timestamp"`
+
+	// TriggeredID is the stop leg that triggered; the group then follows
+	// that order alone.
+	TriggeredID string `json:"triggered_order_id,omitempty"`
+}
+
+// BracketRequest describes a bracket: a limit entry and the prices of its
+// two exits on the opposite side.
+type BracketRequest struct {
+	Entry         *common.Order
+	TakeProfit    int64 // limit price of the profit-taking exit
+	StopLoss      int64 // stop price of the protective exit
+	StopLossLimit int64 // limit price once the stop triggers; 0 exits at market
+}
+
+// PlaceOCO enters linked legs of the same account, symbol, side and
+// quantity. Legs are placed in order; a leg that fills reduces the legs
+// after it. If the book rejects a leg, the group is cancelled and the
+// error is returned together with the group, its orders and the trades
+// earlier legs already made, which stand.
+func (m *MatchingEngine) PlaceOCO(legs []*common.Order) (*OrderGroup, []*common.Order, []*common.Trade, error) {
+	if len(legs) < 2 {
+		return nil, nil, nil, ErrInvalidOrderData
+	}
+	for _, req := range legs {
+		if err := validateOrderRequest(req, m.Instruments); err != nil {
+			return nil, nil, nil, err
+		}
+		if req.Type == common.OrderTypeMarket || req.Type == common.OrderTypePeg || req.Dark || req.QuoteID != "" {
+			return nil, nil, nil, ErrInvalidOrderData
+		}
+		first := legs[0]
+		if req.Account != first.Account || req.Symbol != first.Symbol ||
+			req.Side != first.Side || req.Quantity != first.Quantity {
+			return nil, nil, nil, ErrInvalidOrderData
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, err := m.groupPrecheckLocked(legs[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	g := m.newGroupLocked(GroupOCO, legs[0])
+	g.State = GroupActive
+	orders := make([]*common.Order, len(legs))
+	for i, req := range legs {
+		orders[i] = m.createOrder(req)
+		orders[i].GroupID = g.ID
+		g.LegIDs = append(g.LegIDs, orders[i].ID)
+	}
+
+	var trades []*common.Trade
+	for _, o := range orders {
+		left := g.Quantity - m.groupFilledLocked(g)
+		if err != nil || g.State != GroupActive || g.TriggeredID != "" || left <= 0 {
+			o.Status = common.OrderStatusCancelled
+			m.orders[o.ID] = o
+			continue
+		}
+		if o.Quantity > left {
+			o.Quantity = left
+		}
+		var t []*common.Trade
+		if t, err = m.placeLocked(o); err != nil {
+			o.Status = common.OrderStatusCancelled
+			m.orders[o.ID] = o
+			m.cancelGroupLocked(book, g, CancelReasonRejected)
+			continue
+		}
+		trades = append(trades, t...)
+	}
+	return g.snapshot(), orders, trades, err
+}
+
+// PlaceBracket enters a limit entry order. Its exits wait as PENDING orders
+// and enter the book for the filled quantity once the entry has filled, or
+// once it is cancelled after a partial fill.
+func (m *MatchingEngine) PlaceBracket(req BracketRequest) (*OrderGroup, *common.Order, []*common.Trade, error) {
+	entry := req.Entry
+	if err := validateOrderRequest(entry, m.Instruments); err != nil {
+		return nil, nil, nil, err
+	}
+	if entry.Type != common.OrderTypeLimit || entry.Dark || entry.QuoteID != "" ||
+		req.TakeProfit <= 0 || req.StopLoss <= 0 || req.StopLossLimit < 0 {
+		return nil, nil, nil, ErrInvalidOrderData
+	}
+
+	exitSide := common.SideSell
+	if entry.Side == common.SideSell {
+		exitSide = common.SideBuy
+	}
+	if (exitSide == common.SideSell) != (req.TakeProfit > req.StopLoss) {
+		return nil, nil, nil, ErrInvalidOrderData
+	}
+
+	exit := func(typ common.OrderType) *common.Order {
+		return &common.Order{
+			Account:            entry.Account,
+			Symbol:             entry.Symbol,
+			Side:               exitSide,
+			Type:               typ,
+			Quantity:           entry.Quantity,
+			SessionID:          entry.SessionID,
+			CancelOnDisconnect: entry.CancelOnDisconnect,
+		}
+	}
+	takeProfit := exit(common.OrderTypeLimit)
+	takeProfit.Price = req.TakeProfit
+	stopLoss := exit(common.OrderTypeStop)
+	stopLoss.StopPrice = req.StopLoss
+	if req.StopLossLimit > 0 {
+		stopLoss.Type = common.OrderTypeStopLimit
+		stopLoss.Price = req.StopLossLimit
+	}
+	for _, o := range [2]*common.Order{takeProfit, stopLoss} {
+		if err := validateOrderRequest(o, m.Instruments); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.groupPrecheckLocked(entry); err != nil {
+		return nil, nil, nil, err
+	}
+
+	g := m.newGroupLocked(GroupBracket, entry)
+	parent := m.createOrder(entry)
+	parent.GroupID = g.ID
+	g.EntryID = parent.ID
+
+	children := [2]*common.Order{m.createOrder(takeProfit), m.createOrder(stopLoss)}
+	for _, o := range children {
+		o.GroupID = g.ID
+		o.Status = common.OrderStatusPending
+		m.orders[o.ID] = o
+		g.LegIDs = append(g.LegIDs, o.ID)
+	}
+
+	trades, err := m.placeLocked(parent)
+	if err != nil {
+		for _, o := range children {
+			delete(m.orders, o.ID)
+		}
+		delete(m.groups, g.ID)
+		return nil, nil, nil, err
+	}
+	return g.snapshot(), parent, trades, nil
+}
+
+// GetGroup returns a snapshot of an order group.
+func (m *MatchingEngine) GetGroup(id string) (*OrderGroup, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	g, ok := m.groups[id]
+	if !ok {
+		return nil, false
+	}
+	return g.snapshot(), true
+}
+
+// CancelGroup cancels every live order of a group. A bracket cancelled
+// before its entry filled never activates its exits.
+func (m *MatchingEngine) CancelGroup(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.groups[id]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	if g.State == GroupCompleted || g.State == GroupCancelled {
+		return ErrGroupFinalized
+	}
+	book, ok := m.books[g.Symbol]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	if !book.Status.AcceptsCancels() {
+		return ErrCancelNotAllowed
+	}
+
+	m.cancelGroupLocked(book, g, CancelReasonUser)
+	if book.Status.InCall() {
+		m.emitAuctionLocked(book)
+	}
+	m.processContingentLocked(book)
+	return nil
+}
+
+func (g *OrderGroup) snapshot() *OrderGroup {
+	cp := *g
+	cp.LegIDs = append([]string(nil), g.LegIDs...)
+	return &cp
+}
+
+// groupPrecheckLocked applies the order-entry checks that would otherwise
+// only fail part way through placing a group. The caller holds m.mu.
+func (m *MatchingEngine) groupPrecheckLocked(req *common.Order) (*orderbook.OrderBook, error) {
+	if m.killedLocked(req.Account) {
+		return nil, ErrKillSwitchActive
+	}
+	if req.SessionID != "" && !m.sessionActiveLocked(req.SessionID, req.Account) {
+		return nil, ErrUnknownSession
+	}
+	book := m.ensureBook(req.Symbol)
+	if !book.Status.AcceptsOrders() {
+		return nil, ErrSymbolNotOpen
+	}
+	return book, nil
+}
+
+func (m *MatchingEngine) newGroupLocked(typ GroupType, req *common.Order) *OrderGroup {
+	g := &OrderGroup{
+		ID:        uuid.NewString(),
+		Type:      typ,
+		State:     GroupPending,
+		Account:   req.Account,
+		Symbol:    req.Symbol,
+		Quantity:  req.Quantity,
+		LegIDs:    make([]string, 0, 2),
+		Timestamp: time.Now().UnixMilli(),
+	}
+	m.groups[g.ID] = g
+	return g
+}
+
+// touchGroupLocked queues the order's group for reconciliation. The caller
+// holds m.mu.
+func (m *MatchingEngine) touchGroupLocked(o *common.Order) {
+	if o.GroupID != "" {
+		m.dirtyGroups = append(m.dirtyGroups, o.GroupID)
+	}
+}
+
+// groupFilledLocked is the quantity filled across the group's legs.
+func (m *MatchingEngine) groupFilledLocked(g *OrderGroup) int64 {
+	filled := int64(0)
+	for _, id := range g.LegIDs {
+		if o, ok := m.orders[id]; ok {
+			filled += o.FilledQty
+		}
+	}
+	return filled
+}
+
+// reconcileGroupLocked brings a group in line with the fills and cancels
+// of its orders. The caller holds m.mu.
+func (m *MatchingEngine) reconcileGroupLocked(g *OrderGroup) {
+	book, ok := m.books[g.Symbol]
+	if !ok {
+		return
+	}
+	switch g.State {
+	case GroupPending:
+		m.reconcileBracketLocked(book, g)
+	case GroupActive:
+		m.reconcileOCOLocked(book, g)
+	}
+}
+
+// reconcileOCOLocked completes the group once its quantity has filled and
+// otherwise reduces every live leg to the quantity left. A leg cancelled
+// from outside the group cancels the rest.
+func (m *MatchingEngine) reconcileOCOLocked(book *orderbook.OrderBook, g *OrderGroup) {
+	if g.TriggeredID != "" {
+		if o, ok := m.orders[g.TriggeredID]; ok && !live(o) {
+			g.State = GroupCompleted
+			if o.FilledQty == 0 {
+				g.State = GroupCancelled
+			}
+		}
+		return
+	}
+
+	cancelled := false
+	var live []*common.Order
+	for _, id := range g.LegIDs {
+		o, ok := m.orders[id]
+		if !ok {
+			continue
+		}
+		switch o.Status {
+		case common.OrderStatusCancelled:
+			cancelled = true
+		case common.OrderStatusFilled:
+		default:
+			live = append(live, o)
+		}
+	}
+
+	left := g.Quantity - m.groupFilledLocked(g)
+	switch {
+	case left <= 0:
+		g.State = GroupCompleted
+	case cancelled:
+		g.State = GroupCancelled
+	default:
+		for _, o := range live {
+			if o.Quantity-o.FilledQty > left {
+				m.reduceLocked(book, o, o.FilledQty+left)
+			}
+		}
+		return
+	}
+	for _, o := range live {
+		_ = m.cancelLocked(book, o, CancelReasonOCO)
+	}
+}
+
+// reconcileBracketLocked activates the exits once the entry is done
+// filling. An entry cancelled without fills, or an exit cancelled before
+// activation, cancels the whole bracket.
+func (m *MatchingEngine) reconcileBracketLocked(book *orderbook.OrderBook, g *OrderGroup) {
+	entry, ok := m.orders[g.EntryID]
+	if !ok {
+		return
+	}
+	for _, id := range g.LegIDs {
+		if o, ok := m.orders[id]; !ok || o.Status == common.OrderStatusCancelled {
+			m.cancelGroupLocked(book, g, CancelReasonGroup)
+			return
+		}
+	}
+
+	switch {
+	case entry.Status == common.OrderStatusFilled:
+	case entry.Status == common.OrderStatusCancelled && entry.FilledQty > 0:
+	case entry.Status == common.OrderStatusCancelled:
+		m.cancelGroupLocked(book, g, CancelReasonGroup)
+		return
+	default:
+		return
+	}
+
+	g.State = GroupActive
+	g.Quantity = entry.FilledQty
+	for _, id := range g.LegIDs {
+		o, ok := m.orders[id]
+		if !ok {
+			continue
+		}
+		o.Quantity = g.Quantity
+		if o.Type.IsStop() {
+			m.parkStopLocked(book, o)
+			continue
+		}
+		o.Status = common.OrderStatusAccepted
+		if _, err := m.placeLocked(o); err != nil {
+			o.Status = common.OrderStatusCancelled
+			m.touchGroupLocked(o)
+			m.emitCancelLocked(o, o.Quantity, CancelReasonRejected)
+		}
+	}
+}
+
+// cancelGroupLocked marks the group cancelled and cancels its live
+// orders. The caller holds m.mu.
+func (m *MatchingEngine) cancelGroupLocked(book *orderbook.OrderBook, g *OrderGroup, reason string) {
+	g.State = GroupCancelled
+	ids := g.LegIDs
+	if g.EntryID != "" {
+		ids = append([]string{g.EntryID}, ids...)
+	}
+	for _, id := range ids {
+		if o, ok := m.orders[id]; ok && live(o) {
+			_ = m.cancelLocked(book, o, reason)
+		}
+	}
+}
+
+// cancelSiblingsLocked cancels the live legs of g other than o.
+func (m *MatchingEngine) cancelSiblingsLocked(book *orderbook.OrderBook, g *OrderGroup, o *common.Order, reason string) {
+	for _, id := range g.LegIDs {
+		if s, ok := m.orders[id]; ok && s != o && live(s) {
+			_ = m.cancelLocked(book, s, reason)
+		}
+	}
+}
+
+// reduceLocked lowers an order's quantity to qty, keeping its queue
+// position, and releases the funds no longer needed. The caller holds m.mu.
+func (m *MatchingEngine) reduceLocked(book *orderbook.OrderBook, o *common.Order, qty int64) {
+	delta := o.Quantity - qty
+	if delta <= 0 {
+		return
+	}
+	o.Quantity = qty
+	if o.Status != common.OrderStatusPending {
+		if o.Side == common.SideBuy {
+			book.Bids.TotalQuantity -= delta
+		} else {
+			book.Asks.TotalQuantity -= delta
+		}
+	}
+
+	res, ok := m.reservations[o.ID]
+	if !ok {
+		return
+	}
+	before := m.sizeReservation(book, o, qty+delta-o.FilledQty)
+	after := m.sizeReservation(book, o, qty-o.FilledQty)
+	amount := min(before.amount-after.amount, res.amount)
+	fee := min(before.fees-after.fees, res.fees)
+	owed := min(before.owed-after.owed, res.owed)
+	res.amount -= amount
+	res.fees -= fee
+	res.owed -= owed
+	if left := amount + fee; left > 0 {
+		_ = m.Ledger.Release(o.Account, res.asset, left)
+	}
+	if owed > 0 {
+		_ = m.Ledger.Release(o.Account, res.quote, owed)
+	}
+}
+
+func live(o *common.Order) bool {
+	return o.Status != common.OrderStatusFilled && o.Status != common.OrderStatusCancelled
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"order-matching-engine/internal/common"
+)
+
+var ErrKillSwitchActive = errors.New("order entry blocked by kill switch")
+
+const (
+	CancelReasonDeadMan    = "dead_man_switch"
+	CancelReasonKillSwitch = "kill_switch"
+)
+
+// deadMan is an armed dead-man's switch: unless refreshed before expiry,
+// all of the account's resting orders are cancelled, on halted books too.
+type deadMan struct {
+	timer   *time.Timer
+	expires time.Time
+}
+
+// KillSwitchState lists the active kill switches.
+type KillSwitchState struct {
+	Global   bool     `json:"global"`
+	Accounts []string `json:"accounts"`
+}
+
+// ArmDeadManSwitch arms or refreshes an account's dead-man's switch and
+// returns when it will fire.
+func (m *MatchingEngine) ArmDeadManSwitch(account string, timeout time.Duration) (time.Time, error) {
+	if account == "" {
+		return time.Time{}, ErrAccountRequired
+	}
+	if timeout <= 0 {
+		return time.Time{}, ErrInvalidOrderData
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if d, ok := m.deadMen[account]; ok {
+		d.timer.Stop()
+	}
+	d := &deadMan{expires: time.Now().Add(timeout)}
+	d.timer = time.AfterFunc(timeout, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.deadMen[account] != d {
+			return
+		}
+		delete(m.deadMen, account)
+		m.forceCancelLocked("", func(o *common.Order) bool { return o.Account == account }, CancelReasonDeadMan)
+	})
+	m.deadMen[account] = d
+	return d.expires, nil
+}
+
+// DisarmDeadManSwitch stops an account's switch without cancelling orders.
+// It reports whether a switch was armed.
+func (m *MatchingEngine) DisarmDeadManSwitch(account string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deadMen[account]
+	if ok {
+		d.timer.Stop()
+		delete(m.deadMen, account)
+	}
+	return ok
+}
+
+// DeadManSwitch returns when an account's switch fires, if armed.
+func (m *MatchingEngine) DeadManSwitch(account string) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if d, ok := m.deadMen[account]; ok {
+		return d.expires, true
+	}
+	return time.Time{}, false
+}
+
+// SetAccountKillSwitch blocks or unblocks order entry for an account.
+// Activating it cancels the account's resting orders, halted books
+// included, and returns their IDs.
+func (m *MatchingEngine) SetAccountKillSwitch(account string, active bool) ([]string, error) {
+	if account == "" {
+		return nil, ErrAccountRequired
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !active {
+		delete(m.killedAccounts, account)
+		return nil, nil
+	}
+	m.killedAccounts[account] = true
+	return m.forceCancelLocked("", func(o *common.Order) bool { return o.Account == account }, CancelReasonKillSwitch), nil
+}
+
+// SetGlobalKillSwitch blocks or unblocks order entry on every book.
+// Activating it cancels all resting orders, halted books included, and
+// returns their IDs.
+func (m *MatchingEngine) SetGlobalKillSwitch(active bool) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.globalKill = active
+	if !active {
+		return nil
+	}
+	return m.forceCancelLocked("", func(*common.Order) bool { return true }, CancelReasonKillSwitch)
+}
+
+// KillSwitches returns the active kill switches.
+func (m *MatchingEngine) KillSwitches() KillSwitchState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	st := KillSwitchState{Global: m.globalKill, Accounts: make([]string, 0, len(m.killedAccounts))}
+	for account := range m.killedAccounts {
+		st.Accounts = append(st.Accounts, account)
+	}
+	sort.Strings(st.Accounts)
+	return st
+}
+
+// killedLocked reports whether a kill switch blocks the account. The caller
+// holds m.mu.
+func (m *MatchingEngine) killedLocked(account string) bool {
+	return m.globalKill || (account != "" && m.killedAccounts[account])
+}
@@ -0,0 +1,354 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+)
+
+var ErrInsufficientMargin = errors.New("insufficient margin")
+
+// CancelReasonLiquidation marks orders cancelled when their account was
+// liquidated.
+const CancelReasonLiquidation = "liquidation"
+
+// MarginAccount is an account's margin state across margined instruments.
+// Equity is collateral plus realized and unrealized P&L, net of fees, on
+// margined symbols. Available is what may still be committed or withdrawn.
+type MarginAccount struct {
+	Account           string `json:"account_id"`
+	Collateral        int64  `json:"collateral"`
+	Equity            int64  `json:"equity"`
+	InitialMargin     int64  `json:"initial_margin"`     // positions and open orders
+	MaintenanceMargin int64  `json:"maintenance_margin"` // positions only
+	Available         int64  `json:"available"`
+}
+
+// Liquidation records one liquidation of an account: the state that
+// triggered it, the orders cancelled, the liquidation orders that traded,
+// and any shortfall the insurance fund covered.
+type Liquidation struct {
+	Account           string   `json:"account_id"`
+	Equity            int64    `json:"equity"`
+	MaintenanceMargin int64    `json:"maintenance_margin"`
+	Cancelled         []string `json:"cancelled_orders"`
+	Orders            []string `json:"liquidation_orders"`
+	Shortfall         int64    `json:"shortfall"`
+	Timestamp         int64    `json:"timestamp"`
+}
+
+// exposure is an account's position and open orders in one symbol.
+type exposure struct {
+	position   int64
+	buys       int64
+	sells      int64
+	orderPrice int64 // highest limit price among the open orders
+}
+
+// marginInstrument returns the instrument of a margined symbol.
+func (m *MatchingEngine) marginInstrument(symbol string) (instruments.Instrument, bool) {
+	if m.Instruments == nil {
+		return instruments.Instrument{}, false
+	}
+	inst, ok := m.Instruments.Get(symbol)
+	return inst, ok && inst.Margined()
+}
+
+// margined reports whether symbol trades on margin.
+func (m *MatchingEngine) margined(symbol string) bool {
+	_, ok := m.marginInstrument(symbol)
+	return ok
+}
+
+// SetMarkPrice sets the price margined positions are valued at and checks
+// every margin account against it.
+func (m *MatchingEngine) SetMarkPrice(symbol string, price int64) error {
+	if price <= 0 {
+		return ErrInvalidOrderData
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.marks[symbol] = price
+	m.Positions.SetMarkPrice(symbol, price)
+	m.checkLiquidationsLocked()
+	return nil
+}
+
+// MarkPrice returns the price positions in symbol are valued at: the last
+// mark price set, else the last trade price, or 0 if there is neither.
+func (m *MatchingEngine) MarkPrice(symbol string) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.markPriceLocked(symbol)
+}
+
+func (m *MatchingEngine) markPriceLocked(symbol string) int64 {
+	if price, ok := m.marks[symbol]; ok {
+		return price
+	}
+	if book, ok := m.books[symbol]; ok {
+		return book.LastPrice
+	}
+	return 0
+}
+
+// MarginStatus returns an account's margin state.
+func (m *MatchingEngine) MarginStatus(account string) MarginAccount {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.marginAccountLocked(account)
+}
+
+// WithdrawMargin removes collateral the account's positions and open orders
+// do not need.
+func (m *MatchingEngine) WithdrawMargin(account string, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if amount > m.marginAccountLocked(account).Available {
+		return ErrInsufficientMargin
+	}
+	return m.Margin.Withdraw(account, amount)
+}
+
+// Liquidations returns the liquidations carried out so far, oldest first.
+func (m *MatchingEngine) Liquidations() []Liquidation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Liquidation, 0, len(m.liquidations))
+	for _, l := range m.liquidations {
+		out = append(out, *l)
+	}
+	return out
+}
+
+// marginAccountLocked computes an account's margin state. The caller holds
+// m.mu.
+func (m *MatchingEngine) marginAccountLocked(account string) MarginAccount {
+	acc := MarginAccount{Account: account}
+	if m.Margin == nil {
+		return acc
+	}
+	acc.Collateral = m.Margin.Collateral(account)
+	acc.Equity = acc.Collateral
+	for _, p := range m.Positions.Account(account).Positions {
+		if m.margined(p.Symbol) {
+			acc.Equity += p.RealizedPnL + p.UnrealizedPnL - p.Fees
+		}
+	}
+	acc.InitialMargin = m.requirementLocked(account, nil, false)
+	acc.MaintenanceMargin = m.requirementLocked(account, nil, true)
+	acc.Available = acc.Equity - acc.InitialMargin
+	return acc
+}
+
+// requirementLocked sums an account's margin requirement over margined
+// symbols. Maintenance margin covers positions. Initial margin covers the
+// larger of the position after every open buy fills and after every open
+// sell fills, counting extra as an open order. Positions are valued at the
+// mark price, or the highest order price while a symbol has none. The
+// caller holds m.mu.
+func (m *MatchingEngine) requirementLocked(account string, extra *common.Order, maintenance bool) int64 {
+	exposures := make(map[string]*exposure)
+	get := func(symbol string) *exposure {
+		if !m.margined(symbol) {
+			return nil
+		}
+		e, ok := exposures[symbol]
+		if !ok {
+			e = &exposure{}
+			exposures[symbol] = e
+		}
+		return e
+	}
+	addOrder := func(o *common.Order) {
+		e := get(o.Symbol)
+		if e == nil {
+			return
+		}
+		if o.Side == common.SideBuy {
+			e.buys += o.Quantity - o.FilledQty
+		} else {
+			e.sells += o.Quantity - o.FilledQty
+		}
+		e.orderPrice = max(e.orderPrice, limitPrice(o))
+	}
+
+	for _, p := range m.Positions.Account(account).Positions {
+		if e := get(p.Symbol); e != nil {
+			e.position = p.Quantity
+		}
+	}
+	if !maintenance {
+		for _, o := range m.accountOrders[account] {
+			addOrder(o)
+		}
+		if extra != nil {
+			addOrder(extra)
+		}
+	}
+
+	total := int64(0)
+	for symbol, e := range exposures {
+		inst, _ := m.marginInstrument(symbol)
+		bps, size := inst.InitialMarginBps, max(abs(e.position+e.buys), abs(e.position-e.sells))
+		if maintenance {
+			bps, size = inst.MaintenanceMarginBps, abs(e.position)
+		}
+		price := m.markPriceLocked(symbol)
+		if price == 0 {
+			price = e.orderPrice
+		}
+		total += size * price * m.multiplier(symbol) * bps / 10000
+	}
+	return total
+}
+
+// checkMarginLocked rejects an order on a margined symbol that would raise
+// the account's initial margin above its equity. Orders that reduce the
+// requirement always pass, so an account can trade out of its positions.
+// The caller holds m.mu.
+func (m *MatchingEngine) checkMarginLocked(o *common.Order) error {
+	if m.Margin == nil || o.Liquidation || !m.margined(o.Symbol) {
+		return nil
+	}
+	acc := m.marginAccountLocked(o.Account)
+	if after := m.requirementLocked(o.Account, o, false); after > acc.InitialMargin && after > acc.Equity {
+		return ErrInsufficientMargin
+	}
+	return nil
+}
+
+// CheckLiquidations liquidates every account whose equity has fallen below
+// its maintenance margin and returns what it did.
+func (m *MatchingEngine) CheckLiquidations() []Liquidation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Liquidation
+	for _, l := range m.checkLiquidationsLocked() {
+		out = append(out, *l)
+	}
+	return out
+}
+
+// StartLiquidationMonitor checks margin accounts every interval until the
+// returned stop function is called. Mark price updates check them too.
+func (m *MatchingEngine) StartLiquidationMonitor(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				m.CheckLiquidations()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// checkLiquidationsLocked liquidates accounts below maintenance margin,
+// in account order. The caller holds m.mu.
+func (m *MatchingEngine) checkLiquidationsLocked() []*Liquidation {
+	if m.Margin == nil || m.Instruments == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, inst := range m.Instruments.List() {
+		if !inst.Margined() {
+			continue
+		}
+		for _, account := range m.Positions.Holders(inst.Symbol) {
+			if !seen[account] {
+				seen[account] = true
+				accounts = append(accounts, account)
+			}
+		}
+	}
+	sort.Strings(accounts)
+
+	var out []*Liquidation
+	for _, account := range accounts {
+		if acc := m.marginAccountLocked(account); acc.Equity < acc.MaintenanceMargin {
+			if l := m.liquidateLocked(acc); l != nil {
+				out = append(out, l)
+			}
+		}
+	}
+	return out
+}
+
+// liquidateLocked cancels the account's orders on margined symbols, halted
+// books included, and sends a market order to close each of its margined
+// positions the book has liquidity for. Liquidation orders bypass kill
+// switches and pre-trade checks. If the account ends up flat with negative
+// equity, the insurance fund covers the shortfall; positions the book could
+// not absorb are retried on the next check. A check that cancelled, traded
+// and covered nothing returns nil and leaves no record, so retries while a
+// book is empty do not pile up. The caller holds m.mu.
+func (m *MatchingEngine) liquidateLocked(acc MarginAccount) *Liquidation {
+	l := &Liquidation{
+		Account:           acc.Account,
+		Equity:            acc.Equity,
+		MaintenanceMargin: acc.MaintenanceMargin,
+		Timestamp:         time.Now().UnixMilli(),
+	}
+	l.Cancelled = m.forceCancelLocked("", func(o *common.Order) bool {
+		return o.Account == acc.Account && m.margined(o.Symbol)
+	}, CancelReasonLiquidation)
+
+	for _, p := range m.Positions.Account(acc.Account).Positions {
+		if p.Quantity == 0 || !m.margined(p.Symbol) {
+			continue
+		}
+		book, ok := m.books[p.Symbol]
+		if !ok {
+			continue
+		}
+		side, opposite := common.SideSell, book.Bids
+		if p.Quantity < 0 {
+			side, opposite = common.SideBuy, book.Asks
+		}
+		if opposite.TotalQuantity == 0 {
+			continue
+		}
+		// Fill-and-kill takes whatever the book holds; the rest is retried.
+		o := m.createOrder(&common.Order{Account: acc.Account, Symbol: p.Symbol, Side: side,
+			Type: common.OrderTypeMarket, MarketMode: common.MarketModeFillAndKill, Quantity: abs(p.Quantity)})
+		o.Liquidation = true
+		if _, err := m.placeLocked(o); err == nil && o.FilledQty > 0 {
+			l.Orders = append(l.Orders, o.ID)
+		}
+	}
+
+	flat := true
+	for _, p := range m.Positions.Account(acc.Account).Positions {
+		if p.Quantity != 0 && m.margined(p.Symbol) {
+			flat = false
+		}
+	}
+	if after := m.marginAccountLocked(acc.Account); flat && after.Equity < 0 {
+		l.Shortfall = -after.Equity
+		m.Margin.CoverShortfall(acc.Account, l.Shortfall)
+	}
+	if len(l.Cancelled) == 0 && len(l.Orders) == 0 && l.Shortfall == 0 {
+		return nil
+	}
+
+	m.liquidations = append(m.liquidations, l)
+	m.emit(Event{Type: EventLiquidation, Liquidation: l})
+	return l
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+// prepareMarketOrder applies the order's market mode before funds are
+// reserved. Fill-or-kill orders need enough liquidity within the protection
+// price and the price band; fill-and-kill orders need some; market-to-limit orders become limit
+// orders at the best opposite price. The caller holds m.mu.
+func (m *MatchingEngine) prepareMarketOrder(book *orderbook.OrderBook, o *common.Order) error {
+	opposite := book.Asks
+	if o.Side == common.SideSell {
+		opposite = book.Bids
+	}
+	best, ok := opposite.BestPrice()
+	if !ok || !withinProtection(o, best) {
+		return ErrInsufficientLiquidity
+	}
+
+	switch o.MarketMode {
+	case common.MarketModeMarketToLimit:
+		o.Type = common.OrderTypeLimit
+		o.Price = best
+	case common.MarketModeFillAndKill:
+	default:
+		within := func(price int64) bool { return withinProtection(o, price) }
+		if m.executableLocked(book, o, m.priceBandLocked(book), within) < o.Quantity {
+			return ErrInsufficientLiquidity
+		}
+	}
+	return nil
+}
+
+// withinProtection reports whether a market order may trade at price.
+func withinProtection(o *common.Order, price int64) bool {
+	if o.ProtectionPrice == 0 {
+		return true
+	}
+	if o.Side == common.SideBuy {
+		return price <= o.ProtectionPrice
+	}
+	return price >= o.ProtectionPrice
+}
+
+// marketOutcome describes how an order entered as a market order ended.
+// The caller holds m.mu.
+func marketOutcome(book *orderbook.OrderBook, o *common.Order, breached bool) common.MarketOutcome {
+	switch {
+	case o.FilledQty == o.Quantity:
+		return common.MarketOutcomeFilled
+	case breached && o.Status == common.OrderStatusCancelled:
+		return common.MarketOutcomePriceBand
+	case o.Type == common.OrderTypeLimit:
+		return common.MarketOutcomeConverted
+	}
+	opposite := book.Asks
+	if o.Side == common.SideSell {
+		opposite = book.Bids
+	}
+	if opposite.TotalQuantity > 0 {
+		return common.MarketOutcomeProtectionReached
+	}
+	return common.MarketOutcomeLiquidityExhausted
+}
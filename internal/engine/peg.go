@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"errors"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+var ErrNoPegReference = errors.New("no reference price for pegged order")
+
+// pegRefs returns the best bid and ask of the book's displayed, non-pegged
+// orders, 0 for an empty side. Pegs never reference each other, so repricing cannot
+// feed on itself.
+func pegRefs(book *orderbook.OrderBook) (bid, ask int64) {
+	return referencePrice(book.Bids), referencePrice(book.Asks)
+}
+
+func referencePrice(side *orderbook.SideBook) int64 {
+	for _, p := range side.Prices {
+		for _, o := range side.Levels[p].Orders {
+			if o.Type != common.OrderTypePeg && !o.Hidden && o.FilledQty < o.Quantity {
+				return p
+			}
+		}
+	}
+	return 0
+}
+
+// pegTarget is the price o should work at for the given references. A
+// midpoint between ticks rounds down for a buy and up for a sell, so the
+// order never prices past the midpoint. It reports false when the reference
+// side is empty.
+func pegTarget(o *common.Order, bid, ask, tick int64) (int64, bool) {
+	same, opposite := bid, ask
+	if o.Side == common.SideSell {
+		same, opposite = ask, bid
+	}
+
+	var twice int64 // twice the reference, keeping half-tick midpoints exact
+	switch o.PegReference {
+	case common.PegPrimary:
+		twice = 2 * same
+	case common.PegMarket:
+		twice = 2 * opposite
+	case common.PegMidpoint:
+		if bid == 0 || ask == 0 {
+			return 0, false
+		}
+		twice = bid + ask
+	}
+	if twice == 0 {
+		return 0, false
+	}
+
+	unit := 2 * tick
+	var price int64
+	if o.Side == common.SideBuy {
+		twice += 2 * o.PegOffset
+		price = twice / unit * tick
+		if price > o.PegLimit {
+			price = o.PegLimit
+		}
+	} else {
+		twice -= 2 * o.PegOffset
+		price = (twice + unit - 1) / unit * tick
+		if price < o.PegLimit {
+			price = o.PegLimit
+		}
+	}
+	if price <= 0 {
+		return 0, false
+	}
+	return price, true
+}
+
+// pricePegLocked sets a new pegged order's working price. It may cross the
+// book and trade on entry like a limit order. The caller holds m.mu.
+func (m *MatchingEngine) pricePegLocked(book *orderbook.OrderBook, o *common.Order) error {
+	bid, ask := pegRefs(book)
+	price, ok := pegTarget(o, bid, ask, m.tickSize(book.Symbol))
+	if !ok {
+		return ErrNoPegReference
+	}
+	o.Price = price
+	return nil
+}
+
+// repegLocked reprices the book's resting pegs after the top of the book
+// moved, in arrival order. A peg whose price changes joins the back of its
+// new level; one whose price holds keeps its place. Repricing never crosses
+// the book: a target at or through the opposite best is held one tick
+// inside it. Pegs stay put outside continuous trading or when their
+// reference is gone. The caller holds m.mu.
+func (m *MatchingEngine) repegLocked(book *orderbook.OrderBook) {
+	pegs := m.pegs[book.Symbol]
+	if len(pegs) == 0 {
+		return
+	}
+
+	kept := pegs[:0]
+	for _, o := range pegs {
+		if o.Status == common.OrderStatusAccepted || o.Status == common.OrderStatusPartial {
+			kept = append(kept, o)
+		}
+	}
+	for i := len(kept); i < len(pegs); i++ {
+		pegs[i] = nil
+	}
+	if len(kept) == 0 {
+		delete(m.pegs, book.Symbol)
+		return
+	}
+	m.pegs[book.Symbol] = kept
+
+	if book.Status != common.TradingStatusOpen {
+		return
+	}
+	tick := m.tickSize(book.Symbol)
+	bid, ask := pegRefs(book)
+	for _, o := range kept {
+		target, ok := pegTarget(o, bid, ask, tick)
+		if !ok {
+			continue
+		}
+		if o.Side == common.SideBuy {
+			if best, ok := book.Asks.BestPrice(); ok && target >= best {
+				target = best - tick
+			}
+		} else if best, ok := book.Bids.BestPrice(); ok && target <= best {
+			target = best + tick
+		}
+		if target == o.Price || target <= 0 {
+			continue
+		}
+
+		if _, ok := m.unlinkLocked(book, o); !ok {
+			continue
+		}
+		o.Price = target
+		if o.Side == common.SideBuy {
+			book.Bids.AddOrder(o)
+		} else {
+			book.Asks.AddOrder(o)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"order-matching-engine/internal/common"
+)
+
+var (
+	ErrDuplicateQuoteID = errors.New("duplicate quote id in quote set")
+	ErrCrossedQuotes    = errors.New("quote set crosses itself")
+)
+
+// CancelReasonQuoteReplaced marks quotes withdrawn by a new quote set.
+const CancelReasonQuoteReplaced = "quote_replaced"
+
+// Quote is one level of a market maker's quote set. QuoteID is chosen by
+// the client and must be unique within the set.
+type Quote struct {
+	QuoteID  string      `json:"quote_id"`
+	Symbol   string      `json:"symbol"`
+	Side     common.Side `json:"side"`
+	Price    int64       `json:"price"`
+	Quantity int64       `json:"quantity"`
+}
+
+// MassQuoteRequest replaces an account's whole quote set, across symbols.
+// An empty Quotes list withdraws all quotes.
+type MassQuoteRequest struct {
+	Account            string
+	SessionID          string // set when quoting over a session
+	CancelOnDisconnect bool
+	Quotes             []Quote
+}
+
+// QuoteAck is the outcome of one quote. Exactly one of Order and Err is set.
+type QuoteAck struct {
+	QuoteID string
+	Order   *common.Order
+	Trades  []*common.Trade
+	Err     error
+}
+
+// MassQuoteResult lists the replaced quotes and one ack per new quote, in
+// request order.
+type MassQuoteResult struct {
+	Cancelled []string
+	Acks      []QuoteAck
+}
+
+// MassQuote atomically withdraws the account's resting quotes and enters
+// the new set as limit orders. Each quote is accepted or rejected on its
+// own; accepted quotes match and rest like any other order.
+func (m *MatchingEngine) MassQuote(req MassQuoteRequest) (*MassQuoteResult, error) {
+	if req.Account == "" {
+		return nil, ErrAccountRequired
+	}
+
+	res := &MassQuoteResult{Acks: make([]QuoteAck, len(req.Quotes))}
+	orders := make([]*common.Order, len(req.Quotes))
+	seen := make(map[string]bool, len(req.Quotes))
+	for i, q := range req.Quotes {
+		atomic.AddUint64(&m.Metrics.OrdersReceived, 1)
+		res.Acks[i].QuoteID = q.QuoteID
+
+		o := &common.Order{
+			Account:            req.Account,
+			Symbol:             q.Symbol,
+			Side:               q.Side,
+			Type:               common.OrderTypeLimit,
+			Price:              q.Price,
+			Quantity:           q.Quantity,
+			QuoteID:            q.QuoteID,
+			SessionID:          req.SessionID,
+			CancelOnDisconnect: req.CancelOnDisconnect,
+		}
+		switch {
+		case q.QuoteID == "":
+			res.Acks[i].Err = ErrInvalidOrderData
+		case seen[q.QuoteID]:
+			res.Acks[i].Err = ErrDuplicateQuoteID
+		default:
+			res.Acks[i].Err = validateOrderRequest(o, m.Instruments)
+		}
+		seen[q.QuoteID] = true
+		if res.Acks[i].Err == nil {
+			orders[i] = m.createOrder(o)
+		}
+	}
+	if crossesItself(orders) {
+		return nil, ErrCrossedQuotes
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.killedLocked(req.Account) {
+		return nil, ErrKillSwitchActive
+	}
+	if req.SessionID != "" && !m.sessionActiveLocked(req.SessionID, req.Account) {
+		return nil, ErrUnknownSession
+	}
+
+	// Every old quote goes, halted books included, so none survives
+	// alongside its replacement once trading resumes.
+	res.Cancelled = m.forceCancelLocked("", func(o *common.Order) bool {
+		return o.Account == req.Account && o.QuoteID != ""
+	}, CancelReasonQuoteReplaced)
+
+	for i, o := range orders {
+		if o == nil {
+			continue
+		}
+		trades, err := m.placeLocked(o)
+		if err != nil {
+			res.Acks[i].Err = err
+			continue
+		}
+		res.Acks[i].Order = o
+		res.Acks[i].Trades = trades
+	}
+	return res, nil
+}
+
+// crossesItself reports whether a bid in the quote set is at or above an
+// ask for the same symbol; entered together they would trade with each
+// other.
+func crossesItself(orders []*common.Order) bool {
+	bids := make(map[string]int64)
+	asks := make(map[string]int64)
+	for _, o := range orders {
+		switch {
+		case o == nil:
+		case o.Side == common.SideBuy:
+			if best, ok := bids[o.Symbol]; !ok || o.Price > best {
+				bids[o.Symbol] = o.Price
+			}
+		default:
+			if best, ok := asks[o.Symbol]; !ok || o.Price < best {
+				asks[o.Symbol] = o.Price
+			}
+		}
+	}
+	for symbol, bid := range bids {
+		if ask, ok := asks[symbol]; ok && bid >= ask {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,386 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-matching-engine/internal/common"
+)
+
+var (
+	ErrRFQNotFound      = errors.New("rfq not found")
+	ErrRFQQuoteNotFound = errors.New("rfq quote not found or expired")
+	ErrNotDealer        = errors.New("account is not a registered dealer")
+)
+
+// DefaultRFQTimeout is how long an RFQ stays open when no TTL is given.
+const DefaultRFQTimeout = 30 * time.Second
+
+// RFQState is the lifecycle state of a request for quote.
+type RFQState string
+
+const (
+	RFQStateOpen      RFQState = "OPEN"
+	RFQStateFilled    RFQState = "FILLED"
+	RFQStateExpired   RFQState = "EXPIRED"
+	RFQStateCancelled RFQState = "CANCELLED"
+)
+
+// RFQ is a request for quote: the requester asks registered dealers for a
+// firm price on Quantity of Symbol and may accept one of the replies until
+// ExpiresAt.
+type RFQ struct {
+	ID        string      `json:"rfq_id"`
+	Account   string      `json:"account_id"`
+	Symbol    string      `json:"symbol"`
+	Side      common.Side `json:"side"` // the requester's side
+	Quantity  int64       `json:"quantity"`
+	State     RFQState    `json:"state"`
+	CreatedAt int64       `json:"created_at"`
+	ExpiresAt int64       `json:"expires_at"`
+	Quotes    []*RFQQuote `json:"quotes"`
+	TradeID   string      `json:"trade_id,omitempty"` // set once FILLED
+
+	timer *time.Timer
+}
+
+// RFQQuote is a dealer's firm reply to an RFQ, valid until ExpiresAt.
+type RFQQuote struct {
+	QuoteID   string `json:"quote_id"`
+	RFQID     string `json:"rfq_id"`
+	Dealer    string `json:"dealer_id"`
+	Price     int64  `json:"price"`
+	Quantity  int64  `json:"quantity"`
+	ExpiresAt int64  `json:"expires_at"`
+
+	timer *time.Timer
+}
+
+// RFQRequest opens an RFQ. A zero TTL uses DefaultRFQTimeout.
+type RFQRequest struct {
+	Account  string        `json:"account_id"`
+	Symbol   string        `json:"symbol"`
+	Side     common.Side   `json:"side"`
+	Quantity int64         `json:"quantity"`
+	TTL      time.Duration `json:"-"`
+}
+
+// RFQQuoteRequest is a dealer's reply. A zero Quantity quotes the full RFQ
+// quantity; a zero TTL keeps the quote until the RFQ expires.
+type RFQQuoteRequest struct {
+	RFQID    string        `json:"rfq_id"`
+	Dealer   string        `json:"dealer_id"`
+	Price    int64         `json:"price"`
+	Quantity int64         `json:"quantity"`
+	TTL      time.Duration `json:"-"`
+}
+
+// RFQNotice is the public view of an RFQ published to market data
+// subscribers. It names neither the requester nor the quoting dealers.
+type RFQNotice struct {
+	RFQID     string      `json:"rfq_id"`
+	Symbol    string      `json:"symbol"`
+	Side      common.Side `json:"side"`
+	Quantity  int64       `json:"quantity"`
+	State     RFQState    `json:"state"`
+	Quotes    int         `json:"quotes"` // live quotes
+	ExpiresAt int64       `json:"expires_at"`
+}
+
+// RegisterDealer allows an account to answer RFQs.
+func (m *MatchingEngine) RegisterDealer(account string) error {
+	if account == "" {
+		return ErrAccountRequired
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dealers[account] = true
+	return nil
+}
+
+// UnregisterDealer stops an account from answering RFQs. Its live quotes
+// stay firm until they expire. It reports whether the account was a dealer.
+func (m *MatchingEngine) UnregisterDealer(account string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ok := m.dealers[account]
+	delete(m.dealers, account)
+	return ok
+}
+
+// Dealers lists the registered dealers, sorted.
+func (m *MatchingEngine) Dealers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]string, 0, len(m.dealers))
+	for account := range m.dealers {
+		out = append(out, account)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RequestQuote opens an RFQ and publishes it to dealers. The RFQ and its
+// quotes are dropped when it expires.
+func (m *MatchingEngine) RequestQuote(req RFQRequest) (*RFQ, error) {
+	if req.Account == "" {
+		return nil, ErrAccountRequired
+	}
+	if req.TTL < 0 {
+		return nil, ErrInvalidOrderData
+	}
+	if req.TTL == 0 {
+		req.TTL = DefaultRFQTimeout
+	}
+	probe := &common.Order{Symbol: req.Symbol, Side: req.Side, Type: common.OrderTypeMarket, Quantity: req.Quantity}
+	if err := validateOrderRequest(probe, m.Instruments); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.killedLocked(req.Account) {
+		return nil, ErrKillSwitchActive
+	}
+	if book := m.ensureBook(req.Symbol); book.Status != common.TradingStatusOpen {
+		return nil, ErrSymbolNotOpen
+	}
+
+	now := time.Now()
+	r := &RFQ{
+		ID:        uuid.NewString(),
+		Account:   req.Account,
+		Symbol:    req.Symbol,
+		Side:      req.Side,
+		Quantity:  req.Quantity,
+		State:     RFQStateOpen,
+		CreatedAt: now.UnixMilli(),
+		ExpiresAt: now.Add(req.TTL).UnixMilli(),
+	}
+	r.timer = time.AfterFunc(req.TTL, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.rfqs[r.ID] == r {
+			m.closeRFQLocked(r, RFQStateExpired)
+		}
+	})
+	m.rfqs[r.ID] = r
+	m.emitRFQLocked(r)
+	return r.snapshot(), nil
+}
+
+// QuoteRFQ records a dealer's firm quote on an open RFQ. A dealer's new
+// quote replaces its previous one on the same RFQ.
+func (m *MatchingEngine) QuoteRFQ(req RFQQuoteRequest) (*RFQQuote, error) {
+	if req.Dealer == "" {
+		return nil, ErrAccountRequired
+	}
+	if req.Price <= 0 || req.Quantity < 0 || req.TTL < 0 {
+		return nil, ErrInvalidOrderData
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.dealers[req.Dealer] {
+		return nil, ErrNotDealer
+	}
+	if m.killedLocked(req.Dealer) {
+		return nil, ErrKillSwitchActive
+	}
+	r, ok := m.rfqs[req.RFQID]
+	if !ok {
+		return nil, ErrRFQNotFound
+	}
+	if req.Dealer == r.Account {
+		return nil, ErrInvalidOrderData
+	}
+	if req.Quantity == 0 {
+		req.Quantity = r.Quantity
+	}
+	if req.Quantity > r.Quantity {
+		return nil, ErrInvalidOrderData
+	}
+	probe := &common.Order{Symbol: r.Symbol, Side: opposite(r.Side), Type: common.OrderTypeLimit,
+		Price: req.Price, Quantity: req.Quantity}
+	if err := validateOrderRequest(probe, m.Instruments); err != nil {
+		return nil, err
+	}
+
+	expires := time.UnixMilli(r.ExpiresAt)
+	if req.TTL > 0 && time.Now().Add(req.TTL).Before(expires) {
+		expires = time.Now().Add(req.TTL)
+	}
+	q := &RFQQuote{
+		QuoteID:   uuid.NewString(),
+		RFQID:     r.ID,
+		Dealer:    req.Dealer,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		ExpiresAt: expires.UnixMilli(),
+	}
+	q.timer = time.AfterFunc(time.Until(expires), func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if r.dropQuote(q.QuoteID) != nil {
+			m.emitRFQLocked(r)
+		}
+	})
+
+	for _, prev := range r.Quotes {
+		if prev.Dealer == req.Dealer {
+			r.dropQuote(prev.QuoteID).timer.Stop()
+			break
+		}
+	}
+	r.Quotes = append(r.Quotes, q)
+	m.emitRFQLocked(r)
+
+	cp := *q
+	return &cp, nil
+}
+
+// AcceptRFQQuote executes a live quote against the requester and closes the
+// RFQ. The requester is the taker.
+func (m *MatchingEngine) AcceptRFQQuote(rfqID, account, quoteID string) (*common.Trade, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rfqs[rfqID]
+	if !ok || r.Account != account {
+		return nil, ErrRFQNotFound
+	}
+	q := r.quote(quoteID)
+	if q == nil {
+		return nil, ErrRFQQuoteNotFound
+	}
+	if m.killedLocked(r.Account) || m.killedLocked(q.Dealer) {
+		return nil, ErrKillSwitchActive
+	}
+	book := m.ensureBook(r.Symbol)
+	if book.Status != common.TradingStatusOpen {
+		return nil, ErrSymbolNotOpen
+	}
+
+	buyer, seller := r.Account, q.Dealer
+	if r.Side == common.SideSell {
+		buyer, seller = seller, buyer
+	}
+	trade, err := m.offBookTradeLocked(book, common.TradeTypeRFQ, r.Side, buyer, seller, q.Price, q.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	r.TradeID = trade.TradeID
+	m.closeRFQLocked(r, RFQStateFilled)
+	return trade, nil
+}
+
+// CancelRFQ withdraws the requester's open RFQ.
+func (m *MatchingEngine) CancelRFQ(rfqID, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.rfqs[rfqID]
+	if !ok || r.Account != account {
+		return ErrRFQNotFound
+	}
+	m.closeRFQLocked(r, RFQStateCancelled)
+	return nil
+}
+
+// GetRFQ returns a copy of an open RFQ as account may see it. The
+// requester sees every live quote. A dealer sees the RFQ without the
+// requester and only its own quote. Other accounts do not see it.
+func (m *MatchingEngine) GetRFQ(rfqID, account string) (*RFQ, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rfqs[rfqID]
+	if !ok || account == "" {
+		return nil, false
+	}
+	if account == r.Account {
+		return r.snapshot(), true
+	}
+
+	cp := r.snapshot()
+	cp.Account = ""
+	own := cp.Quotes[:0]
+	for _, q := range cp.Quotes {
+		if q.Dealer == account {
+			own = append(own, q)
+		}
+	}
+	cp.Quotes = own
+	if !m.dealers[account] && len(own) == 0 {
+		return nil, false
+	}
+	return cp, true
+}
+
+// closeRFQLocked ends an RFQ, stops its timers and forgets it. The caller
+// holds m.mu.
+func (m *MatchingEngine) closeRFQLocked(r *RFQ, state RFQState) {
+	r.State = state
+	r.timer.Stop()
+	for _, q := range r.Quotes {
+		q.timer.Stop()
+	}
+	r.Quotes = nil
+	delete(m.rfqs, r.ID)
+	m.emitRFQLocked(r)
+}
+
+// emitRFQLocked publishes the RFQ's public state. The caller holds m.mu.
+func (m *MatchingEngine) emitRFQLocked(r *RFQ) {
+	m.emit(Event{Type: EventRFQ, Symbol: r.Symbol, RFQ: &RFQNotice{
+		RFQID:     r.ID,
+		Symbol:    r.Symbol,
+		Side:      r.Side,
+		Quantity:  r.Quantity,
+		State:     r.State,
+		Quotes:    len(r.Quotes),
+		ExpiresAt: r.ExpiresAt,
+	}})
+}
+
+func (r *RFQ) quote(id string) *RFQQuote {
+	for _, q := range r.Quotes {
+		if q.QuoteID == id {
+			return q
+		}
+	}
+	return nil
+}
+
+// dropQuote removes a quote and returns it, or nil if it is gone.
+func (r *RFQ) dropQuote(id string) *RFQQuote {
+	for i, q := range r.Quotes {
+		if q.QuoteID == id {
+			r.Quotes = append(r.Quotes[:i], r.Quotes[i+1:]...)
+			return q
+		}
+	}
+	return nil
+}
+
+func (r *RFQ) snapshot() *RFQ {
+	cp := *r
+	cp.timer = nil
+	cp.Quotes = make([]*RFQQuote, len(r.Quotes))
+	for i, q := range r.Quotes {
+		qc := *q
+		qc.timer = nil
+		cp.Quotes[i] = &qc
+	}
+	return &cp
+}
+
+func opposite(s common.Side) common.Side {
+	if s == common.SideBuy {
+		return common.SideSell
+	}
+	return common.SideBuy
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-matching-engine/internal/common"
+)
+
+var ErrUnknownSession = errors.New("unknown or inactive session")
+
+// session is a connection-scoped trading session of one account. Transports
+// (WebSocket, binary gateways) open a session on logon and close it when
+// the connection drops.
+type session struct {
+	account   string
+	connected bool
+	timer     *time.Timer // pending cancel-on-disconnect while disconnected
+}
+
+// OpenSession starts a trading session for an account and returns its ID.
+// A non-empty resumeID reattaches a disconnected session still within its
+// grace period, keeping its orders alive.
+func (m *MatchingEngine) OpenSession(account, resumeID string) (string, error) {
+	if account == "" {
+		return "", ErrAccountRequired
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if resumeID != "" {
+		s, ok := m.sessions[resumeID]
+		if !ok || s.account != account || s.connected {
+			return "", ErrUnknownSession
+		}
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+		s.connected = true
+		return resumeID, nil
+	}
+
+	id := uuid.NewString()
+	m.sessions[id] = &session{account: account, connected: true}
+	return id, nil
+}
+
+// CloseSession marks a session disconnected. Unless it is resumed within
+// grace, its cancel-on-disconnect orders are then cancelled and the session
+// ends; a zero grace cancels them immediately.
+func (m *MatchingEngine) CloseSession(id string, grace time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || !s.connected {
+		return ErrUnknownSession
+	}
+	s.connected = false
+	if grace <= 0 {
+		m.endSessionLocked(id)
+		return nil
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(grace, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if cur, ok := m.sessions[id]; ok && cur.timer == t {
+			m.endSessionLocked(id)
+		}
+	})
+	s.timer = t
+	return nil
+}
+
+// endSessionLocked cancels the session's cancel-on-disconnect orders, on
+// halted books too, and forgets it. The caller holds m.mu.
+func (m *MatchingEngine) endSessionLocked(id string) []string {
+	delete(m.sessions, id)
+	return m.forceCancelLocked("", func(o *common.Order) bool {
+		return o.SessionID == id && o.CancelOnDisconnect
+	}, CancelReasonDisconnect)
+}
+
+// sessionActiveLocked reports whether orders may be entered on a session
+// for account. The caller holds m.mu.
+func (m *MatchingEngine) sessionActiveLocked(id, account string) bool {
+	s, ok := m.sessions[id]
+	return ok && s.connected && s.account == account
+}
@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"math"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/orderbook"
+)
+
+// ImpliedQuote is a spread price implied by the leg books and the number of
+// strategy units tradable at it.
+type ImpliedQuote struct {
+	Price    int64 `json:"price"`
+	Quantity int64 `json:"quantity"`
+}
+
+// impliedLeg is the best level of one leg book a strategy order would take.
+type impliedLeg struct {
+	book  *orderbook.OrderBook
+	side  common.Side // side of the leg order
+	ratio int64       // leg units per strategy unit, always positive
+	price int64
+}
+
+// strategyFor returns the instrument of a strategy symbol.
+func (m *MatchingEngine) strategyFor(symbol string) (instruments.Instrument, bool) {
+	if m.Instruments == nil {
+		return instruments.Instrument{}, false
+	}
+	inst, ok := m.Instruments.Get(symbol)
+	return inst, ok && inst.IsStrategy()
+}
+
+// ImpliedTopOfBook returns the best bid and ask that a strategy's leg books
+// imply, each nil when the legs cannot support that side. It is nil for
+// symbols that are not strategies with implied pricing.
+func (m *MatchingEngine) ImpliedTopOfBook(symbol string) (bid, ask *ImpliedQuote) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inst, ok := m.strategyFor(symbol)
+	if !ok || !inst.ImpliedIn {
+		return nil, nil
+	}
+	if _, price, units, ok := m.impliedLocked(inst, common.SideSell, math.MaxInt64); ok {
+		bid = &ImpliedQuote{Price: price, Quantity: units}
+	}
+	if _, price, units, ok := m.impliedLocked(inst, common.SideBuy, math.MaxInt64); ok {
+		ask = &ImpliedQuote{Price: price, Quantity: units}
+	}
+	return bid, ask
+}
+
+// executeSpreadLocked fills a strategy order against resting strategy
+// orders and, with implied pricing, against the leg books. At each step
+// the better price wins, resting strategy orders on a tie. An implied fill
+// trades every leg in full at its best level or not at all. It reports
+// whether matching stopped at the price band. The caller holds m.mu.
+func (m *MatchingEngine) executeSpreadLocked(book *orderbook.OrderBook, inst instruments.Instrument, o *common.Order, band priceBand) ([]*common.Trade, bool) {
+	resting := book.Asks
+	if o.Side == common.SideSell {
+		resting = book.Bids
+	}
+
+	var trades []*common.Trade
+	for o.FilledQty < o.Quantity {
+		direct, hasDirect := resting.BestPrice()
+		hasDirect = hasDirect && limitCrosses(o, direct)
+
+		var (
+			legs       []impliedLeg
+			implied    int64
+			units      int64
+			hasImplied bool
+		)
+		if inst.ImpliedIn {
+			legs, implied, units, hasImplied = m.impliedLocked(inst, o.Side, o.Quantity-o.FilledQty)
+			hasImplied = hasImplied && limitCrosses(o, implied)
+		}
+
+		if hasDirect && (!hasImplied || !improves(o.Side, implied, direct)) {
+			before := o.FilledQty
+			filled, breached := m.execute(book, o, band, func(price int64) bool { return price == direct })
+			trades = append(trades, filled...)
+			if breached {
+				return trades, true
+			}
+			if o.FilledQty > before {
+				continue
+			}
+			// Nothing filled: the level only holds all-or-none orders too
+			// large for this order.
+		}
+		if !hasImplied {
+			break
+		}
+		filled, ok := m.fillLegsLocked(o, legs, units)
+		if !ok {
+			break
+		}
+		trades = append(trades, filled...)
+	}
+	return trades, false
+}
+
+// impliedLocked prices a strategy order of side against the best level of
+// each leg book. It returns the legs, the implied strategy price and how
+// many strategy units, up to upTo, every leg can fill at those levels. The
+// caller holds m.mu.
+func (m *MatchingEngine) impliedLocked(inst instruments.Instrument, side common.Side, upTo int64) ([]impliedLeg, int64, int64, bool) {
+	legs := make([]impliedLeg, 0, len(inst.Legs))
+	levels := make([]*orderbook.PriceLevel, 0, len(inst.Legs))
+	price, units := int64(0), upTo
+	for _, leg := range inst.Legs {
+		book, ok := m.books[leg.Symbol]
+		if !ok || book.Status != common.TradingStatusOpen {
+			return nil, 0, 0, false
+		}
+		l := impliedLeg{book: book, side: side, ratio: leg.Ratio}
+		if leg.Ratio < 0 {
+			l.side, l.ratio = opposite(side), -leg.Ratio
+		}
+		resting := book.Asks
+		if l.side == common.SideSell {
+			resting = book.Bids
+		}
+		level, ok := resting.BestLevel()
+		if !ok || !m.priceBandLocked(book).allows(level.Price) {
+			return nil, 0, 0, false
+		}
+		l.price = level.Price
+
+		total := int64(0)
+		for _, r := range level.Orders {
+			total += r.Quantity - r.FilledQty
+		}
+		units = min(units, total/l.ratio)
+		price += leg.Ratio * level.Price
+		legs = append(legs, l)
+		levels = append(levels, level)
+	}
+
+	// All-or-none orders can leave a level short of its total; shrink until
+	// every leg allocates in full.
+	for changed := true; changed && units > 0; {
+		changed = false
+		for i, l := range legs {
+			got := int64(0)
+			for _, f := range allocate(m.algorithmLocked(l.book.Symbol), levels[i], units*l.ratio) {
+				got += f.Qty
+			}
+			if got < units*l.ratio {
+				units, changed = got/l.ratio, true
+			}
+		}
+	}
+	return legs, price, units, units > 0
+}
+
+// fillLegsLocked executes units of a strategy order as one leg order per
+// leg, each taking its leg's best level as the aggressor. Leg orders carry
+// the strategy order as parent and finish filled. With the ledger enabled
+// every leg order reserves its own funds first, and the strategy order
+// releases its share for the units; if a leg cannot be funded nothing
+// trades and it reports false. The caller holds m.mu and has checked with
+// impliedLocked that every leg fills.
+func (m *MatchingEngine) fillLegsLocked(o *common.Order, legs []impliedLeg, units int64) ([]*common.Trade, bool) {
+	children := make([]*common.Order, len(legs))
+	for i, l := range legs {
+		children[i] = m.createOrder(&common.Order{
+			Account:  o.Account,
+			Symbol:   l.book.Symbol,
+			Side:     l.side,
+			Type:     common.OrderTypeLimit,
+			Price:    l.price,
+			Quantity: units * l.ratio,
+		})
+		children[i].ParentOrderID = o.ID
+		if err := m.reserveFunds(l.book, children[i]); err != nil {
+			for _, child := range children[:i] {
+				m.releaseFunds(child)
+			}
+			return nil, false
+		}
+	}
+
+	var trades []*common.Trade
+	for i, l := range legs {
+		child := children[i]
+		filled, _ := m.execute(l.book, child, priceBand{}, func(price int64) bool { return price == l.price })
+		child.Status = common.OrderStatusFilled
+		m.orders[child.ID] = child
+		trades = append(trades, filled...)
+	}
+	o.FilledQty += units
+	m.releaseUnitsLocked(o, units)
+	if o.FilledQty == o.Quantity {
+		m.releaseFunds(o)
+	}
+
+	for _, l := range legs {
+		m.processContingentLocked(l.book)
+	}
+	return trades, true
+}
+
+// improves reports whether price a is strictly better than b for an order
+// of side.
+func improves(side common.Side, a, b int64) bool {
+	if side == common.SideBuy {
+		return a < b
+	}
+	return a > b
+}
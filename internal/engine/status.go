@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+	"order-matching-engine/internal/orderbook"
+)
+
+var (
+	ErrSymbolNotOpen       = errors.New("symbol is not open for trading")
+	ErrCancelNotAllowed    = errors.New("cancel not allowed in current trading state")
+	ErrInvalidTransition   = errors.New("invalid trading status transition")
+	ErrInvalidTradingState = errors.New("invalid trading status")
+)
+
+// statusTransitions lists the states reachable from each state.
+var statusTransitions = map[common.TradingStatus][]common.TradingStatus{
+	common.TradingStatusPreOpen: {
+		common.TradingStatusOpen, common.TradingStatusHalted,
+		common.TradingStatusCancelOnly, common.TradingStatusClosed,
+	},
+	common.TradingStatusOpen: {
+		common.TradingStatusHalted, common.TradingStatusVolatilityAuction, common.TradingStatusPreClose,
+		common.TradingStatusCancelOnly, common.TradingStatusClosed,
+	},
+	common.TradingStatusHalted: {
+		common.TradingStatusPreOpen, common.TradingStatusOpen,
+		common.TradingStatusCancelOnly, common.TradingStatusClosed,
+	},
+	common.TradingStatusVolatilityAuction: {
+		common.TradingStatusOpen, common.TradingStatusHalted,
+		common.TradingStatusCancelOnly, common.TradingStatusClosed,
+	},
+	common.TradingStatusPreClose: {
+		common.TradingStatusOpen, common.TradingStatusHalted,
+		common.TradingStatusCancelOnly, common.TradingStatusClosed,
+	},
+	common.TradingStatusCancelOnly: {
+		common.TradingStatusPreOpen, common.TradingStatusOpen,
+		common.TradingStatusHalted, common.TradingStatusClosed,
+	},
+	common.TradingStatusClosed: {
+		common.TradingStatusPreOpen, common.TradingStatusOpen,
+	},
+}
+
+// StatusChange is the audit record of one trading status transition.
+type StatusChange struct {
+	Symbol    string               `json:"symbol"`
+	From      common.TradingStatus `json:"from"`
+	To        common.TradingStatus `json:"to"`
+	Reason    string               `json:"reason"`
+	Timestamp int64                `json:"timestamp"`
+}
+
+func canTransition(from, to common.TradingStatus) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTradingStatus moves a symbol to a new trading state, records the
+// transition with its reason and notifies listeners.
+func (m *MatchingEngine) SetTradingStatus(symbol string, to common.TradingStatus, reason string) (*StatusChange, error) {
+	if !to.Valid() {
+		return nil, ErrInvalidTradingState
+	}
+	if m.Instruments != nil {
+		inst, ok := m.Instruments.Get(symbol)
+		if !ok {
+			return nil, instruments.ErrUnknownSymbol
+		}
+		// Expired derivatives never trade again.
+		if inst.Expired(time.Now()) && to != common.TradingStatusClosed {
+			return nil, instruments.ErrExpired
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.setStatusLocked(m.ensureBook(symbol), to, reason)
+}
+
+// setStatusLocked performs a validated transition. Entering OPEN or CLOSED
+// first uncrosses the book, so orders accumulated in a call period (or
+// left crossed by a halt) execute at a single clearing price. The caller
+// holds m.mu.
+func (m *MatchingEngine) setStatusLocked(book *orderbook.OrderBook, to common.TradingStatus, reason string) (*StatusChange, error) {
+	change, _, err := m.transitionLocked(book, to, reason)
+	return change, err
+}
+
+// transitionLocked is setStatusLocked that also returns the uncross it
+// ran, if any. The caller holds m.mu.
+func (m *MatchingEngine) transitionLocked(book *orderbook.OrderBook, to common.TradingStatus, reason string) (*StatusChange, *AuctionResult, error) {
+	if !canTransition(book.Status, to) {
+		return nil, nil, ErrInvalidTransition
+	}
+
+	// Any transition supersedes a pending volatility auction timer.
+	if t, ok := m.volatilityTimers[book.Symbol]; ok {
+		t.Stop()
+		delete(m.volatilityTimers, book.Symbol)
+	}
+
+	var res *AuctionResult
+	if to == common.TradingStatusOpen || to == common.TradingStatusClosed {
+		res = m.uncrossLocked(book)
+	}
+
+	change := &StatusChange{
+		Symbol:    book.Symbol,
+		From:      book.Status,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	book.Status = to
+	m.statusLog[book.Symbol] = append(m.statusLog[book.Symbol], change)
+
+	m.emit(Event{Type: EventStatusChange, Symbol: book.Symbol, Status: change})
+	if to.InCall() {
+		m.emitAuctionLocked(book)
+	}
+	// Stops triggered by the uncross fire once trading continues.
+	m.processContingentLocked(book)
+	return change, res, nil
+}
+
+// TradingStatus returns the current state of a symbol. Symbols without a
+// book yet report the state their book would start in.
+func (m *MatchingEngine) TradingStatus(symbol string) common.TradingStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if book, ok := m.books[symbol]; ok {
+		return book.Status
+	}
+	return m.initialStatus(symbol)
+}
+
+// StatusHistory returns the audit trail of status changes for a symbol.
+func (m *MatchingEngine) StatusHistory(symbol string) []StatusChange {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]StatusChange, 0, len(m.statusLog[symbol]))
+	for _, c := range m.statusLog[symbol] {
+		out = append(out, *c)
+	}
+	return out
+}
+
+// initialStatus is the state a new book starts in: the instrument's
+// configured status, or OPEN.
+func (m *MatchingEngine) initialStatus(symbol string) common.TradingStatus {
+	if m.Instruments != nil {
+		if inst, ok := m.Instruments.Get(symbol); ok {
+			return inst.Status
+		}
+	}
+	return common.TradingStatusOpen
+}
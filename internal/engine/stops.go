@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+// parkStopLocked holds a stop order off-book until it triggers. Stops do not
+// reserve funds before they trigger. The caller holds m.mu.
+func (m *MatchingEngine) parkStopLocked(book *orderbook.OrderBook, o *common.Order) {
+	o.Status = common.OrderStatusPending
+	if o.Type == common.OrderTypeTrailingStop && book.LastPrice > 0 {
+		m.trailStopLocked(o, book.LastPrice)
+	}
+	m.stops[book.Symbol] = append(m.stops[book.Symbol], o)
+	m.orders[o.ID] = o
+	m.trackOpen(o)
+}
+
+// unparkStopLocked removes a stop from the symbol's pending list. It
+// reports whether the stop was parked. The caller holds m.mu.
+func (m *MatchingEngine) unparkStopLocked(o *common.Order) bool {
+	stops := m.stops[o.Symbol]
+	for i, s := range stops {
+		if s == o {
+			m.stops[o.Symbol] = append(stops[:i], stops[i+1:]...)
+			if len(m.stops[o.Symbol]) == 0 {
+				delete(m.stops, o.Symbol)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// stopTriggered reports whether the last trade price reached the stop: at
+// or above it for a buy, at or below it for a sell.
+func stopTriggered(o *common.Order, last int64) bool {
+	if last <= 0 || o.StopPrice <= 0 {
+		return false
+	}
+	if o.Side == common.SideBuy {
+		return last >= o.StopPrice
+	}
+	return last <= o.StopPrice
+}
+
+// triggerStopsLocked fires every parked stop of the book whose trigger the
+// last trade price has reached, in arrival order. Stops only trigger in
+// continuous trading. It reports whether any stop fired. The caller holds m.mu.
+func (m *MatchingEngine) triggerStopsLocked(book *orderbook.OrderBook) bool {
+	if book.Status != common.TradingStatusOpen {
+		return false
+	}
+
+	var fired []*common.Order
+	for _, o := range m.stops[book.Symbol] {
+		if stopTriggered(o, book.LastPrice) {
+			fired = append(fired, o)
+		}
+	}
+	for _, o := range fired {
+		m.unparkStopLocked(o)
+		m.fireStopLocked(book, o)
+	}
+	return len(fired) > 0
+}
+
+// fireStopLocked enters a triggered stop into the book: STOP as a
+// fill-and-kill market order, STOP_LIMIT as a limit order, TRAILING_STOP as
+// either depending on its limit offset. Siblings in an
+// order group are cancelled first. A stop the book rejects is cancelled.
+// The caller holds m.mu.
+func (m *MatchingEngine) fireStopLocked(book *orderbook.OrderBook, o *common.Order) {
+	if g, ok := m.groups[o.GroupID]; ok && g.State == GroupActive {
+		g.TriggeredID = o.ID
+		m.cancelSiblingsLocked(book, g, o, CancelReasonOCO)
+	}
+
+	o.Triggered = true
+	o.Status = common.OrderStatusAccepted
+	switch {
+	case o.Type == common.OrderTypeStopLimit:
+		o.Type = common.OrderTypeLimit
+	case o.Type == common.OrderTypeTrailingStop && o.LimitOffset > 0:
+		o.Type = common.OrderTypeLimit
+		o.Price = m.trailLimitPrice(o)
+	default:
+		o.Type = common.OrderTypeMarket
+		o.MarketMode = common.MarketModeFillAndKill
+	}
+	m.untrackOpen(o)
+
+	if _, err := m.placeLocked(o); err != nil {
+		o.Status = common.OrderStatusCancelled
+		m.touchGroupLocked(o)
+		m.emitCancelLocked(o, o.Quantity-o.FilledQty, CancelReasonRejected)
+	}
+}
+
+// emitCancelLocked publishes the cancellation of qty of an order. The
+// caller holds m.mu.
+func (m *MatchingEngine) emitCancelLocked(o *common.Order, qty int64, reason string) {
+	m.emit(Event{Type: EventCancel, Symbol: o.Symbol, Cancel: &Cancellation{
+		OrderID:      o.ID,
+		Account:      o.Account,
+		Symbol:       o.Symbol,
+		Side:         o.Side,
+		Price:        o.Price,
+		CancelledQty: qty,
+		Reason:       reason,
+		Timestamp:    time.Now().UnixMilli(),
+		Hidden:       o.Hidden || o.Dark,
+	}})
+}
+
+// processContingentLocked settles everything that reacts to fills and
+// cancels: order groups are reconciled, triggered stops fire, pegged orders
+// follow the top of the book, crossing all-or-none orders are re-checked
+// and the midpoint book crosses, until nothing changes. Nested calls return
+// immediately; the outermost call finishes the work. The caller holds m.mu.
+func (m *MatchingEngine) processContingentLocked(book *orderbook.OrderBook) {
+	if m.contingentBusy {
+		return
+	}
+	m.contingentBusy = true
+	defer func() { m.contingentBusy = false }()
+
+	for {
+		dirty := m.dirtyGroups
+		m.dirtyGroups = nil
+		for _, id := range dirty {
+			if g, ok := m.groups[id]; ok {
+				m.reconcileGroupLocked(g)
+			}
+		}
+		fired := m.triggerStopsLocked(book)
+		m.repegLocked(book)
+		filled := m.crossAONLocked(book)
+		crossed := m.crossDarkLocked(book)
+		if !fired && !filled && !crossed && len(m.dirtyGroups) == 0 {
+			return
+		}
+	}
+}
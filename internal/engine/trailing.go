@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+// validateTrail checks a trailing stop request. The engine owns the
+// trigger, so StopPrice must be empty, and exactly one trail distance is set.
+func validateTrail(req *common.Order) error {
+	if req.StopPrice != 0 || req.TrailOffset < 0 || req.TrailBps < 0 || req.LimitOffset < 0 {
+		return ErrInvalidOrderData
+	}
+	if (req.TrailOffset > 0) == (req.TrailBps > 0) || req.TrailBps >= 10_000 {
+		return ErrInvalidOrderData
+	}
+	return nil
+}
+
+// trailLocked moves the triggers of the book's untriggered trailing stops
+// after a trade at price. The caller holds m.mu.
+func (m *MatchingEngine) trailLocked(book *orderbook.OrderBook, price int64) {
+	for _, o := range m.stops[book.Symbol] {
+		if o.Type == common.OrderTypeTrailingStop {
+			m.trailStopLocked(o, price)
+		}
+	}
+}
+
+// trailStopLocked tightens a trailing stop's trigger towards price: a sell
+// trigger only rises, a buy trigger only falls. Triggers are rounded to the
+// tick away from the market.
+func (m *MatchingEngine) trailStopLocked(o *common.Order, price int64) {
+	dist := o.TrailOffset
+	if o.TrailBps > 0 {
+		dist = price * o.TrailBps / 10_000
+	}
+	tick := m.tickSize(o.Symbol)
+
+	if o.Side == common.SideSell {
+		trigger := (price - dist) / tick * tick
+		if trigger > o.StopPrice {
+			o.StopPrice = trigger
+		}
+		return
+	}
+	trigger := (price + dist + tick - 1) / tick * tick
+	if o.StopPrice == 0 || trigger < o.StopPrice {
+		o.StopPrice = trigger
+	}
+}
+
+// trailLimitPrice is the limit a triggered trailing stop enters at,
+// LimitOffset beyond its trigger and never below one tick.
+func (m *MatchingEngine) trailLimitPrice(o *common.Order) int64 {
+	if o.Side == common.SideBuy {
+		return o.StopPrice + o.LimitOffset
+	}
+	if p := o.StopPrice - o.LimitOffset; p > 0 {
+		return p
+	}
+	return m.tickSize(o.Symbol)
+}
+
+// tickSize is the symbol's minimum price increment, 1 without an
+// instrument definition.
+func (m *MatchingEngine) tickSize(symbol string) int64 {
+	if m.Instruments != nil {
+		if inst, ok := m.Instruments.Get(symbol); ok && inst.TickSize > 0 {
+			return inst.TickSize
+		}
+	}
+	return 1
+}
@@ -0,0 +1,293 @@
+package fees
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrInvalidRates = errors.New("fee rates must lie within -10000..10000 bps with maker at most taker")
+
+// MaxBps bounds fee rates both ways: a fee or rebate never exceeds the
+// notional it is charged on.
+const MaxBps = 10000
+
+// CollectorAccount receives fees and pays rebates when the ledger is enabled.
+const CollectorAccount = "FEES"
+
+// volumeWindow is the look-back used to pick a volume tier.
+const volumeWindow = 30 * 24 * time.Hour
+
+// Role is the liquidity role of one side of a trade.
+type Role string
+
+const (
+	RoleMaker Role = "MAKER"
+	RoleTaker Role = "TAKER"
+)
+
+// Tier applies once an account's 30-day traded notional reaches MinVolume.
+// Negative rates are rebates.
+type Tier struct {
+	MinVolume int64 `json:"min_volume"` // cents
+	MakerBps  int64 `json:"maker_bps"`
+	TakerBps  int64 `json:"taker_bps"`
+}
+
+// Schedule is a list of volume tiers.
+type Schedule struct {
+	Tiers []Tier `json:"tiers"`
+}
+
+// Override replaces the tiered rates for a single account.
+type Override struct {
+	MakerBps int64 `json:"maker_bps"`
+	TakerBps int64 `json:"taker_bps"`
+}
+
+// Record is the fee charged to one side of one trade.
+type Record struct {
+	TradeID   string `json:"trade_id"`
+	Account   string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+	Role      Role   `json:"role"`
+	Notional  int64  `json:"notional"`
+	Bps       int64  `json:"bps"`
+	Fee       int64  `json:"fee"` // negative for rebates
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Report aggregates an account's fees over [From, To).
+type Report struct {
+	Account   string    `json:"account_id"`
+	From      int64     `json:"from"`
+	To        int64     `json:"to"`
+	Trades    int       `json:"trades"`
+	Notional  int64     `json:"notional"`
+	MakerFees int64     `json:"maker_fees"`
+	TakerFees int64     `json:"taker_fees"`
+	Rebates   int64     `json:"rebates"`
+	NetFees   int64     `json:"net_fees"`
+	Records   []*Record `json:"records"`
+}
+
+// Manager computes fees at trade time and keeps the records and traded
+// volume they depend on.
+type Manager struct {
+	mu        sync.RWMutex
+	schedule  Schedule
+	overrides map[string]Override         // account -> rates
+	volume    map[string]map[string]int64 // account -> UTC day -> notional
+	records   map[string][]*Record        // account -> records, oldest first
+
+	now func() time.Time
+}
+
+func NewManager(s Schedule) *Manager {
+	m := &Manager{
+		overrides: make(map[string]Override),
+		volume:    make(map[string]map[string]int64),
+		records:   make(map[string][]*Record),
+		now:       time.Now,
+	}
+	m.SetSchedule(s)
+	return m
+}
+
+// validRates reports whether a maker and taker rate pair is usable.
+func validRates(makerBps, takerBps int64) bool {
+	return makerBps >= -MaxBps && takerBps <= MaxBps && makerBps <= takerBps
+}
+
+// SetSchedule replaces the tier table. Every tier's rates must be valid.
+func (m *Manager) SetSchedule(s Schedule) error {
+	for _, t := range s.Tiers {
+		if !validRates(t.MakerBps, t.TakerBps) {
+			return ErrInvalidRates
+		}
+	}
+	tiers := append([]Tier(nil), s.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinVolume < tiers[j].MinVolume })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedule = Schedule{Tiers: tiers}
+	return nil
+}
+
+func (m *Manager) Schedule() Schedule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return Schedule{Tiers: append([]Tier(nil), m.schedule.Tiers...)}
+}
+
+func (m *Manager) SetOverride(account string, o Override) error {
+	if !validRates(o.MakerBps, o.TakerBps) {
+		return ErrInvalidRates
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overrides[account] = o
+	return nil
+}
+
+func (m *Manager) ClearOverride(account string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.overrides, account)
+}
+
+// Rates returns the maker and taker rates currently applying to an account.
+func (m *Manager) Rates(account string) (makerBps, takerBps int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ratesLocked(account)
+}
+
+func (m *Manager) ratesLocked(account string) (int64, int64) {
+	if o, ok := m.overrides[account]; ok {
+		return o.MakerBps, o.TakerBps
+	}
+	vol := m.volumeLocked(account)
+	var maker, taker int64
+	for _, t := range m.schedule.Tiers {
+		if vol < t.MinVolume {
+			break
+		}
+		maker, taker = t.MakerBps, t.TakerBps
+	}
+	return maker, taker
+}
+
+// Volume30d returns the account's traded notional over the last 30 days.
+func (m *Manager) Volume30d(account string) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.volumeLocked(account)
+}
+
+func (m *Manager) volumeLocked(account string) int64 {
+	cutoff := m.now().Add(-volumeWindow).UTC().Format("2006-01-02")
+	total := int64(0)
+	for day, v := range m.volume[account] {
+		if day > cutoff {
+			total += v
+		}
+	}
+	return total
+}
+
+// MaxFee is the largest fee the account could pay on the given notional at
+// its current rates, used to size fund reservations.
+func (m *Manager) MaxFee(account string, notional int64) int64 {
+	maker, taker := m.Rates(account)
+	return MaxFeeAt(notional, maker, taker)
+}
+
+// MaxFeeAt is the largest fee payable on notional at the given rates.
+func MaxFeeAt(notional, makerBps, takerBps int64) int64 {
+	bps := max(makerBps, takerBps)
+	if bps <= 0 {
+		return 0
+	}
+	return (notional*bps + 9999) / 10000
+}
+
+// Charge computes and records the fee for one side of a trade at the
+// account's current rates and adds the notional to the account's volume.
+// Fees round down and rebates round toward zero, so the sum over partial
+// fills never exceeds MaxFee.
+func (m *Manager) Charge(tradeID, account, symbol string, role Role, notional, ts int64) int64 {
+	if account == "" {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	maker, taker := m.ratesLocked(account)
+	return m.chargeLocked(tradeID, account, symbol, role, maker, taker, notional, ts)
+}
+
+// ChargeAt is Charge at the given rates, for orders that locked in the
+// rates in force when they were entered.
+func (m *Manager) ChargeAt(tradeID, account, symbol string, role Role, makerBps, takerBps, notional, ts int64) int64 {
+	if account == "" {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chargeLocked(tradeID, account, symbol, role, makerBps, takerBps, notional, ts)
+}
+
+func (m *Manager) chargeLocked(tradeID, account, symbol string, role Role, maker, taker, notional, ts int64) int64 {
+	bps := taker
+	if role == RoleMaker {
+		bps = maker
+	}
+	fee := notional * bps / 10000
+
+	m.records[account] = append(m.records[account], &Record{
+		TradeID:   tradeID,
+		Account:   account,
+		Symbol:    symbol,
+		Role:      role,
+		Notional:  notional,
+		Bps:       bps,
+		Fee:       fee,
+		Timestamp: ts,
+	})
+
+	days := m.volume[account]
+	if days == nil {
+		days = make(map[string]int64)
+		m.volume[account] = days
+	}
+	days[time.UnixMilli(ts).UTC().Format("2006-01-02")] += notional
+	m.pruneLocked(days)
+
+	return fee
+}
+
+// pruneLocked drops volume buckets that fell out of the window.
+func (m *Manager) pruneLocked(days map[string]int64) {
+	cutoff := m.now().Add(-volumeWindow).UTC().Format("2006-01-02")
+	for day := range days {
+		if day <= cutoff {
+			delete(days, day)
+		}
+	}
+}
+
+// Report summarises an account's fee records with timestamps in [from, to).
+// A zero to means "until now".
+func (m *Manager) Report(account string, from, to int64) *Report {
+	if to == 0 {
+		to = m.now().UnixMilli() + 1
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rep := &Report{Account: account, From: from, To: to, Records: make([]*Record, 0)}
+	for _, r := range m.records[account] {
+		if r.Timestamp < from || r.Timestamp >= to {
+			continue
+		}
+		rep.Trades++
+		rep.Notional += r.Notional
+		switch {
+		case r.Fee < 0:
+			rep.Rebates += -r.Fee
+		case r.Role == RoleMaker:
+			rep.MakerFees += r.Fee
+		default:
+			rep.TakerFees += r.Fee
+		}
+		rep.NetFees += r.Fee
+		cp := *r
+		rep.Records = append(rep.Records, &cp)
+	}
+	return rep
+}
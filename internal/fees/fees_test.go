@@ -0,0 +1,70 @@
+package fees_test
+
+import (
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/fees"
+)
+
+func TestTieredRates(t *testing.T) {
+	m := fees.NewManager(fees.Schedule{Tiers: []fees.Tier{
+		{MinVolume: 100_000_000, MakerBps: -1, TakerBps: 5},
+		{MinVolume: 0, MakerBps: 10, TakerBps: 20},
+	}})
+
+	if maker, taker := m.Rates("A"); maker != 10 || taker != 20 {
+		t.Fatalf("expected base tier, got %d/%d", maker, taker)
+	}
+
+	now := time.Now().UnixMilli()
+	if fee := m.Charge("t1", "A", "AAPL", fees.RoleTaker, 100_000_000, now); fee != 200_000 {
+		t.Fatalf("expected taker fee 200000, got %d", fee)
+	}
+
+	// 30-day volume now reaches the second tier: makers earn a rebate.
+	if fee := m.Charge("t2", "A", "AAPL", fees.RoleMaker, 1_000_000, now); fee != -100 {
+		t.Fatalf("expected rebate -100, got %d", fee)
+	}
+}
+
+func TestOverrideAndReport(t *testing.T) {
+	m := fees.NewManager(fees.Schedule{Tiers: []fees.Tier{{MakerBps: 10, TakerBps: 20}}})
+	m.SetOverride("VIP", fees.Override{MakerBps: -2, TakerBps: 3})
+
+	m.Charge("t1", "VIP", "AAPL", fees.RoleMaker, 1_000_000, 1000)
+	m.Charge("t2", "VIP", "AAPL", fees.RoleTaker, 1_000_000, 2000)
+	m.Charge("t3", "VIP", "AAPL", fees.RoleTaker, 1_000_000, 3000)
+
+	rep := m.Report("VIP", 1000, 3000)
+	if rep.Trades != 2 || rep.Rebates != 200 || rep.TakerFees != 300 || rep.NetFees != 100 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	m.ClearOverride("VIP")
+	if maker, _ := m.Rates("VIP"); maker != 10 {
+		t.Fatalf("override not cleared")
+	}
+}
+
+func TestRatesValidated(t *testing.T) {
+	m := fees.NewManager(fees.Schedule{})
+	for _, o := range []fees.Override{
+		{MakerBps: 0, TakerBps: 10001},
+		{MakerBps: -10001, TakerBps: 0},
+		{MakerBps: 10, TakerBps: 5},
+	} {
+		if err := m.SetOverride("A", o); err != fees.ErrInvalidRates {
+			t.Fatalf("%+v: expected ErrInvalidRates, got %v", o, err)
+		}
+		if err := m.SetSchedule(fees.Schedule{Tiers: []fees.Tier{{MakerBps: o.MakerBps, TakerBps: o.TakerBps}}}); err != fees.ErrInvalidRates {
+			t.Fatalf("%+v: expected ErrInvalidRates, got %v", o, err)
+		}
+	}
+	if maker, taker := m.Rates("A"); maker != 0 || taker != 0 {
+		t.Fatalf("rejected rates must not apply, got %d/%d", maker, taker)
+	}
+	if err := m.SetOverride("A", fees.Override{MakerBps: -10000, TakerBps: 10000}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+}
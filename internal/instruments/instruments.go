@@ -0,0 +1,374 @@
+package instruments
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/matching"
+)
+
+var (
+	ErrUnknownSymbol     = errors.New("unknown symbol")
+	ErrInvalidInstrument = errors.New("invalid instrument definition")
+	ErrInvalidTick       = errors.New("price is not a multiple of the tick size")
+	ErrInvalidLot        = errors.New("quantity is not a multiple of the lot size")
+	ErrQuantityTooSmall  = errors.New("quantity below instrument minimum")
+	ErrQuantityTooLarge  = errors.New("quantity above instrument maximum")
+	ErrExpired           = errors.New("instrument has expired")
+	ErrInstrumentInUse   = errors.New("instrument is a leg of a strategy")
+)
+
+// Type is the kind of contract an instrument trades.
+type Type string
+
+const (
+	TypeSpot   Type = "SPOT"
+	TypeFuture Type = "FUTURE"
+	TypePerp   Type = "PERPETUAL" // a future that never expires
+	TypeOption Type = "OPTION"
+)
+
+// OptionType is the right an option grants.
+type OptionType string
+
+const (
+	OptionCall OptionType = "CALL"
+	OptionPut  OptionType = "PUT"
+)
+
+// BandAction is what happens when a trade would breach a price band.
+type BandAction string
+
+const (
+	BandActionReject  BandAction = "REJECT"  // cancel the remainder of the order
+	BandActionHalt    BandAction = "HALT"    // halt the symbol
+	BandActionAuction BandAction = "AUCTION" // start a timed volatility auction
+)
+
+// defaultPriceScale is the number of decimals in a price when none is set.
+const defaultPriceScale = 2
+
+// defaultVolatilityAuctionMs is the volatility auction length when none is set.
+const defaultVolatilityAuctionMs = 5 * 60 * 1000
+
+// Leg is one component of a strategy. Buying one unit of the strategy buys
+// Ratio units of Symbol, or sells -Ratio units when Ratio is negative.
+type Leg struct {
+	Symbol string `json:"symbol"`
+	Ratio  int64  `json:"ratio"`
+}
+
+// Instrument describes a tradable symbol and its granularity rules. Prices
+// are integers in units of 10^-PriceScale of the quote asset. PriceScale
+// defaults to 2 when unset; set it to 0 for integer-priced instruments.
+type Instrument struct {
+	Symbol     string               `json:"symbol"`
+	BaseAsset  string               `json:"base_asset"`
+	QuoteAsset string               `json:"quote_asset"`
+	TickSize   int64                `json:"tick_size"` // minimum price increment
+	LotSize    int64                `json:"lot_size"`  // minimum quantity increment
+	MinQty     int64                `json:"min_qty"`
+	MaxQty     int64                `json:"max_qty"` // 0 means unlimited
+	PriceScale *int                 `json:"price_scale,omitempty"`
+	Status     common.TradingStatus `json:"status"` // state the symbol's book starts in
+
+	// Derivatives. Futures and options stop trading at Expiry (unix ms) and
+	// then settle. Notional is price x quantity x ContractMultiplier. Options
+	// are on Underlying, which they settle against at their intrinsic value.
+	Type               Type       `json:"type"`
+	Expiry             int64      `json:"expiry,omitempty"`
+	ContractMultiplier int64      `json:"contract_multiplier"`
+	Underlying         string     `json:"underlying,omitempty"`
+	Strike             int64      `json:"strike,omitempty"`
+	OptionType         OptionType `json:"option_type,omitempty"`
+
+	// Price bands in basis points around the static reference price (last
+	// auction or first trade) and the last trade price. Zero disables a band.
+	StaticBandBps       int64      `json:"static_band_bps"`
+	DynamicBandBps      int64      `json:"dynamic_band_bps"`
+	BandAction          BandAction `json:"band_action"`
+	VolatilityAuctionMs int64      `json:"volatility_auction_ms"`
+
+	// Negotiated block trades must be at least MinBlockQty and priced within
+	// BlockBandBps of the book; zero uses the engine default band.
+	MinBlockQty  int64 `json:"min_block_qty"`
+	BlockBandBps int64 `json:"block_band_bps"`
+
+	// Margin in basis points of position value at the mark price. A non-zero
+	// InitialMarginBps makes the instrument margined: orders need initial
+	// margin and accounts below maintenance margin are liquidated.
+	InitialMarginBps     int64 `json:"initial_margin_bps,omitempty"`
+	MaintenanceMarginBps int64 `json:"maintenance_margin_bps,omitempty"`
+
+	// Matching selects how an incoming order is allocated across the orders
+	// at a price level. The zero value is price-time FIFO.
+	Matching matching.Config `json:"matching"`
+
+	// Legs makes the instrument a strategy, such as a calendar spread, priced
+	// as the ratio-weighted sum of its leg prices. With ImpliedIn, strategy
+	// orders also trade against liquidity implied by the leg books.
+	Legs      []Leg `json:"legs,omitempty"`
+	ImpliedIn bool  `json:"implied_in,omitempty"`
+}
+
+// validateContract checks the derivative terms against the instrument type.
+func (i *Instrument) validateContract() error {
+	if i.ContractMultiplier < 0 || i.Expiry < 0 || i.Strike < 0 {
+		return ErrInvalidInstrument
+	}
+	switch i.Type {
+	case TypeSpot:
+		if i.Expiry != 0 || i.ContractMultiplier != 1 || i.Underlying != "" {
+			return ErrInvalidInstrument
+		}
+	case TypeFuture:
+		if i.Expiry == 0 {
+			return ErrInvalidInstrument
+		}
+	case TypePerp:
+		if i.Expiry != 0 || i.Underlying != "" {
+			return ErrInvalidInstrument
+		}
+	case TypeOption:
+		if i.Expiry == 0 || i.Strike == 0 || i.Underlying == "" || i.Underlying == i.Symbol ||
+			(i.OptionType != OptionCall && i.OptionType != OptionPut) {
+			return ErrInvalidInstrument
+		}
+		return nil
+	default:
+		return ErrInvalidInstrument
+	}
+	if i.Strike != 0 || i.OptionType != "" {
+		return ErrInvalidInstrument
+	}
+	return nil
+}
+
+// Expired reports whether a derivative has reached its expiry.
+func (i *Instrument) Expired(now time.Time) bool {
+	return i.Expiry > 0 && now.UnixMilli() >= i.Expiry
+}
+
+// Margined reports whether positions in the instrument are held on margin.
+func (i *Instrument) Margined() bool {
+	return i.InitialMarginBps > 0
+}
+
+// IsStrategy reports whether the instrument is a multi-leg strategy.
+func (i *Instrument) IsStrategy() bool {
+	return len(i.Legs) > 0
+}
+
+// normalize fills defaults and validates the definition.
+func (i *Instrument) normalize() error {
+	if i.Symbol == "" {
+		return ErrInvalidInstrument
+	}
+	if i.BaseAsset == "" {
+		i.BaseAsset = i.Symbol
+	}
+	if i.QuoteAsset == "" {
+		i.QuoteAsset = "USD"
+	}
+	if i.TickSize == 0 {
+		i.TickSize = 1
+	}
+	if i.LotSize == 0 {
+		i.LotSize = 1
+	}
+	if i.PriceScale == nil {
+		scale := defaultPriceScale
+		i.PriceScale = &scale
+	}
+	if i.Status == "" {
+		i.Status = common.TradingStatusOpen
+	}
+	if i.Type == "" {
+		i.Type = TypeSpot
+	}
+	if i.ContractMultiplier == 0 {
+		i.ContractMultiplier = 1
+	}
+	if i.BandAction == "" {
+		i.BandAction = BandActionReject
+	}
+	if i.BandAction == BandActionAuction && i.VolatilityAuctionMs == 0 {
+		i.VolatilityAuctionMs = defaultVolatilityAuctionMs
+	}
+	if i.TickSize < 0 || i.LotSize < 0 || i.MinQty < 0 || i.MaxQty < 0 || *i.PriceScale < 0 {
+		return ErrInvalidInstrument
+	}
+	if i.StaticBandBps < 0 || i.DynamicBandBps < 0 || i.VolatilityAuctionMs < 0 ||
+		i.MinBlockQty < 0 || i.BlockBandBps < 0 {
+		return ErrInvalidInstrument
+	}
+	if i.InitialMarginBps < 0 || i.MaintenanceMarginBps < 0 || i.MaintenanceMarginBps > i.InitialMarginBps ||
+		(i.Margined() && (i.MaintenanceMarginBps == 0 || len(i.Legs) > 0)) {
+		return ErrInvalidInstrument
+	}
+	switch i.BandAction {
+	case BandActionReject, BandActionHalt, BandActionAuction:
+	default:
+		return ErrInvalidInstrument
+	}
+	if i.Matching.Validate() != nil {
+		return ErrInvalidInstrument
+	}
+	if i.MaxQty > 0 && i.MaxQty < i.MinQty {
+		return ErrInvalidInstrument
+	}
+	if !i.Status.Valid() {
+		return ErrInvalidInstrument
+	}
+	if i.ImpliedIn && !i.IsStrategy() {
+		return ErrInvalidInstrument
+	}
+	if err := i.validateContract(); err != nil {
+		return err
+	}
+	if i.IsStrategy() {
+		if len(i.Legs) < 2 {
+			return ErrInvalidInstrument
+		}
+		seen := make(map[string]bool, len(i.Legs))
+		for _, leg := range i.Legs {
+			if leg.Symbol == "" || leg.Symbol == i.Symbol || leg.Ratio == 0 || seen[leg.Symbol] {
+				return ErrInvalidInstrument
+			}
+			seen[leg.Symbol] = true
+		}
+	}
+	return nil
+}
+
+// CheckOrder validates price and quantity granularity. Market orders carry
+// no price, so only their quantity is checked.
+func (i *Instrument) CheckOrder(o *common.Order) error {
+	if o.Price%i.TickSize != 0 || o.StopPrice%i.TickSize != 0 ||
+		o.TrailOffset%i.TickSize != 0 || o.LimitOffset%i.TickSize != 0 || o.PegOffset%i.TickSize != 0 {
+		return ErrInvalidTick
+	}
+	if o.Quantity%i.LotSize != 0 {
+		return ErrInvalidLot
+	}
+	if o.Quantity < i.MinQty {
+		return ErrQuantityTooSmall
+	}
+	if i.MaxQty > 0 && o.Quantity > i.MaxQty {
+		return ErrQuantityTooLarge
+	}
+	return nil
+}
+
+// Registry holds the instrument definitions known to the engine.
+type Registry struct {
+	mu          sync.RWMutex
+	instruments map[string]*Instrument
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		instruments: make(map[string]*Instrument),
+	}
+}
+
+// Load reads a JSON array of instruments from a file.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var defs []Instrument
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+
+	r := NewRegistry()
+	for _, def := range defs {
+		if _, err := r.Upsert(def); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Upsert validates and stores an instrument, returning the stored copy.
+// Strategy legs must already be registered and cannot be strategies.
+func (r *Registry) Upsert(inst Instrument) (Instrument, error) {
+	if err := inst.normalize(); err != nil {
+		return Instrument{}, err
+	}
+	inst.Legs = append([]Leg(nil), inst.Legs...)
+	scale := *inst.PriceScale
+	inst.PriceScale = &scale
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, leg := range inst.Legs {
+		if def, ok := r.instruments[leg.Symbol]; !ok || def.IsStrategy() {
+			return Instrument{}, ErrInvalidInstrument
+		}
+	}
+	if inst.IsStrategy() && r.usedAsLegLocked(inst.Symbol) {
+		return Instrument{}, ErrInvalidInstrument
+	}
+	stored := inst
+	r.instruments[inst.Symbol] = &stored
+	return inst, nil
+}
+
+// Delete removes an instrument. Existing orders are unaffected but new
+// orders for the symbol are rejected. A leg of a registered strategy
+// cannot be deleted before the strategy.
+func (r *Registry) Delete(symbol string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.instruments[symbol]; !ok {
+		return ErrUnknownSymbol
+	}
+	if r.usedAsLegLocked(symbol) {
+		return ErrInstrumentInUse
+	}
+	delete(r.instruments, symbol)
+	return nil
+}
+
+// usedAsLegLocked reports whether a registered strategy has symbol as a
+// leg. The caller holds r.mu.
+func (r *Registry) usedAsLegLocked(symbol string) bool {
+	for _, inst := range r.instruments {
+		for _, leg := range inst.Legs {
+			if leg.Symbol == symbol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Get returns a copy of an instrument definition.
+func (r *Registry) Get(symbol string) (Instrument, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	inst, ok := r.instruments[symbol]
+	if !ok {
+		return Instrument{}, false
+	}
+	return *inst, true
+}
+
+// List returns all instruments sorted by symbol.
+func (r *Registry) List() []Instrument {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Instrument, 0, len(r.instruments))
+	for _, inst := range r.instruments {
+		out = append(out, *inst)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
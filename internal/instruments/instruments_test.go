@@ -0,0 +1,138 @@
+package instruments_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/instruments"
+)
+
+func TestCheckOrder(t *testing.T) {
+	reg := instruments.NewRegistry()
+	inst, err := reg.Upsert(instruments.Instrument{Symbol: "AAPL", TickSize: 5, LotSize: 10, MinQty: 10, MaxQty: 1000})
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if inst.BaseAsset != "AAPL" || inst.QuoteAsset != "USD" || inst.Status != common.TradingStatusOpen {
+		t.Fatalf("defaults not applied: %+v", inst)
+	}
+
+	cases := []struct {
+		name  string
+		order common.Order
+		want  error
+	}{
+		{"valid", common.Order{Type: common.OrderTypeLimit, Price: 10005, Quantity: 20}, nil},
+		{"off tick", common.Order{Type: common.OrderTypeLimit, Price: 10003, Quantity: 20}, instruments.ErrInvalidTick},
+		{"off lot", common.Order{Type: common.OrderTypeLimit, Price: 10005, Quantity: 25}, instruments.ErrInvalidLot},
+		{"too large", common.Order{Type: common.OrderTypeLimit, Price: 10005, Quantity: 1010}, instruments.ErrQuantityTooLarge},
+		{"market ignores tick", common.Order{Type: common.OrderTypeMarket, Quantity: 20}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := inst.CheckOrder(&tc.order); err != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instruments.json")
+	os.WriteFile(path, []byte(`[
+		{"symbol": "BTC-USD", "base_asset": "BTC", "quote_asset": "USD", "tick_size": 100, "lot_size": 1},
+		{"symbol": "ETH-USD", "base_asset": "ETH", "quote_asset": "USD", "status": "HALTED"}
+	]`), 0o644)
+
+	reg, err := instruments.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if list := reg.List(); len(list) != 2 || list[0].Symbol != "BTC-USD" {
+		t.Fatalf("unexpected instruments: %+v", list)
+	}
+	if eth, _ := reg.Get("ETH-USD"); eth.Status != common.TradingStatusHalted {
+		t.Fatalf("status not loaded: %+v", eth)
+	}
+
+	os.WriteFile(path, []byte(`[{"symbol": "BAD", "min_qty": 10, "max_qty": 5}]`), 0o644)
+	if _, err := instruments.Load(path); err != instruments.ErrInvalidInstrument {
+		t.Fatalf("expected invalid instrument, got %v", err)
+	}
+
+	os.WriteFile(path, []byte(`[{"symbol": "BAD", "dynamic_band_bps": 500, "band_action": "PANIC"}]`), 0o644)
+	if _, err := instruments.Load(path); err != instruments.ErrInvalidInstrument {
+		t.Fatalf("expected invalid band action, got %v", err)
+	}
+}
+
+func TestContractTerms(t *testing.T) {
+	reg := instruments.NewRegistry()
+	fut, err := reg.Upsert(instruments.Instrument{Symbol: "ESZ6", Type: instruments.TypeFuture, Expiry: 1_800_000_000_000, ContractMultiplier: 50})
+	if err != nil || fut.ContractMultiplier != 50 {
+		t.Fatalf("unexpected %+v: %v", fut, err)
+	}
+	if spot, _ := reg.Upsert(instruments.Instrument{Symbol: "AAPL"}); spot.Type != instruments.TypeSpot || spot.ContractMultiplier != 1 {
+		t.Fatalf("spot defaults not applied: %+v", spot)
+	}
+
+	bad := []instruments.Instrument{
+		{Symbol: "F1", Type: instruments.TypeFuture},                                                                    // no expiry
+		{Symbol: "S1", Expiry: 1_800_000_000_000},                                                                       // spot with expiry
+		{Symbol: "O1", Type: instruments.TypeOption, Expiry: 1, Strike: 100, Underlying: "ESZ6"},                        // no call/put
+		{Symbol: "O2", Type: instruments.TypeOption, Expiry: 1, OptionType: instruments.OptionCall, Underlying: "ESZ6"}, // no strike
+		{Symbol: "F2", Type: instruments.TypeFuture, Expiry: 1, Strike: 100},                                            // strike on a future
+	}
+	for _, inst := range bad {
+		if _, err := reg.Upsert(inst); err != instruments.ErrInvalidInstrument {
+			t.Fatalf("%s: expected ErrInvalidInstrument, got %v", inst.Symbol, err)
+		}
+	}
+}
+
+func TestPriceScale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instruments.json")
+	os.WriteFile(path, []byte(`[
+		{"symbol": "AAPL"},
+		{"symbol": "JPY-IDX", "price_scale": 0}
+	]`), 0o644)
+
+	reg, err := instruments.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if inst, _ := reg.Get("AAPL"); *inst.PriceScale != 2 {
+		t.Fatalf("expected the default scale, got %d", *inst.PriceScale)
+	}
+	if inst, _ := reg.Get("JPY-IDX"); *inst.PriceScale != 0 {
+		t.Fatalf("expected an integer-priced instrument, got scale %d", *inst.PriceScale)
+	}
+}
+
+func TestDeleteRejectsStrategyLeg(t *testing.T) {
+	reg := instruments.NewRegistry()
+	reg.Upsert(instruments.Instrument{Symbol: "A"})
+	reg.Upsert(instruments.Instrument{Symbol: "B"})
+	reg.Upsert(instruments.Instrument{Symbol: "C"})
+	reg.Upsert(instruments.Instrument{Symbol: "A-B", Legs: []instruments.Leg{{Symbol: "A", Ratio: 1}, {Symbol: "B", Ratio: -1}}})
+
+	if err := reg.Delete("A"); err != instruments.ErrInstrumentInUse {
+		t.Fatalf("expected ErrInstrumentInUse, got %v", err)
+	}
+	// A leg cannot become a strategy either.
+	if _, err := reg.Upsert(instruments.Instrument{Symbol: "B", Legs: []instruments.Leg{{Symbol: "A", Ratio: 1}, {Symbol: "C", Ratio: 1}}}); err != instruments.ErrInvalidInstrument {
+		t.Fatalf("expected ErrInvalidInstrument, got %v", err)
+	}
+
+	if err := reg.Delete("A-B"); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if err := reg.Delete("A"); err != nil {
+		t.Fatalf("expected the leg deletable once the strategy is gone, got %v", err)
+	}
+	if err := reg.Delete("A"); err != instruments.ErrUnknownSymbol {
+		t.Fatalf("expected ErrUnknownSymbol, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package ledger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnbalanced        = errors.New("postings do not balance")
+)
+
+// Balance is the holding of one asset by one account. Reserved funds back
+// resting orders and cannot be withdrawn or reserved again.
+type Balance struct {
+	Available int64 `json:"available"`
+	Reserved  int64 `json:"reserved"`
+}
+
+func (b Balance) Total() int64 {
+	return b.Available + b.Reserved
+}
+
+// Posting is a signed change to one account's balance of one asset.
+type Posting struct {
+	Account   string
+	Asset     string
+	Available int64
+	Reserved  int64
+}
+
+// Ledger holds per-account, per-asset balances. All mutations go through
+// Apply, which is atomic: either every posting is applied or none is.
+type Ledger struct {
+	mu       sync.RWMutex
+	balances map[string]map[string]*Balance // account -> asset -> balance
+
+	// overdraft lists system accounts whose available balance may go
+	// negative, such as the fee collector paying out rebates.
+	overdraft map[string]bool
+}
+
+func New() *Ledger {
+	return &Ledger{
+		balances:  make(map[string]map[string]*Balance),
+		overdraft: make(map[string]bool),
+	}
+}
+
+// AllowOverdraft lets an account's available balance go negative.
+func (l *Ledger) AllowOverdraft(account string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overdraft[account] = true
+}
+
+// Deposit credits external funds to an account.
+func (l *Ledger) Deposit(account, asset string, amount int64) error {
+	if amount <= 0 || account == "" || asset == "" {
+		return ErrInvalidAmount
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.balanceLocked(account, asset).Available += amount
+	return nil
+}
+
+// Withdraw debits available funds from an account.
+func (l *Ledger) Withdraw(account, asset string, amount int64) error {
+	if amount <= 0 || account == "" || asset == "" {
+		return ErrInvalidAmount
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.balanceLocked(account, asset)
+	if b.Available < amount {
+		return ErrInsufficientFunds
+	}
+	b.Available -= amount
+	return nil
+}
+
+// Reserve moves funds from available to reserved.
+func (l *Ledger) Reserve(account, asset string, amount int64) error {
+	if amount < 0 {
+		return ErrInvalidAmount
+	}
+	return l.Apply(Posting{Account: account, Asset: asset, Available: -amount, Reserved: amount})
+}
+
+// Release moves funds from reserved back to available.
+func (l *Ledger) Release(account, asset string, amount int64) error {
+	if amount < 0 {
+		return ErrInvalidAmount
+	}
+	return l.Apply(Posting{Account: account, Asset: asset, Available: amount, Reserved: -amount})
+}
+
+// Apply atomically applies a set of postings. The postings must net to zero
+// per asset (nothing is created or destroyed) and no balance may go negative.
+func (l *Ledger) Apply(postings ...Posting) error {
+	net := make(map[string]int64)
+	for _, p := range postings {
+		net[p.Asset] += p.Available + p.Reserved
+	}
+	for _, n := range net {
+		if n != 0 {
+			return ErrUnbalanced
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Validate against the combined effect before touching any balance, so
+	// several postings to the same balance are checked together.
+	type key struct{ account, asset string }
+	after := make(map[key]Balance)
+	for _, p := range postings {
+		k := key{p.Account, p.Asset}
+		b, ok := after[k]
+		if !ok {
+			if cur := l.balances[p.Account][p.Asset]; cur != nil {
+				b = *cur
+			}
+		}
+		b.Available += p.Available
+		b.Reserved += p.Reserved
+		after[k] = b
+	}
+	for k, b := range after {
+		if (b.Available < 0 && !l.overdraft[k.account]) || b.Reserved < 0 {
+			return ErrInsufficientFunds
+		}
+	}
+
+	for k, b := range after {
+		*l.balanceLocked(k.account, k.asset) = b
+	}
+	return nil
+}
+
+// Balance returns a copy of one balance.
+func (l *Ledger) Balance(account, asset string) Balance {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if b := l.balances[account][asset]; b != nil {
+		return *b
+	}
+	return Balance{}
+}
+
+// Balances returns a copy of all balances of an account keyed by asset.
+func (l *Ledger) Balances(account string) map[string]Balance {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]Balance, len(l.balances[account]))
+	for asset, b := range l.balances[account] {
+		out[asset] = *b
+	}
+	return out
+}
+
+// Accounts lists every account holding a balance, sorted.
+func (l *Ledger) Accounts() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]string, 0, len(l.balances))
+	for account := range l.balances {
+		out = append(out, account)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Totals sums available and reserved funds per asset across all accounts.
+// It only changes through deposits and withdrawals.
+func (l *Ledger) Totals() map[string]int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]int64)
+	for _, assets := range l.balances {
+		for asset, b := range assets {
+			out[asset] += b.Total()
+		}
+	}
+	return out
+}
+
+// balanceLocked returns the balance, creating it if needed. The caller holds l.mu.
+func (l *Ledger) balanceLocked(account, asset string) *Balance {
+	assets := l.balances[account]
+	if assets == nil {
+		assets = make(map[string]*Balance)
+		l.balances[account] = assets
+	}
+	b := assets[asset]
+	if b == nil {
+		b = &Balance{}
+		assets[asset] = b
+	}
+	return b
+}
@@ -0,0 +1,60 @@
+package ledger_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/ledger"
+)
+
+func TestReserveAndRelease(t *testing.T) {
+	l := ledger.New()
+	l.Deposit("A", "USD", 1000)
+
+	if err := l.Reserve("A", "USD", 1500); err != ledger.ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if err := l.Reserve("A", "USD", 600); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if err := l.Withdraw("A", "USD", 500); err != ledger.ErrInsufficientFunds {
+		t.Fatalf("reserved funds must not be withdrawable, got %v", err)
+	}
+	l.Release("A", "USD", 600)
+
+	if b := l.Balance("A", "USD"); b.Available != 1000 || b.Reserved != 0 {
+		t.Fatalf("unexpected balance %+v", b)
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	l := ledger.New()
+	l.Deposit("A", "USD", 100)
+	l.Deposit("B", "AAPL", 10)
+
+	// B has only 10 AAPL, so the whole settlement must be rejected.
+	err := l.Apply(
+		ledger.Posting{Account: "A", Asset: "USD", Available: -100},
+		ledger.Posting{Account: "B", Asset: "USD", Available: 100},
+		ledger.Posting{Account: "B", Asset: "AAPL", Available: -20},
+		ledger.Posting{Account: "A", Asset: "AAPL", Available: 20},
+	)
+	if err != ledger.ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if b := l.Balance("A", "USD"); b.Available != 100 {
+		t.Fatalf("partial settlement applied: %+v", b)
+	}
+}
+
+func TestApplyRejectsUnbalancedPostings(t *testing.T) {
+	l := ledger.New()
+	l.Deposit("A", "USD", 100)
+
+	err := l.Apply(ledger.Posting{Account: "A", Asset: "USD", Available: -50})
+	if err != ledger.ErrUnbalanced {
+		t.Fatalf("expected unbalanced, got %v", err)
+	}
+	if totals := l.Totals(); totals["USD"] != 100 {
+		t.Fatalf("totals changed: %v", totals)
+	}
+}
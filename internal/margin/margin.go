@@ -0,0 +1,131 @@
+// Package margin holds the collateral backing leveraged positions and the
+// insurance fund that absorbs losses liquidations cannot cover.
+package margin
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidAmount          = errors.New("invalid amount")
+	ErrInsufficientCollateral = errors.New("insufficient collateral")
+)
+
+// Insurance fund entry reasons.
+const (
+	FundReasonDeposit   = "deposit"   // operator top-up
+	FundReasonShortfall = "shortfall" // covered a liquidated account's negative equity
+)
+
+// FundEntry is one movement of the insurance fund. Amount is positive for
+// inflows and negative for payouts; Balance is the fund after the entry.
+type FundEntry struct {
+	Account   string `json:"account_id,omitempty"` // the liquidated account for shortfalls
+	Amount    int64  `json:"amount"`
+	Balance   int64  `json:"balance"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Manager holds per-account collateral in cents and the insurance fund.
+// It is safe for concurrent use.
+type Manager struct {
+	mu         sync.RWMutex
+	collateral map[string]int64
+	fund       int64
+	fundLog    []FundEntry
+}
+
+func NewManager() *Manager {
+	return &Manager{collateral: make(map[string]int64)}
+}
+
+// Deposit adds collateral to an account.
+func (m *Manager) Deposit(account string, amount int64) error {
+	if account == "" || amount <= 0 {
+		return ErrInvalidAmount
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collateral[account] += amount
+	return nil
+}
+
+// Withdraw removes collateral from an account. It only checks the
+// collateral balance; whether the account's positions still have enough
+// margin is the caller's concern.
+func (m *Manager) Withdraw(account string, amount int64) error {
+	if account == "" || amount <= 0 {
+		return ErrInvalidAmount
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.collateral[account] < amount {
+		return ErrInsufficientCollateral
+	}
+	m.collateral[account] -= amount
+	return nil
+}
+
+// Collateral returns an account's collateral.
+func (m *Manager) Collateral(account string) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.collateral[account]
+}
+
+// Accounts lists the accounts holding collateral, sorted.
+func (m *Manager) Accounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]string, 0, len(m.collateral))
+	for account := range m.collateral {
+		out = append(out, account)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FundInsurance adds an operator deposit to the insurance fund.
+func (m *Manager) FundInsurance(amount int64) (FundEntry, error) {
+	if amount <= 0 {
+		return FundEntry{}, ErrInvalidAmount
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.recordLocked("", amount, FundReasonDeposit), nil
+}
+
+// CoverShortfall pays amount from the insurance fund into an account's
+// collateral, bringing a liquidated account's negative equity back to
+// zero. The fund may go negative; that loss is left to the operator.
+func (m *Manager) CoverShortfall(account string, amount int64) FundEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collateral[account] += amount
+	return m.recordLocked(account, -amount, FundReasonShortfall)
+}
+
+// Insurance returns the insurance fund balance and its history, oldest
+// first.
+func (m *Manager) Insurance() (int64, []FundEntry) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.fund, append([]FundEntry(nil), m.fundLog...)
+}
+
+func (m *Manager) recordLocked(account string, amount int64, reason string) FundEntry {
+	m.fund += amount
+	e := FundEntry{
+		Account:   account,
+		Amount:    amount,
+		Balance:   m.fund,
+		Reason:    reason,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	m.fundLog = append(m.fundLog, e)
+	return e
+}
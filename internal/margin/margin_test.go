@@ -0,0 +1,34 @@
+package margin_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/margin"
+)
+
+func TestCollateralAndInsuranceFund(t *testing.T) {
+	m := margin.NewManager()
+	if err := m.Deposit("A", 0); err != margin.ErrInvalidAmount {
+		t.Fatalf("expected ErrInvalidAmount, got %v", err)
+	}
+	m.Deposit("A", 1_000)
+	if err := m.Withdraw("A", 1_001); err != margin.ErrInsufficientCollateral {
+		t.Fatalf("expected ErrInsufficientCollateral, got %v", err)
+	}
+	m.Withdraw("A", 400)
+	if got := m.Collateral("A"); got != 600 {
+		t.Fatalf("expected 600, got %d", got)
+	}
+
+	m.FundInsurance(500)
+	e := m.CoverShortfall("A", 700)
+	if e.Amount != -700 || e.Balance != -200 || e.Reason != margin.FundReasonShortfall {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if got := m.Collateral("A"); got != 1_300 {
+		t.Fatalf("shortfall should credit the account, got %d", got)
+	}
+	if balance, entries := m.Insurance(); balance != -200 || len(entries) != 2 {
+		t.Fatalf("unexpected fund %d %+v", balance, entries)
+	}
+}
@@ -80,7 +80,9 @@ func (m *MarketData) GetOHLCV(symbol string) *OHLCV {
 	return nil
 }
 
-func (m *MarketData) GetRecentTrades(symbol string, limit int) []*common.Trade {
+// GetRecentTrades returns up to limit of the most recent trades, oldest
+// first. A non-empty typ keeps only trades of that type.
+func (m *MarketData) GetRecentTrades(symbol string, limit int, typ common.TradeType) []*common.Trade {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -97,6 +99,19 @@ func (m *MarketData) GetRecentTrades(symbol string, limit int) []*common.Trade {
 		return []*common.Trade{}
 	}
 
+	if typ != "" {
+		matching := make([]*common.Trade, 0, limit)
+		for i := len(trades) - 1; i >= 0 && len(matching) < limit; i-- {
+			if trades[i].Type == typ {
+				matching = append(matching, trades[i])
+			}
+		}
+		for i, j := 0, len(matching)-1; i < j; i, j = i+1, j-1 {
+			matching[i], matching[j] = matching[j], matching[i]
+		}
+		return matching
+	}
+
 	start := 0
 	if len(trades) > limit {
 		start = len(trades) - limit
@@ -0,0 +1,223 @@
+package matching
+
+import (
+	"errors"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/orderbook"
+)
+
+var ErrInvalidConfig = errors.New("invalid matching algorithm configuration")
+
+// Kind names a built-in allocation algorithm.
+type Kind string
+
+const (
+	KindFIFO    Kind = "FIFO"
+	KindProRata Kind = "PRO_RATA"
+	KindHybrid  Kind = "HYBRID"
+)
+
+// Fill is the quantity allocated to one resting order.
+type Fill struct {
+	Order *common.Order
+	Qty   int64
+}
+
+// Algorithm allocates an incoming quantity across the orders resting at one
+// price level. It must allocate exactly min(qty, level quantity), give no
+// order more than its remaining quantity and return fills in queue order.
+// It does not modify the orders.
+type Algorithm interface {
+	Name() string
+	Allocate(level *orderbook.PriceLevel, qty int64) []Fill
+}
+
+// Config selects and parameterises a built-in algorithm. The zero value is FIFO.
+type Config struct {
+	Algorithm     Kind     `json:"algorithm,omitempty"`
+	MinAllocation int64    `json:"min_allocation,omitempty"` // pro-rata shares below this round to zero
+	TopOrderPct   int64    `json:"top_order_pct,omitempty"`  // hybrid: share for the order at the front of the level
+	LMMAccounts   []string `json:"lmm_accounts,omitempty"`   // hybrid: lead market makers
+	LMMPct        int64    `json:"lmm_pct,omitempty"`        // hybrid: share split pro-rata among LMM orders
+}
+
+// Validate checks the configuration without building an algorithm.
+func (c Config) Validate() error {
+	_, err := New(c)
+	return err
+}
+
+// New builds the algorithm described by c.
+func New(c Config) (Algorithm, error) {
+	if c.MinAllocation < 0 || c.TopOrderPct < 0 || c.LMMPct < 0 || c.TopOrderPct+c.LMMPct > 100 {
+		return nil, ErrInvalidConfig
+	}
+	switch c.Algorithm {
+	case "", KindFIFO:
+		return FIFO{}, nil
+	case KindProRata:
+		return ProRata{MinAllocation: c.MinAllocation}, nil
+	case KindHybrid:
+		lmm := make(map[string]bool, len(c.LMMAccounts))
+		for _, a := range c.LMMAccounts {
+			lmm[a] = true
+		}
+		return Hybrid{
+			TopOrderPct:   c.TopOrderPct,
+			LMMAccounts:   lmm,
+			LMMPct:        c.LMMPct,
+			MinAllocation: c.MinAllocation,
+		}, nil
+	}
+	return nil, ErrInvalidConfig
+}
+
+// FIFO fills orders strictly in time priority.
+type FIFO struct{}
+
+func (FIFO) Name() string { return string(KindFIFO) }
+
+func (FIFO) Allocate(level *orderbook.PriceLevel, qty int64) []Fill {
+	a := newAllocation(level)
+	a.fifo(qty)
+	return a.fills()
+}
+
+// ProRata splits the incoming quantity in proportion to each order's
+// remaining quantity. Shares round down and shares below MinAllocation are
+// dropped; the residue goes out in time priority.
+type ProRata struct {
+	MinAllocation int64
+}
+
+func (ProRata) Name() string { return string(KindProRata) }
+
+func (p ProRata) Allocate(level *orderbook.PriceLevel, qty int64) []Fill {
+	a := newAllocation(level)
+	left := a.proRata(a.all(), qty, p.MinAllocation)
+	a.fifo(left)
+	return a.fills()
+}
+
+// Hybrid first gives TopOrderPct of the incoming quantity to the order at
+// the front of the level, then LMMPct of what is left to lead market maker
+// orders pro-rata, then allocates the rest pro-rata across all orders with
+// the residue in time priority.
+type Hybrid struct {
+	TopOrderPct   int64
+	LMMAccounts   map[string]bool
+	LMMPct        int64
+	MinAllocation int64
+}
+
+func (Hybrid) Name() string { return string(KindHybrid) }
+
+func (h Hybrid) Allocate(level *orderbook.PriceLevel, qty int64) []Fill {
+	a := newAllocation(level)
+	left := qty
+	if len(a.orders) > 0 && h.TopOrderPct > 0 {
+		left -= a.give(0, qty*h.TopOrderPct/100)
+	}
+	if h.LMMPct > 0 {
+		var lmm []int
+		for i, o := range a.orders {
+			if h.LMMAccounts[o.Account] {
+				lmm = append(lmm, i)
+			}
+		}
+		share := left * h.LMMPct / 100
+		left -= share - a.proRata(lmm, share, 0)
+	}
+	left = a.proRata(a.all(), left, h.MinAllocation)
+	a.fifo(left)
+	return a.fills()
+}
+
+// allocation accumulates fills for the orders of one level.
+type allocation struct {
+	orders []*common.Order
+	got    []int64
+}
+
+func newAllocation(level *orderbook.PriceLevel) *allocation {
+	return &allocation{orders: level.Orders, got: make([]int64, len(level.Orders))}
+}
+
+func (a *allocation) all() []int {
+	idx := make([]int, len(a.orders))
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+// available is what order i can still take.
+func (a *allocation) available(i int) int64 {
+	o := a.orders[i]
+	return o.Quantity - o.FilledQty - a.got[i]
+}
+
+// give allocates up to qty to order i and returns the amount given.
+func (a *allocation) give(i int, qty int64) int64 {
+	if avail := a.available(i); qty > avail {
+		qty = avail
+	}
+	if qty <= 0 {
+		return 0
+	}
+	a.got[i] += qty
+	return qty
+}
+
+// fifo allocates qty in queue order.
+func (a *allocation) fifo(qty int64) {
+	for i := range a.orders {
+		if qty == 0 {
+			return
+		}
+		qty -= a.give(i, qty)
+	}
+}
+
+// proRata allocates qty across the given orders in proportion to what each
+// can still take and returns the unallocated rest.
+func (a *allocation) proRata(idx []int, qty, minAlloc int64) int64 {
+	total := int64(0)
+	for _, i := range idx {
+		total += a.available(i)
+	}
+	if total == 0 || qty == 0 {
+		return qty
+	}
+	if qty >= total {
+		for _, i := range idx {
+			qty -= a.give(i, a.available(i))
+		}
+		return qty
+	}
+
+	shares := make([]int64, len(idx))
+	for k, i := range idx {
+		share := qty * a.available(i) / total
+		if share < minAlloc {
+			share = 0
+		}
+		shares[k] = share
+	}
+	left := qty
+	for k, i := range idx {
+		left -= a.give(i, shares[k])
+	}
+	return left
+}
+
+func (a *allocation) fills() []Fill {
+	out := make([]Fill, 0, len(a.orders))
+	for i, o := range a.orders {
+		if a.got[i] > 0 {
+			out = append(out, Fill{Order: o, Qty: a.got[i]})
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package matching_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/matching"
+	"order-matching-engine/internal/orderbook"
+)
+
+func level(orders ...*common.Order) *orderbook.PriceLevel {
+	l := orderbook.NewPriceLevel(10000)
+	for _, o := range orders {
+		l.Enqueue(o)
+	}
+	return l
+}
+
+func resting(id, account string, qty int64) *common.Order {
+	return &common.Order{ID: id, Account: account, Quantity: qty}
+}
+
+func allocated(fills []matching.Fill) map[string]int64 {
+	out := make(map[string]int64)
+	for _, f := range fills {
+		out[f.Order.ID] += f.Qty
+	}
+	return out
+}
+
+func TestAlgorithms(t *testing.T) {
+	cases := []struct {
+		name   string
+		config matching.Config
+		level  *orderbook.PriceLevel
+		qty    int64
+		want   map[string]int64
+	}{
+		{
+			name:   "fifo",
+			config: matching.Config{},
+			level:  level(resting("A", "", 100), resting("B", "", 300)),
+			qty:    150,
+			want:   map[string]int64{"A": 100, "B": 50},
+		},
+		{
+			name:   "pro-rata drops small shares and gives residue in time priority",
+			config: matching.Config{Algorithm: matching.KindProRata, MinAllocation: 5},
+			level:  level(resting("A", "", 100), resting("B", "", 300), resting("C", "", 10)),
+			qty:    200,
+			want:   map[string]int64{"A": 54, "B": 146},
+		},
+		{
+			name:   "pro-rata fills everything when incoming exceeds the level",
+			config: matching.Config{Algorithm: matching.KindProRata},
+			level:  level(resting("A", "", 100), resting("B", "", 300)),
+			qty:    500,
+			want:   map[string]int64{"A": 100, "B": 300},
+		},
+		{
+			name: "hybrid top order then LMM then pro-rata",
+			config: matching.Config{
+				Algorithm:   matching.KindHybrid,
+				TopOrderPct: 40,
+				LMMAccounts: []string{"MM"},
+				LMMPct:      20,
+			},
+			level: level(resting("A", "X", 100), resting("B", "MM", 200), resting("C", "Y", 100)),
+			qty:   200,
+			want:  map[string]int64{"A": 87, "B": 81, "C": 32},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			alg, err := matching.New(tc.config)
+			if err != nil {
+				t.Fatalf("unexpected: %v", err)
+			}
+			got := allocated(alg.Allocate(tc.level, tc.qty))
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for id, qty := range tc.want {
+				if got[id] != qty {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, c := range []matching.Config{
+		{Algorithm: "RANDOM"},
+		{Algorithm: matching.KindHybrid, TopOrderPct: 80, LMMPct: 30},
+		{Algorithm: matching.KindProRata, MinAllocation: -1},
+	} {
+		if _, err := matching.New(c); err != matching.ErrInvalidConfig {
+			t.Fatalf("expected invalid config for %+v, got %v", c, err)
+		}
+	}
+}
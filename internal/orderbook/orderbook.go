@@ -19,6 +19,25 @@ func (pl *PriceLevel) Enqueue(o *common.Order) {
 	pl.Orders = append(pl.Orders, o)
 }
 
+// EnqueueVisible adds a displayed order behind the level's other displayed
+// orders but ahead of any hidden ones, which queue last.
+func (pl *PriceLevel) EnqueueVisible(o *common.Order) {
+	i := pl.HiddenIndex()
+	pl.Orders = append(pl.Orders, nil)
+	copy(pl.Orders[i+1:], pl.Orders[i:])
+	pl.Orders[i] = o
+}
+
+// HiddenIndex is the position of the first hidden order, or len(Orders).
+func (pl *PriceLevel) HiddenIndex() int {
+	for i := len(pl.Orders); i > 0; i-- {
+		if !pl.Orders[i-1].Hidden {
+			return i
+		}
+	}
+	return 0
+}
+
 func (pl *PriceLevel) Dequeue() (*common.Order, bool) {
 	if len(pl.Orders) == 0 {
 		return nil, false
@@ -122,14 +141,63 @@ func (sb *SideBook) AddOrder(o *common.Order) {
 		return
 	}
 	level := sb.InsertPrice(o.Price)
-	level.Enqueue(o)
+	if o.Hidden {
+		level.Enqueue(o)
+	} else {
+		level.EnqueueVisible(o)
+	}
 	sb.TotalQuantity += remaining
 }
 
+// Level is the displayed quantity at one price.
+type Level struct {
+	Price    int64 `json:"price"`
+	Quantity int64 `json:"quantity"`
+}
+
+// Depth returns up to n price levels with displayed quantity, best first.
+// Hidden orders are left out, and levels holding only hidden orders are
+// skipped.
+func (sb *SideBook) Depth(n int) []Level {
+	if n < 0 {
+		n = 0
+	}
+	out := make([]Level, 0, n)
+	for _, price := range sb.Prices {
+		if len(out) >= n {
+			break
+		}
+		qty := int64(0)
+		for _, o := range sb.Levels[price].Orders {
+			if !o.Hidden {
+				qty += o.Quantity - o.FilledQty
+			}
+		}
+		if qty > 0 {
+			out = append(out, Level{Price: price, Quantity: qty})
+		}
+	}
+	return out
+}
+
+// BestVisiblePrice is the best price with displayed quantity.
+func (sb *SideBook) BestVisiblePrice() (int64, bool) {
+	if d := sb.Depth(1); len(d) > 0 {
+		return d[0].Price, true
+	}
+	return 0, false
+}
+
 type OrderBook struct {
-	Symbol string
-	Bids   *SideBook
-	Asks   *SideBook
+	Symbol    string
+	Bids      *SideBook
+	Asks      *SideBook
+	LastPrice int64                // price of the most recent trade, 0 if none
+	Status    common.TradingStatus // current trading state
+
+	// ReferencePrice anchors the static price band: the last auction
+	// clearing price, or the first trade if there has been no auction.
+	ReferencePrice int64
 }
 
 func NewOrderBook(symbol string) *OrderBook {
@@ -137,5 +205,6 @@ func NewOrderBook(symbol string) *OrderBook {
 		Symbol: symbol,
 		Bids:   NewSideBook(true),
 		Asks:   NewSideBook(false),
+		Status: common.TradingStatusOpen,
 	}
 }
@@ -0,0 +1,233 @@
+// Package positions keeps per-account, per-symbol positions and their
+// profit and loss from executed trades.
+package positions
+
+import (
+	"sort"
+	"sync"
+
+	"order-matching-engine/internal/common"
+)
+
+// PriceSource is the price unrealized P&L was computed against.
+type PriceSource string
+
+const (
+	PriceNone PriceSource = ""     // no price yet; unrealized P&L is 0
+	PriceLast PriceSource = "LAST" // last book trade
+	PriceMark PriceSource = "MARK" // mark price
+)
+
+// Position is an account's holding in one symbol. Quantity is signed:
+// positive long, negative short. P&L is in cents and includes the contract
+// multiplier; fees are tracked separately and not deducted from it.
+type Position struct {
+	Account       string      `json:"account_id"`
+	Symbol        string      `json:"symbol"`
+	Quantity      int64       `json:"quantity"`
+	AvgPrice      int64       `json:"avg_price"` // average entry price of the open quantity
+	RealizedPnL   int64       `json:"realized_pnl"`
+	UnrealizedPnL int64       `json:"unrealized_pnl"`
+	Fees          int64       `json:"fees"` // cents paid, negative for net rebates
+	Price         int64       `json:"price"`
+	PriceSource   PriceSource `json:"price_source"`
+	Multiplier    int64       `json:"multiplier"`
+	UpdatedAt     int64       `json:"updated_at"`
+}
+
+// Summary totals an account's positions.
+type Summary struct {
+	Account       string     `json:"account_id"`
+	RealizedPnL   int64      `json:"realized_pnl"`
+	UnrealizedPnL int64      `json:"unrealized_pnl"`
+	Fees          int64      `json:"fees"`
+	Positions     []Position `json:"positions"`
+}
+
+// position is the running state behind a Position. cost is the entry value
+// of the open quantity (sum of price x quantity, unsigned for shorts too) so
+// the average price does not accumulate rounding.
+type position struct {
+	qty        int64
+	cost       int64
+	realized   int64
+	fees       int64
+	multiplier int64
+	updatedAt  int64
+}
+
+// Service tracks positions from trades. It is safe for concurrent use.
+type Service struct {
+	mu        sync.RWMutex
+	positions map[string]map[string]*position // account -> symbol -> position
+	last      map[string]int64                // symbol -> last book trade price
+	marks     map[string]int64                // symbol -> mark price
+}
+
+func NewService() *Service {
+	return &Service{
+		positions: make(map[string]map[string]*position),
+		last:      make(map[string]int64),
+		marks:     make(map[string]int64),
+	}
+}
+
+// OnTrade applies both sides of a trade on symbol and returns the updated
+// positions. Only book trades move the last price; off-book trades still
+// change positions. A multiplier below 1 counts as 1.
+func (s *Service) OnTrade(symbol string, t *common.Trade, multiplier int64) []Position {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.Type == common.TradeTypeBook {
+		s.last[symbol] = t.Price
+	}
+	var out []Position
+	for _, side := range [2]struct {
+		account string
+		qty     int64
+		fee     int64
+	}{
+		{t.BuyAccount, t.Quantity, t.BuyFee},
+		{t.SellAccount, -t.Quantity, t.SellFee},
+	} {
+		if side.account == "" {
+			continue
+		}
+		p := s.ensure(side.account, symbol)
+		p.multiplier = multiplier
+		p.apply(side.qty, t.Price)
+		p.fees += side.fee
+		p.updatedAt = t.Timestamp
+		out = append(out, s.snapshot(side.account, symbol, p))
+	}
+	return out
+}
+
+// SetMarkPrice sets the price unrealized P&L is computed against for
+// symbol, in preference to the last trade. A price of 0 clears it.
+func (s *Service) SetMarkPrice(symbol string, price int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if price == 0 {
+		delete(s.marks, symbol)
+		return
+	}
+	s.marks[symbol] = price
+}
+
+// Get returns an account's position in symbol.
+func (s *Service) Get(account, symbol string) (Position, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.positions[account][symbol]
+	if !ok {
+		return Position{}, false
+	}
+	return s.snapshot(account, symbol, p), true
+}
+
+// Account returns an account's positions, sorted by symbol, with totals.
+// Closed positions stay listed for their realized P&L.
+func (s *Service) Account(account string) Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sum := Summary{Account: account, Positions: make([]Position, 0, len(s.positions[account]))}
+	for symbol, p := range s.positions[account] {
+		pos := s.snapshot(account, symbol, p)
+		sum.RealizedPnL += pos.RealizedPnL
+		sum.UnrealizedPnL += pos.UnrealizedPnL
+		sum.Fees += pos.Fees
+		sum.Positions = append(sum.Positions, pos)
+	}
+	sort.Slice(sum.Positions, func(i, j int) bool { return sum.Positions[i].Symbol < sum.Positions[j].Symbol })
+	return sum
+}
+
+// Holders returns the accounts with an open position in symbol, sorted.
+func (s *Service) Holders(symbol string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []string
+	for account, bySymbol := range s.positions {
+		if p, ok := bySymbol[symbol]; ok && p.qty != 0 {
+			out = append(out, account)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *Service) ensure(account, symbol string) *position {
+	bySymbol, ok := s.positions[account]
+	if !ok {
+		bySymbol = make(map[string]*position)
+		s.positions[account] = bySymbol
+	}
+	p, ok := bySymbol[symbol]
+	if !ok {
+		p = &position{multiplier: 1}
+		bySymbol[symbol] = p
+	}
+	return p
+}
+
+// snapshot values p at the symbol's mark price, else its last price. The
+// caller holds s.mu.
+func (s *Service) snapshot(account, symbol string, p *position) Position {
+	pos := Position{
+		Account:     account,
+		Symbol:      symbol,
+		Quantity:    p.qty,
+		RealizedPnL: p.realized,
+		Fees:        p.fees,
+		Multiplier:  p.multiplier,
+		UpdatedAt:   p.updatedAt,
+	}
+	if p.qty != 0 {
+		pos.AvgPrice = p.cost / abs(p.qty)
+	}
+	if mark, ok := s.marks[symbol]; ok {
+		pos.Price, pos.PriceSource = mark, PriceMark
+	} else if last, ok := s.last[symbol]; ok {
+		pos.Price, pos.PriceSource = last, PriceLast
+	}
+	if pos.PriceSource != PriceNone && p.qty != 0 {
+		pos.UnrealizedPnL = sign(p.qty) * (pos.Price*abs(p.qty) - p.cost) * p.multiplier
+	}
+	return pos
+}
+
+// apply adds a signed fill of qty at price. Fills against the open side
+// realize P&L on the closed quantity at its average cost; any excess opens
+// a new position at price.
+func (p *position) apply(qty, price int64) {
+	if p.qty != 0 && sign(qty) != sign(p.qty) {
+		closed := min(abs(qty), abs(p.qty))
+		closedCost := p.cost * closed / abs(p.qty)
+		p.realized += sign(p.qty) * (price*closed - closedCost) * p.multiplier
+		p.cost -= closedCost
+		p.qty += sign(qty) * closed
+		qty -= sign(qty) * closed
+	}
+	p.qty += qty
+	p.cost += price * abs(qty)
+}
+
+func sign(v int64) int64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package positions_test
+
+import (
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/positions"
+)
+
+func trade(buyer, seller string, price, qty int64) *common.Trade {
+	return &common.Trade{Type: common.TradeTypeBook, BuyAccount: buyer, SellAccount: seller, Price: price, Quantity: qty}
+}
+
+func TestRealizedAndUnrealizedPnL(t *testing.T) {
+	s := positions.NewService()
+	s.OnTrade("AAPL", trade("A", "B", 10000, 10), 1)
+	s.OnTrade("AAPL", trade("A", "B", 10300, 20), 1)
+
+	p, _ := s.Get("A", "AAPL")
+	if p.Quantity != 30 || p.AvgPrice != 10200 || p.UnrealizedPnL != 30*100 || p.PriceSource != positions.PriceLast {
+		t.Fatalf("unexpected long position %+v", p)
+	}
+	if p, _ := s.Get("B", "AAPL"); p.Quantity != -30 || p.AvgPrice != 10200 || p.UnrealizedPnL != -30*100 {
+		t.Fatalf("unexpected short position %+v", p)
+	}
+
+	// A sells 40 at 10500: 30 close at +300 each, 10 open short.
+	updated := s.OnTrade("AAPL", trade("C", "A", 10500, 40), 1)
+	if len(updated) != 2 {
+		t.Fatalf("expected both sides updated, got %+v", updated)
+	}
+	p, _ = s.Get("A", "AAPL")
+	if p.Quantity != -10 || p.AvgPrice != 10500 || p.RealizedPnL != 9000 || p.UnrealizedPnL != 0 {
+		t.Fatalf("unexpected flipped position %+v", p)
+	}
+
+	s.SetMarkPrice("AAPL", 10400)
+	if p, _ := s.Get("A", "AAPL"); p.UnrealizedPnL != 1000 || p.PriceSource != positions.PriceMark {
+		t.Fatalf("expected unrealized P&L against the mark, got %+v", p)
+	}
+	if sum := s.Account("A"); sum.RealizedPnL != 9000 || sum.UnrealizedPnL != 1000 || len(sum.Positions) != 1 {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	if got := s.Holders("AAPL"); len(got) != 3 {
+		t.Fatalf("expected three holders, got %v", got)
+	}
+}
+
+func TestMultiplierAndOffBookTrades(t *testing.T) {
+	s := positions.NewService()
+	s.OnTrade("ESZ6", trade("A", "B", 10000, 2), 50)
+
+	block := trade("B", "A", 9000, 2)
+	block.Type = common.TradeTypeBlock
+	s.OnTrade("ESZ6", block, 50)
+
+	p, _ := s.Get("A", "ESZ6")
+	if p.Quantity != 0 || p.RealizedPnL != -2*1000*50 {
+		t.Fatalf("unexpected closed position %+v", p)
+	}
+	if b, _ := s.Get("B", "ESZ6"); b.Price != 10000 {
+		t.Fatalf("block trades must not move the last price, got %+v", b)
+	}
+}
@@ -0,0 +1,354 @@
+// Package prices computes a mark price per symbol from several inputs, so
+// that risk and P&L do not follow a single print.
+package prices
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"order-matching-engine/internal/common"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid mark price config")
+	ErrInvalidPrice  = errors.New("invalid price")
+)
+
+// Source is one input to the mark price.
+type Source string
+
+const (
+	SourceMid   Source = "MID"   // midpoint of the displayed best bid and ask
+	SourceEMA   Source = "EMA"   // exponential moving average of recent book trades
+	SourceIndex Source = "INDEX" // externally pushed index price
+)
+
+// DefaultEMAPeriod is the number of trades the EMA spans when a config
+// does not set one.
+const DefaultEMAPeriod = 20
+
+// Config selects the inputs of a symbol's mark price. The mark is the
+// median of the inputs available, so with three inputs no single one can
+// move it on its own.
+type Config struct {
+	Sources       []Source `json:"sources"`          // empty uses all
+	EMAPeriod     int      `json:"ema_period"`       // trades; 0 uses DefaultEMAPeriod
+	IndexMaxAgeMs int64    `json:"index_max_age_ms"` // older index prices are ignored; 0 never expires
+}
+
+func (c *Config) normalize() error {
+	if len(c.Sources) == 0 {
+		c.Sources = []Source{SourceMid, SourceEMA, SourceIndex}
+	}
+	seen := make(map[Source]bool)
+	for _, s := range c.Sources {
+		if (s != SourceMid && s != SourceEMA && s != SourceIndex) || seen[s] {
+			return ErrInvalidConfig
+		}
+		seen[s] = true
+	}
+	if c.EMAPeriod == 0 {
+		c.EMAPeriod = DefaultEMAPeriod
+	}
+	if c.EMAPeriod < 0 || c.IndexMaxAgeMs < 0 {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+// Price is the published mark price of a symbol with the inputs it was
+// computed from. Inputs that were unavailable are 0.
+type Price struct {
+	Symbol    string   `json:"symbol"`
+	Mark      int64    `json:"mark_price"`
+	Index     int64    `json:"index_price"`
+	Mid       int64    `json:"mid_price"`
+	EMA       int64    `json:"ema_price"`
+	Sources   []Source `json:"sources"` // inputs the mark used
+	Timestamp int64    `json:"timestamp"`
+}
+
+// Book is the order book side of the inputs and where marks are applied;
+// the matching engine implements it.
+type Book interface {
+	TopOfBook(symbol string) (bid, ask int64, ok bool)
+	Symbols() []string
+	SetMarkPrice(symbol string, price int64) error
+}
+
+// Trades supplies recent trades, oldest first; market data implements it.
+type Trades interface {
+	GetRecentTrades(symbol string, limit int, typ common.TradeType) []*common.Trade
+}
+
+type indexPrice struct {
+	price int64
+	at    time.Time
+}
+
+// Service computes mark prices, applies them to the book side and
+// publishes them. It is safe for concurrent use.
+type Service struct {
+	mu       sync.RWMutex
+	book     Book
+	trades   Trades
+	defaults Config
+	configs  map[string]Config
+	index    map[string]indexPrice
+	prices   map[string]Price
+
+	// refreshing serializes refreshes of a symbol, so its marks reach the
+	// book and OnUpdate in the order they were computed.
+	refreshing map[string]*sync.Mutex
+
+	// OnUpdate is called with every changed mark price. Set it before
+	// Start.
+	OnUpdate func(Price)
+}
+
+func NewService(book Book, trades Trades) *Service {
+	s := &Service{
+		book:    book,
+		trades:  trades,
+		configs: make(map[string]Config),
+		index:   make(map[string]indexPrice),
+		prices:  make(map[string]Price),
+
+		refreshing: make(map[string]*sync.Mutex),
+	}
+	s.defaults.normalize()
+	return s
+}
+
+// SetDefaultConfig sets the config of symbols without their own.
+func (s *Service) SetDefaultConfig(cfg Config) (Config, error) {
+	if err := cfg.normalize(); err != nil {
+		return Config{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults = cfg
+	return cfg, nil
+}
+
+// SetConfig sets a symbol's config.
+func (s *Service) SetConfig(symbol string, cfg Config) (Config, error) {
+	if err := cfg.normalize(); err != nil {
+		return Config{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs[symbol] = cfg
+	return cfg, nil
+}
+
+// Config returns the config in effect for a symbol.
+func (s *Service) Config(symbol string) Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.configLocked(symbol)
+}
+
+func (s *Service) configLocked(symbol string) Config {
+	if cfg, ok := s.configs[symbol]; ok {
+		return cfg
+	}
+	return s.defaults
+}
+
+// SetIndex records an external index price and recomputes the symbol's
+// mark.
+func (s *Service) SetIndex(symbol string, price int64) (Price, error) {
+	if symbol == "" || price <= 0 {
+		return Price{}, ErrInvalidPrice
+	}
+	s.mu.Lock()
+	s.index[symbol] = indexPrice{price: price, at: time.Now()}
+	s.mu.Unlock()
+
+	p, _ := s.refresh(symbol)
+	return p, nil
+}
+
+// LoadIndexFile pushes every index price in a JSON file mapping symbols to
+// prices, such as {"BTC-PERP": 5000000}.
+func (s *Service) LoadIndexFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var feed map[string]int64
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return err
+	}
+	for symbol, price := range feed {
+		if _, err := s.SetIndex(symbol, price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the last published price of a symbol.
+func (s *Service) Get(symbol string) (Price, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.prices[symbol]
+	return p, ok
+}
+
+// All returns every published price, sorted by symbol.
+func (s *Service) All() []Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Price, 0, len(s.prices))
+	for _, p := range s.prices {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// Refresh recomputes the mark of every symbol with a book or an index.
+func (s *Service) Refresh() {
+	symbols := make(map[string]bool)
+	for _, symbol := range s.book.Symbols() {
+		symbols[symbol] = true
+	}
+	s.mu.RLock()
+	for symbol := range s.index {
+		symbols[symbol] = true
+	}
+	s.mu.RUnlock()
+
+	for symbol := range symbols {
+		s.refresh(symbol)
+	}
+}
+
+// Start refreshes every interval, and re-reads indexFile when it changes if
+// one is given, until the returned stop function is called.
+func (s *Service) Start(interval time.Duration, indexFile string) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		var loaded time.Time
+		for {
+			if indexFile != "" {
+				if fi, err := os.Stat(indexFile); err == nil && fi.ModTime().After(loaded) {
+					if s.LoadIndexFile(indexFile) == nil {
+						loaded = fi.ModTime()
+					}
+				}
+			}
+			s.Refresh()
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// refresh recomputes one symbol. A changed mark is applied to the book
+// side and published. It reports whether the symbol has a mark.
+func (s *Service) refresh(symbol string) (Price, bool) {
+	s.mu.Lock()
+	serial, ok := s.refreshing[symbol]
+	if !ok {
+		serial = new(sync.Mutex)
+		s.refreshing[symbol] = serial
+	}
+	s.mu.Unlock()
+	serial.Lock()
+	defer serial.Unlock()
+
+	s.mu.RLock()
+	cfg := s.configLocked(symbol)
+	idx, hasIndex := s.index[symbol]
+	prev, hadPrev := s.prices[symbol]
+	s.mu.RUnlock()
+
+	p := Price{Symbol: symbol, Timestamp: time.Now().UnixMilli()}
+	if hasIndex && (cfg.IndexMaxAgeMs == 0 || time.Since(idx.at) <= time.Duration(cfg.IndexMaxAgeMs)*time.Millisecond) {
+		p.Index = idx.price
+	}
+	if bid, ask, ok := s.book.TopOfBook(symbol); ok {
+		p.Mid = (bid + ask) / 2
+	}
+	p.EMA = ema(s.trades.GetRecentTrades(symbol, cfg.EMAPeriod*2, common.TradeTypeBook), cfg.EMAPeriod)
+
+	var inputs []int64
+	for _, src := range cfg.Sources {
+		if v := p.input(src); v > 0 {
+			inputs = append(inputs, v)
+			p.Sources = append(p.Sources, src)
+		}
+	}
+	if len(inputs) == 0 {
+		return p, false
+	}
+	p.Mark = median(inputs)
+
+	changed := !hadPrev || prev.Mark != p.Mark || prev.Index != p.Index || prev.Mid != p.Mid || prev.EMA != p.EMA
+	s.mu.Lock()
+	s.prices[symbol] = p
+	s.mu.Unlock()
+	if !hadPrev || prev.Mark != p.Mark {
+		s.book.SetMarkPrice(symbol, p.Mark)
+	}
+	if changed && s.OnUpdate != nil {
+		s.OnUpdate(p)
+	}
+	return p, true
+}
+
+// input returns the value of one source, 0 if unavailable.
+func (p Price) input(src Source) int64 {
+	switch src {
+	case SourceMid:
+		return p.Mid
+	case SourceEMA:
+		return p.EMA
+	case SourceIndex:
+		return p.Index
+	}
+	return 0
+}
+
+// ema averages trade prices, oldest first, weighting the latest with
+// 2/(period+1). It is 0 without trades.
+func ema(trades []*common.Trade, period int) int64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	alpha := 2 / float64(period+1)
+	v := float64(trades[0].Price)
+	for _, t := range trades[1:] {
+		v += alpha * (float64(t.Price) - v)
+	}
+	return int64(v + 0.5)
+}
+
+// median of at least one value; the mean of the middle two for an even
+// count.
+func median(values []int64) int64 {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
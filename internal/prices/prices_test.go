@@ -0,0 +1,172 @@
+package prices_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/prices"
+)
+
+type fakeBook struct {
+	bid, ask int64
+	marks    map[string]int64
+}
+
+func (b *fakeBook) TopOfBook(symbol string) (int64, int64, bool) {
+	return b.bid, b.ask, b.bid > 0 && b.ask > 0
+}
+
+func (b *fakeBook) Symbols() []string { return []string{"BTC-PERP"} }
+
+func (b *fakeBook) SetMarkPrice(symbol string, price int64) error {
+	b.marks[symbol] = price
+	return nil
+}
+
+type fakeTrades []*common.Trade
+
+func (t fakeTrades) GetRecentTrades(symbol string, limit int, typ common.TradeType) []*common.Trade {
+	return t
+}
+
+func prints(ps ...int64) fakeTrades {
+	var out fakeTrades
+	for _, p := range ps {
+		out = append(out, &common.Trade{Type: common.TradeTypeBook, Price: p, Quantity: 1})
+	}
+	return out
+}
+
+func TestMarkIsMedianOfInputs(t *testing.T) {
+	book := &fakeBook{bid: 9900, ask: 10100, marks: make(map[string]int64)}
+	s := prices.NewService(book, prints(10000, 10000, 10000))
+
+	var published []prices.Price
+	s.OnUpdate = func(p prices.Price) { published = append(published, p) }
+
+	// Mid and EMA only: the mean of the two.
+	s.Refresh()
+	p, ok := s.Get("BTC-PERP")
+	if !ok || p.Mid != 10000 || p.EMA != 10000 || p.Mark != 10000 || book.marks["BTC-PERP"] != 10000 {
+		t.Fatalf("unexpected price %+v", p)
+	}
+
+	// A far-off index is outvoted by the other two inputs.
+	p, err := s.SetIndex("BTC-PERP", 20000)
+	if err != nil || p.Index != 20000 || p.Mark != 10000 || len(p.Sources) != 3 {
+		t.Fatalf("unexpected price %+v (%v)", p, err)
+	}
+	if len(published) != 2 {
+		t.Fatalf("expected an update per change, got %d", len(published))
+	}
+
+	// Unchanged inputs publish nothing.
+	s.Refresh()
+	if len(published) != 2 {
+		t.Fatalf("expected no update, got %d", len(published))
+	}
+
+	if _, err := s.SetIndex("BTC-PERP", 0); err != prices.ErrInvalidPrice {
+		t.Fatalf("expected ErrInvalidPrice, got %v", err)
+	}
+}
+
+func TestConcurrentRefreshesPublishInOrder(t *testing.T) {
+	book := &fakeBook{marks: make(map[string]int64)}
+	s := prices.NewService(book, prints())
+	if _, err := s.SetConfig("BTC-PERP", prices.Config{Sources: []prices.Source{prices.SourceIndex}}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	var published []prices.Price
+	s.OnUpdate = func(p prices.Price) { published = append(published, p) }
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(2)
+		go func(price int64) {
+			defer wg.Done()
+			s.SetIndex("BTC-PERP", price)
+		}(int64(i * 100))
+		go func() {
+			defer wg.Done()
+			s.Refresh()
+		}()
+	}
+	wg.Wait()
+
+	// Whatever order the updates ran in, the last one published is the
+	// stored mark and the one the book holds.
+	p, _ := s.Get("BTC-PERP")
+	if last := published[len(published)-1]; last.Mark != p.Mark || book.marks["BTC-PERP"] != p.Mark {
+		t.Fatalf("stored %d, published %d, book %d", p.Mark, last.Mark, book.marks["BTC-PERP"])
+	}
+}
+
+func TestEMAResistsSinglePrint(t *testing.T) {
+	book := &fakeBook{marks: make(map[string]int64)}
+	s := prices.NewService(book, prints(10000, 10000, 10000, 20000))
+	if _, err := s.SetConfig("BTC-PERP", prices.Config{Sources: []prices.Source{prices.SourceEMA}, EMAPeriod: 9}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	s.Refresh()
+	// alpha = 2/10: the 20000 print moves the average by a fifth.
+	if p, _ := s.Get("BTC-PERP"); p.Mark != 12000 || p.Mid != 0 {
+		t.Fatalf("unexpected price %+v", p)
+	}
+}
+
+func TestStaleIndexIgnored(t *testing.T) {
+	book := &fakeBook{marks: make(map[string]int64)}
+	s := prices.NewService(book, prints())
+	if _, err := s.SetDefaultConfig(prices.Config{IndexMaxAgeMs: 20}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if p, _ := s.SetIndex("ETH-PERP", 3000); p.Mark != 3000 {
+		t.Fatalf("unexpected price %+v", p)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	s.Refresh()
+	if p, _ := s.Get("ETH-PERP"); p.Mark != 3000 {
+		t.Fatalf("expected the last mark kept without inputs, got %+v", p)
+	}
+	if book.marks["ETH-PERP"] != 3000 {
+		t.Fatalf("unexpected book mark %d", book.marks["ETH-PERP"])
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	s := prices.NewService(&fakeBook{marks: make(map[string]int64)}, prints())
+	for _, cfg := range []prices.Config{
+		{Sources: []prices.Source{"LAST"}},
+		{Sources: []prices.Source{prices.SourceMid, prices.SourceMid}},
+		{EMAPeriod: -1},
+	} {
+		if _, err := s.SetDefaultConfig(cfg); err != prices.ErrInvalidConfig {
+			t.Fatalf("expected ErrInvalidConfig for %+v, got %v", cfg, err)
+		}
+	}
+	if cfg := s.Config("X"); len(cfg.Sources) != 3 || cfg.EMAPeriod != prices.DefaultEMAPeriod {
+		t.Fatalf("unexpected default config %+v", cfg)
+	}
+}
+
+func TestIndexFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte(`{"BTC-PERP": 5000000}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	book := &fakeBook{marks: make(map[string]int64)}
+	s := prices.NewService(book, prints())
+	if err := s.LoadIndexFile(path); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if p, _ := s.Get("BTC-PERP"); p.Index != 5000000 || p.Mark != 5000000 {
+		t.Fatalf("unexpected price %+v", p)
+	}
+}
@@ -0,0 +1,124 @@
+package risk
+
+import (
+	"fmt"
+
+	"order-matching-engine/internal/common"
+)
+
+// DefaultChecks returns the built-in checks in evaluation order.
+func DefaultChecks() []Check {
+	return []Check{
+		CheckFunc{"max_order_qty", checkMaxOrderQty},
+		CheckFunc{"max_notional", checkMaxNotional},
+		CheckFunc{"price_collar", checkPriceCollar},
+		CheckFunc{"max_open_orders", checkMaxOpenOrders},
+		CheckFunc{"max_position", checkMaxPosition},
+		CheckFunc{"max_daily_notional", checkMaxDailyNotional},
+	}
+}
+
+// checkMaxOrderQty is the fat-finger quantity check.
+func checkMaxOrderQty(ctx *Context) *RejectError {
+	limit := ctx.Limits.MaxOrderQty
+	if limit > 0 && ctx.Order.Quantity > limit {
+		return &RejectError{
+			Code:   RejectMaxOrderQty,
+			Reason: fmt.Sprintf("quantity %d exceeds limit %d", ctx.Order.Quantity, limit),
+		}
+	}
+	return nil
+}
+
+func checkMaxNotional(ctx *Context) *RejectError {
+	limit := ctx.Limits.MaxNotional
+	if limit == 0 {
+		return nil
+	}
+	if n := ctx.Notional(); n > limit {
+		return &RejectError{
+			Code:   RejectMaxNotional,
+			Reason: fmt.Sprintf("notional %d exceeds limit %d", n, limit),
+		}
+	}
+	return nil
+}
+
+// checkPriceCollar rejects limit prices too far from the reference price.
+// Market and stop orders carry no limit price and are not collared.
+func checkPriceCollar(ctx *Context) *RejectError {
+	bps := ctx.Limits.PriceCollarBps
+	price := limitPrice(ctx.Order)
+	if bps == 0 || price == 0 {
+		return nil
+	}
+	ref := ctx.ReferencePrice()
+	if ref == 0 {
+		return nil // nothing to collar against yet
+	}
+	diff := price - ref
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff*10000 > bps*ref {
+		return &RejectError{
+			Code:   RejectPriceCollar,
+			Reason: fmt.Sprintf("price %d outside %d bps of reference %d", price, bps, ref),
+		}
+	}
+	return nil
+}
+
+// checkMaxOpenOrders counts every order that may rest, parked stops
+// included.
+func checkMaxOpenOrders(ctx *Context) *RejectError {
+	limit := ctx.Limits.MaxOpenOrders
+	if limit == 0 || ctx.Order.Account == "" || !mayRest(ctx.Order) {
+		return nil
+	}
+	if ctx.Market.OpenOrders >= limit {
+		return &RejectError{
+			Code:   RejectMaxOpenOrders,
+			Reason: fmt.Sprintf("account has %d open orders, limit %d", ctx.Market.OpenOrders, limit),
+		}
+	}
+	return nil
+}
+
+// checkMaxPosition assumes the order fills completely.
+func checkMaxPosition(ctx *Context) *RejectError {
+	limit := ctx.Limits.MaxPosition
+	if limit == 0 || ctx.Order.Account == "" {
+		return nil
+	}
+	pos := ctx.Position
+	if ctx.Order.Side == common.SideBuy {
+		pos += ctx.Order.Quantity
+	} else {
+		pos -= ctx.Order.Quantity
+	}
+	if pos < 0 {
+		pos = -pos
+	}
+	if pos > limit {
+		return &RejectError{
+			Code:   RejectMaxPosition,
+			Reason: fmt.Sprintf("resulting position %d exceeds limit %d", pos, limit),
+		}
+	}
+	return nil
+}
+
+func checkMaxDailyNotional(ctx *Context) *RejectError {
+	limit := ctx.Limits.MaxDailyNotional
+	if limit == 0 || ctx.Order.Account == "" {
+		return nil
+	}
+	if total := ctx.DailyNotional + ctx.Notional(); total > limit {
+		return &RejectError{
+			Code:   RejectMaxDailyNotional,
+			Reason: fmt.Sprintf("daily notional %d would exceed limit %d", total, limit),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package risk
+
+import (
+	"sync"
+	"time"
+
+	"order-matching-engine/internal/common"
+)
+
+// RejectCode identifies which pre-trade check rejected an order.
+type RejectCode string
+
+const (
+	RejectMaxOrderQty      RejectCode = "MAX_ORDER_QTY"
+	RejectMaxNotional      RejectCode = "MAX_NOTIONAL"
+	RejectPriceCollar      RejectCode = "PRICE_COLLAR"
+	RejectMaxOpenOrders    RejectCode = "MAX_OPEN_ORDERS"
+	RejectMaxPosition      RejectCode = "MAX_POSITION"
+	RejectMaxDailyNotional RejectCode = "MAX_DAILY_NOTIONAL"
+)
+
+// RejectError is returned when an order fails a pre-trade check.
+type RejectError struct {
+	Code   RejectCode
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return "risk reject " + string(e.Code) + ": " + e.Reason
+}
+
+// Limits holds the configurable thresholds. A zero value disables the check.
+type Limits struct {
+	MaxOrderQty      int64 `json:"max_order_qty"`
+	MaxNotional      int64 `json:"max_notional"`     // cents
+	PriceCollarBps   int64 `json:"price_collar_bps"` // max deviation from reference price
+	MaxOpenOrders    int   `json:"max_open_orders"`
+	MaxPosition      int64 `json:"max_position"`       // absolute net quantity per symbol
+	MaxDailyNotional int64 `json:"max_daily_notional"` // cents traded per UTC day
+}
+
+// override returns base with every limit set in l replacing its own.
+func override(base, l Limits) Limits {
+	return Limits{
+		MaxOrderQty:      orDefault(l.MaxOrderQty, base.MaxOrderQty),
+		MaxNotional:      orDefault(l.MaxNotional, base.MaxNotional),
+		PriceCollarBps:   orDefault(l.PriceCollarBps, base.PriceCollarBps),
+		MaxOpenOrders:    int(orDefault(int64(l.MaxOpenOrders), int64(base.MaxOpenOrders))),
+		MaxPosition:      orDefault(l.MaxPosition, base.MaxPosition),
+		MaxDailyNotional: orDefault(l.MaxDailyNotional, base.MaxDailyNotional),
+	}
+}
+
+func orDefault(v, def int64) int64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// tighter returns the most restrictive combination of two limit sets,
+// treating zero as "not set".
+func tighter(a, b Limits) Limits {
+	return Limits{
+		MaxOrderQty:      minNonZero(a.MaxOrderQty, b.MaxOrderQty),
+		MaxNotional:      minNonZero(a.MaxNotional, b.MaxNotional),
+		PriceCollarBps:   minNonZero(a.PriceCollarBps, b.PriceCollarBps),
+		MaxOpenOrders:    int(minNonZero(int64(a.MaxOpenOrders), int64(b.MaxOpenOrders))),
+		MaxPosition:      minNonZero(a.MaxPosition, b.MaxPosition),
+		MaxDailyNotional: minNonZero(a.MaxDailyNotional, b.MaxDailyNotional),
+	}
+}
+
+func minNonZero(a, b int64) int64 {
+	if a == 0 {
+		return b
+	}
+	if b == 0 || a < b {
+		return a
+	}
+	return b
+}
+
+// MarketState is the engine-side view of the book supplied with each check.
+type MarketState struct {
+	LastPrice  int64 // last trade price, 0 if none
+	BestBid    int64 // 0 if the side is empty
+	BestAsk    int64 // 0 if the side is empty
+	OpenOrders int   // resting orders of the order's account
+	Multiplier int64 // contract multiplier; 0 means 1
+}
+
+// Context carries everything a Check needs to evaluate one order.
+type Context struct {
+	Order         *common.Order
+	Limits        Limits
+	Market        MarketState
+	Position      int64 // current net position of the account in the symbol
+	DailyNotional int64 // notional already traded by the account today
+}
+
+// ReferencePrice is the last trade price, falling back to the BBO midpoint
+// or whichever side of the book is populated.
+func (c *Context) ReferencePrice() int64 {
+	if c.Market.LastPrice > 0 {
+		return c.Market.LastPrice
+	}
+	bid, ask := c.Market.BestBid, c.Market.BestAsk
+	switch {
+	case bid > 0 && ask > 0:
+		return (bid + ask) / 2
+	case bid > 0:
+		return bid
+	default:
+		return ask
+	}
+}
+
+// Notional estimates the order value in cents, scaled by the contract
+// multiplier. Strategy prices can be negative; the value is their absolute
+// value. Market orders are valued at the best opposite price, or the
+// reference price if that side is empty. Stop and trailing stop orders are
+// valued at their trigger, or the reference price while it is not set.
+func (c *Context) Notional() int64 {
+	o := c.Order
+	price := o.Price
+	switch o.Type {
+	case common.OrderTypeMarket:
+		if o.Side == common.SideBuy {
+			price = c.Market.BestAsk
+		} else {
+			price = c.Market.BestBid
+		}
+		if price == 0 {
+			price = c.ReferencePrice()
+		}
+	case common.OrderTypeStop, common.OrderTypeTrailingStop:
+		price = o.StopPrice
+		if price == 0 {
+			price = c.ReferencePrice()
+		}
+	}
+	if price < 0 {
+		price = -price
+	}
+	if c.Market.Multiplier > 1 {
+		return price * o.Quantity * c.Market.Multiplier
+	}
+	return price * o.Quantity
+}
+
+// limitPrice is the price an order may rest at: the limit of LIMIT and
+// STOP_LIMIT orders and the working price of PEG orders, or 0 for orders
+// that execute at whatever the market gives.
+func limitPrice(o *common.Order) int64 {
+	switch o.Type {
+	case common.OrderTypeLimit, common.OrderTypeStopLimit, common.OrderTypePeg:
+		return o.Price
+	}
+	return 0
+}
+
+// mayRest reports whether an order can end up resting, as a book order or
+// a parked stop; only market orders that kill their remainder cannot.
+func mayRest(o *common.Order) bool {
+	return o.Type != common.OrderTypeMarket || o.MarketMode == common.MarketModeMarketToLimit
+}
+
+// Check is a single pre-trade rule. Returning nil accepts the order.
+type Check interface {
+	Name() string
+	Check(ctx *Context) *RejectError
+}
+
+// CheckFunc adapts a plain function to the Check interface.
+type CheckFunc struct {
+	CheckName string
+	Fn        func(ctx *Context) *RejectError
+}
+
+func (c CheckFunc) Name() string                    { return c.CheckName }
+func (c CheckFunc) Check(ctx *Context) *RejectError { return c.Fn(ctx) }
+
+type dailyNotional struct {
+	day      string
+	notional int64
+}
+
+// Manager evaluates the configured checks and keeps the per-account state
+// (positions, daily traded notional) they depend on.
+type Manager struct {
+	mu       sync.RWMutex
+	defaults Limits
+	symbols  map[string]Limits // symbol -> limits
+	accounts map[string]Limits // account -> limits
+	checks   []Check
+
+	positions map[string]map[string]int64 // account -> symbol -> net qty
+	daily     map[string]*dailyNotional   // account -> today's notional
+
+	now func() time.Time
+}
+
+func NewManager(defaults Limits) *Manager {
+	return &Manager{
+		defaults:  defaults,
+		symbols:   make(map[string]Limits),
+		accounts:  make(map[string]Limits),
+		checks:    DefaultChecks(),
+		positions: make(map[string]map[string]int64),
+		daily:     make(map[string]*dailyNotional),
+		now:       time.Now,
+	}
+}
+
+func (rm *Manager) SetDefaultLimits(l Limits) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.defaults = l
+}
+
+func (rm *Manager) SetSymbolLimits(symbol string, l Limits) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.symbols[symbol] = l
+}
+
+func (rm *Manager) SetAccountLimits(account string, l Limits) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.accounts[account] = l
+}
+
+// AddCheck appends a custom check after the built-in ones.
+func (rm *Manager) AddCheck(c Check) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.checks = append(rm.checks, c)
+}
+
+// Limits returns the effective limits for an account trading a symbol.
+// Account limits replace the defaults, so an account may be allowed more
+// or less than them; symbol limits then cap the result.
+func (rm *Manager) Limits(account, symbol string) Limits {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return rm.limitsLocked(account, symbol)
+}
+
+func (rm *Manager) limitsLocked(account, symbol string) Limits {
+	l := rm.defaults
+	if account != "" {
+		l = override(l, rm.accounts[account])
+	}
+	return tighter(l, rm.symbols[symbol])
+}
+
+// Check runs every registered check against the order and returns the
+// first rejection as a *RejectError.
+func (rm *Manager) Check(o *common.Order, mkt MarketState) error {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	ctx := &Context{
+		Order:    o,
+		Limits:   rm.limitsLocked(o.Account, o.Symbol),
+		Market:   mkt,
+		Position: rm.positions[o.Account][o.Symbol],
+	}
+	if d, ok := rm.daily[o.Account]; ok && d.day == rm.today() {
+		ctx.DailyNotional = d.notional
+	}
+
+	for _, c := range rm.checks {
+		if rej := c.Check(ctx); rej != nil {
+			return rej
+		}
+	}
+	return nil
+}
+
+// OnFill updates position and daily notional for one side of a trade.
+func (rm *Manager) OnFill(account, symbol string, side common.Side, price, qty int64) {
+	rm.OnFillNotional(account, symbol, side, qty, price*qty)
+}
+
+// OnFillNotional is OnFill for trades whose notional is not simply price
+// times quantity, such as contracts with a multiplier.
+func (rm *Manager) OnFillNotional(account, symbol string, side common.Side, qty, notional int64) {
+	if account == "" {
+		return
+	}
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	pos := rm.positions[account]
+	if pos == nil {
+		pos = make(map[string]int64)
+		rm.positions[account] = pos
+	}
+	if side == common.SideBuy {
+		pos[symbol] += qty
+	} else {
+		pos[symbol] -= qty
+	}
+
+	today := rm.today()
+	d, ok := rm.daily[account]
+	if !ok || d.day != today {
+		d = &dailyNotional{day: today}
+		rm.daily[account] = d
+	}
+	d.notional += notional
+}
+
+// Position returns the net position tracked for an account and symbol.
+func (rm *Manager) Position(account, symbol string) int64 {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return rm.positions[account][symbol]
+}
+
+func (rm *Manager) today() string {
+	return rm.now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,142 @@
+package risk_test
+
+import (
+	"errors"
+	"testing"
+
+	"order-matching-engine/internal/common"
+	"order-matching-engine/internal/risk"
+)
+
+func limitOrder(account string, side common.Side, price, qty int64) *common.Order {
+	return &common.Order{
+		Account:  account,
+		Symbol:   "AAPL",
+		Side:     side,
+		Type:     common.OrderTypeLimit,
+		Price:    price,
+		Quantity: qty,
+	}
+}
+
+func typedOrder(typ common.OrderType, price, stop, qty int64) *common.Order {
+	o := limitOrder("A", common.SideBuy, price, qty)
+	o.Type, o.StopPrice = typ, stop
+	return o
+}
+
+func rejectCode(err error) risk.RejectCode {
+	var rej *risk.RejectError
+	if errors.As(err, &rej) {
+		return rej.Code
+	}
+	return ""
+}
+
+func TestChecks(t *testing.T) {
+	mkt := risk.MarketState{LastPrice: 10000, BestBid: 9990, BestAsk: 10010}
+
+	cases := []struct {
+		name   string
+		limits risk.Limits
+		order  *common.Order
+		mkt    risk.MarketState
+		want   risk.RejectCode
+	}{
+		{"no limits", risk.Limits{}, limitOrder("A", common.SideBuy, 10000, 1_000_000), mkt, ""},
+		{"fat finger qty", risk.Limits{MaxOrderQty: 500}, limitOrder("A", common.SideBuy, 10000, 501), mkt, risk.RejectMaxOrderQty},
+		{"qty at limit", risk.Limits{MaxOrderQty: 500}, limitOrder("A", common.SideBuy, 10000, 500), mkt, ""},
+		{"notional", risk.Limits{MaxNotional: 1_000_000}, limitOrder("A", common.SideBuy, 10000, 101), mkt, risk.RejectMaxNotional},
+		{"negative price notional", risk.Limits{MaxNotional: 1_000}, limitOrder("A", common.SideSell, -25, 5), risk.MarketState{Multiplier: 10}, risk.RejectMaxNotional},
+		{"collar above", risk.Limits{PriceCollarBps: 500}, limitOrder("A", common.SideBuy, 10501, 1), mkt, risk.RejectPriceCollar},
+		{"collar inside", risk.Limits{PriceCollarBps: 500}, limitOrder("A", common.SideSell, 9500, 1), mkt, ""},
+		{"collar vs bbo mid", risk.Limits{PriceCollarBps: 100}, limitOrder("A", common.SideSell, 9800, 1), risk.MarketState{BestBid: 9990, BestAsk: 10010}, risk.RejectPriceCollar},
+		{"open orders", risk.Limits{MaxOpenOrders: 2}, limitOrder("A", common.SideBuy, 10000, 1), risk.MarketState{OpenOrders: 2}, risk.RejectMaxOpenOrders},
+		{"position", risk.Limits{MaxPosition: 100}, limitOrder("A", common.SideSell, 10000, 101), mkt, risk.RejectMaxPosition},
+		{"anonymous skips account checks", risk.Limits{MaxPosition: 100}, limitOrder("", common.SideSell, 10000, 101), mkt, ""},
+
+		// Stops are valued at their trigger, or the reference price before one is set.
+		{"stop notional at trigger", risk.Limits{MaxNotional: 1_000_000}, typedOrder(common.OrderTypeStop, 0, 10500, 96), mkt, risk.RejectMaxNotional},
+		{"stop notional inside", risk.Limits{MaxNotional: 1_000_000}, typedOrder(common.OrderTypeStop, 0, 10500, 95), mkt, ""},
+		{"trailing stop notional at reference", risk.Limits{MaxNotional: 1_000_000}, typedOrder(common.OrderTypeTrailingStop, 0, 0, 101), mkt, risk.RejectMaxNotional},
+		{"stop daily notional", risk.Limits{MaxDailyNotional: 1_000_000}, typedOrder(common.OrderTypeStop, 0, 10500, 96), mkt, risk.RejectMaxDailyNotional},
+		{"stop limit notional", risk.Limits{MaxNotional: 1_000_000}, typedOrder(common.OrderTypeStopLimit, 10000, 9900, 101), mkt, risk.RejectMaxNotional},
+
+		// Every order with a limit or working price is collared.
+		{"stop limit collar", risk.Limits{PriceCollarBps: 500}, typedOrder(common.OrderTypeStopLimit, 10501, 10400, 1), mkt, risk.RejectPriceCollar},
+		{"peg collar", risk.Limits{PriceCollarBps: 500}, typedOrder(common.OrderTypePeg, 10501, 0, 1), mkt, risk.RejectPriceCollar},
+		{"stop not collared", risk.Limits{PriceCollarBps: 500}, typedOrder(common.OrderTypeStop, 0, 20000, 1), mkt, ""},
+
+		// Every order that may rest counts against the open-order limit.
+		{"peg open orders", risk.Limits{MaxOpenOrders: 2}, typedOrder(common.OrderTypePeg, 10000, 0, 1), risk.MarketState{OpenOrders: 2}, risk.RejectMaxOpenOrders},
+		{"stop open orders", risk.Limits{MaxOpenOrders: 2}, typedOrder(common.OrderTypeStop, 0, 10500, 1), risk.MarketState{OpenOrders: 2}, risk.RejectMaxOpenOrders},
+		{"stop limit open orders", risk.Limits{MaxOpenOrders: 2}, typedOrder(common.OrderTypeStopLimit, 10000, 9900, 1), risk.MarketState{OpenOrders: 2}, risk.RejectMaxOpenOrders},
+		{"trailing stop open orders", risk.Limits{MaxOpenOrders: 2}, typedOrder(common.OrderTypeTrailingStop, 0, 0, 1), risk.MarketState{OpenOrders: 2}, risk.RejectMaxOpenOrders},
+		{"market never rests", risk.Limits{MaxOpenOrders: 2}, typedOrder(common.OrderTypeMarket, 0, 0, 1), risk.MarketState{OpenOrders: 2}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rm := risk.NewManager(tc.limits)
+			got := rejectCode(rm.Check(tc.order, tc.mkt))
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPositionAndDailyNotionalTracking(t *testing.T) {
+	rm := risk.NewManager(risk.Limits{MaxPosition: 150, MaxDailyNotional: 2_000_000})
+	rm.OnFill("A", "AAPL", common.SideBuy, 10000, 100)
+
+	if pos := rm.Position("A", "AAPL"); pos != 100 {
+		t.Fatalf("expected position 100, got %d", pos)
+	}
+
+	// 100 + 60 breaches the position limit.
+	err := rm.Check(limitOrder("A", common.SideBuy, 10000, 60), risk.MarketState{})
+	if rejectCode(err) != risk.RejectMaxPosition {
+		t.Fatalf("expected MAX_POSITION, got %v", err)
+	}
+
+	// Selling reduces the position but 1,000,000 + 1,010,000 breaches daily notional.
+	err = rm.Check(limitOrder("A", common.SideSell, 10100, 100), risk.MarketState{})
+	if rejectCode(err) != risk.RejectMaxDailyNotional {
+		t.Fatalf("expected MAX_DAILY_NOTIONAL, got %v", err)
+	}
+}
+
+func TestAccountLimitsReplaceDefaultsAndSymbolLimitsCap(t *testing.T) {
+	rm := risk.NewManager(risk.Limits{MaxOrderQty: 1000, MaxOpenOrders: 10})
+	rm.SetSymbolLimits("AAPL", risk.Limits{MaxOrderQty: 500, MaxNotional: 10_000_000})
+	rm.SetAccountLimits("A", risk.Limits{MaxOrderQty: 2000, MaxOpenOrders: 30})
+
+	l := rm.Limits("A", "AAPL")
+	if l.MaxOrderQty != 500 || l.MaxNotional != 10_000_000 || l.MaxOpenOrders != 30 {
+		t.Fatalf("unexpected effective limits: %+v", l)
+	}
+	if l := rm.Limits("A", "MSFT"); l.MaxOrderQty != 2000 {
+		t.Fatalf("an account limit must replace the default, got %+v", l)
+	}
+	if l := rm.Limits("B", "MSFT"); l.MaxOrderQty != 1000 || l.MaxOpenOrders != 10 {
+		t.Fatalf("unexpected default limits: %+v", l)
+	}
+}
+
+func TestCustomCheck(t *testing.T) {
+	rm := risk.NewManager(risk.Limits{})
+	rm.AddCheck(risk.CheckFunc{
+		CheckName: "no_sells",
+		Fn: func(ctx *risk.Context) *risk.RejectError {
+			if ctx.Order.Side == common.SideSell {
+				return &risk.RejectError{Code: "NO_SELLS", Reason: "sells disabled"}
+			}
+			return nil
+		},
+	})
+
+	if err := rm.Check(limitOrder("A", common.SideSell, 10000, 1), risk.MarketState{}); rejectCode(err) != "NO_SELLS" {
+		t.Fatalf("expected custom reject, got %v", err)
+	}
+}